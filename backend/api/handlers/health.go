@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"poem/backend/pkg/buildinfo"
+	"poem/backend/services/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 存活和就绪探针
+type HealthHandler struct {
+	service *health.HealthService
+}
+
+// NewHealthHandler 创建健康检查处理器
+func NewHealthHandler(service *health.HealthService) *HealthHandler {
+	return &HealthHandler{service: service}
+}
+
+// Liveness 进程存活即返回 200，不访问数据库，避免数据库故障时编排系统反复重启服务
+// @Summary 存活检查
+// @Tags 运维
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"build":  buildinfo.Get(),
+	})
+}
+
+// Readiness 检查数据库、表结构、数据和搜索索引，有检查失败时返回 503
+// @Summary 就绪检查
+// @Tags 运维
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.service.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"net/http"
 	"poem/backend/models"
+	"poem/backend/pkg/buildinfo"
 	"poem/backend/services"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,6 +18,9 @@ import (
 // PoetryHandler 诗词处理器
 type PoetryHandler struct {
 	service *services.PoetryService
+
+	httpCache bool
+	maxAge    time.Duration
 }
 
 // NewPoetryHandler 创建诗词处理器
@@ -19,6 +28,13 @@ func NewPoetryHandler(service *services.PoetryService) *PoetryHandler {
 	return &PoetryHandler{service: service}
 }
 
+// EnableHTTPCache 分类、作者列表和诗词详情返回 ETag、Last-Modified 和 Cache-Control: public, max-age，
+// 客户端带 If-None-Match/If-Modified-Since 重新验证时，数据未变化则返回 304
+func (h *PoetryHandler) EnableHTTPCache(maxAge time.Duration) {
+	h.httpCache = true
+	h.maxAge = maxAge
+}
+
 // GetPoems 获取诗词列表
 // @Summary 获取诗词列表
 // @Tags 诗词
@@ -27,19 +43,18 @@ func NewPoetryHandler(service *services.PoetryService) *PoetryHandler {
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
 // @Param category query string false "分类"
+// @Param anthology query string false "选集（如 tangshi300）"
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param with_total query bool false "是否统计总数" default(true)
 // @Success 200 {object} models.APIResponse
 // @Router /poems [get]
 func (h *PoetryHandler) GetPoems(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 	category := c.Query("category")
+	anthology := c.Query("anthology")
 
-	result, err := h.service.GetPoems(page, pageSize, category)
+	result, err := h.service.GetPoems(c.Request.Context(), pageQuery(c), category, anthology)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		listError(c, err)
 		return
 	}
 
@@ -58,10 +73,14 @@ func (h *PoetryHandler) GetPoems(c *gin.Context) {
 // @Success 200 {object} models.APIResponse
 // @Router /poems/{id} [get]
 func (h *PoetryHandler) GetPoemByID(c *gin.Context) {
+	if h.notModified(c) {
+		return
+	}
 	id := c.Param("id")
 
-	poem, err := h.service.GetPoemByID(id)
+	poem, err := h.service.GetPoemByID(c.Request.Context(), id)
 	if err != nil {
+		dropValidators(c)
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Error:   "诗词不存在",
@@ -88,7 +107,7 @@ func (h *PoetryHandler) GetRandomPoem(c *gin.Context) {
 	count, _ := strconv.Atoi(c.DefaultQuery("count", "1"))
 	category := c.Query("category")
 
-	poems, err := h.service.GetRandomPoems(count, category)
+	poems, err := h.service.GetRandomPoems(c.Request.Context(), count, category)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -111,6 +130,8 @@ func (h *PoetryHandler) GetRandomPoem(c *gin.Context) {
 // @Param q query string true "搜索关键词"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param with_total query bool false "是否统计总数" default(true)
 // @Success 200 {object} models.APIResponse
 // @Router /search [get]
 func (h *PoetryHandler) Search(c *gin.Context) {
@@ -123,15 +144,9 @@ func (h *PoetryHandler) Search(c *gin.Context) {
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-
-	result, err := h.service.Search(query, page, pageSize)
+	result, err := h.service.Search(c.Request.Context(), query, pageQuery(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		listError(c, err)
 		return
 	}
 
@@ -149,14 +164,18 @@ func (h *PoetryHandler) Search(c *gin.Context) {
 // @Success 200 {object} models.APIResponse
 // @Router /dynasties [get]
 func (h *PoetryHandler) GetDynasties(c *gin.Context) {
-	// This method might need to be removed or refactored if dynasties are no longer a primary entity.
-	// For now, returning empty list or static list if needed.
-	// In the new design, dynasties are properties of authors.
-	// We could return a distinct list of dynasties from the authors table, but that's a new repo method.
-	// Leaving it empty or static for now.
+	dynasties, err := h.service.GetDynasties(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    []string{},
+		Data:    dynasties,
 	})
 }
 
@@ -168,7 +187,10 @@ func (h *PoetryHandler) GetDynasties(c *gin.Context) {
 // @Success 200 {object} models.APIResponse
 // @Router /categories [get]
 func (h *PoetryHandler) GetCategories(c *gin.Context) {
-	categories := h.service.GetCategories()
+	if h.notModified(c) {
+		return
+	}
+	categories := h.service.GetCategories(c.Request.Context())
 
 	c.JSON(http.StatusOK, models.APIResponse{
 		Success: true,
@@ -176,6 +198,22 @@ func (h *PoetryHandler) GetCategories(c *gin.Context) {
 	})
 }
 
+// GetAnthologies 获取选集列表
+// @Summary 获取选集列表
+// @Tags 目录
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Router /anthologies [get]
+func (h *PoetryHandler) GetAnthologies(c *gin.Context) {
+	anthologies := h.service.GetAnthologies(c.Request.Context())
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    anthologies,
+	})
+}
+
 // GetAuthors 获取作者列表
 // @Summary 获取作者列表
 // @Tags 作者
@@ -184,19 +222,32 @@ func (h *PoetryHandler) GetCategories(c *gin.Context) {
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
 // @Param dynasty query string false "朝代"
+// @Param alive_in query int false "该年在世的作者"
+// @Param born_after query int false "生年不早于（公元前为负数）"
+// @Param born_before query int false "生年不晚于（公元前为负数）"
+// @Param sort query string false "排序字段：id, name, birth_year, death_year" default(id)
+// @Param order query string false "asc 或 desc" default(asc)
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param with_total query bool false "是否统计总数" default(true)
 // @Success 200 {object} models.APIResponse
 // @Router /authors [get]
 func (h *PoetryHandler) GetAuthors(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
-	dynasty := c.Query("dynasty")
+	if h.notModified(c) {
+		return
+	}
+	filter := models.AuthorFilter{
+		Dynasty:    c.Query("dynasty"),
+		AliveIn:    queryInt(c, "alive_in"),
+		BornAfter:  queryInt(c, "born_after"),
+		BornBefore: queryInt(c, "born_before"),
+		SortBy:     c.Query("sort"),
+		Desc:       c.Query("order") == "desc",
+	}
 
-	result, err := h.service.GetAuthors(page, pageSize, dynasty)
+	result, err := h.service.GetAuthors(c.Request.Context(), pageQuery(c), filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		dropValidators(c)
+		listError(c, err)
 		return
 	}
 
@@ -211,13 +262,15 @@ func (h *PoetryHandler) GetAuthors(c *gin.Context) {
 // @Tags 作者
 // @Accept json
 // @Produce json
-// @Param name path string true "作者名称"
+// @Param name path string true "作者名称（可为字、号等别名）"
+// @Param dynasty query string false "朝代（同名作者时用于区分）"
 // @Success 200 {object} models.APIResponse
 // @Router /authors/{name} [get]
 func (h *PoetryHandler) GetAuthorByName(c *gin.Context) {
 	name := c.Param("name")
+	dynasty := c.Query("dynasty")
 
-	author, err := h.service.GetAuthorByName(name)
+	author, err := h.service.GetAuthorByName(c.Request.Context(), name, dynasty)
 	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
@@ -237,22 +290,21 @@ func (h *PoetryHandler) GetAuthorByName(c *gin.Context) {
 // @Tags 作者
 // @Accept json
 // @Produce json
-// @Param name path string true "作者名称"
+// @Param name path string true "作者名称（可为字、号等别名）"
+// @Param dynasty query string false "朝代（同名作者时用于区分）"
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(20)
+// @Param cursor query string false "上一页返回的 next_cursor"
+// @Param with_total query bool false "是否统计总数" default(true)
 // @Success 200 {object} models.APIResponse
 // @Router /authors/{name}/poems [get]
 func (h *PoetryHandler) GetAuthorPoems(c *gin.Context) {
 	name := c.Param("name")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	dynasty := c.Query("dynasty")
 
-	result, err := h.service.GetPoemsByAuthor(name, page, pageSize)
+	result, err := h.service.GetPoemsByAuthor(c.Request.Context(), name, dynasty, pageQuery(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.APIResponse{
-			Success: false,
-			Error:   err.Error(),
-		})
+		listError(c, err)
 		return
 	}
 
@@ -261,3 +313,100 @@ func (h *PoetryHandler) GetAuthorPoems(c *gin.Context) {
 		Data:    result,
 	})
 }
+
+// pageQuery 读取分页参数：page、page_size、cursor、with_total
+func pageQuery(c *gin.Context) models.PageQuery {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size")) // 未指定时使用配置的默认值
+	return models.PageQuery{
+		Page:      page,
+		PageSize:  pageSize,
+		Cursor:    c.Query("cursor"),
+		WithTotal: c.DefaultQuery("with_total", "true") != "false",
+	}
+}
+
+// listError 列表接口的错误响应，无效游标返回 400
+func listError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Error:   "无效的分页游标",
+		})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Error:   err.Error(),
+	})
+}
+
+// queryInt 读取可选的整数查询参数，缺失或非法时返回 nil
+func queryInt(c *gin.Context, key string) *int {
+	v, err := strconv.Atoi(c.Query(key))
+	if err != nil {
+		return nil
+	}
+	return &v
+}
+
+// notModified 写入 ETag、Last-Modified 和 Cache-Control，客户端的缓存仍然有效时返回 304。
+// ETag 由数据版本、程序版本和请求 URL 决定，语料变化或升级程序后失效；读取数据版本失败时不做协商缓存
+func (h *PoetryHandler) notModified(c *gin.Context) bool {
+	if !h.httpCache {
+		return false
+	}
+	version, err := h.service.DataVersion(c.Request.Context())
+	if err != nil {
+		return false
+	}
+
+	build := buildinfo.Get()
+	sum := fnv.New64a()
+	fmt.Fprintf(sum, "%d|%s|%s|%s", version.Version, build.Version, build.Commit, c.Request.URL.RequestURI())
+	etag := fmt.Sprintf(`W/"%x"`, sum.Sum64())
+	lastModified := version.UpdatedAt.UTC().Truncate(time.Second)
+
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	// 这些接口的响应与登录用户无关，允许 CDN 等共享缓存保存
+	if h.maxAge > 0 {
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.maxAge.Seconds())))
+	} else {
+		c.Header("Cache-Control", "public, no-cache")
+	}
+
+	// If-None-Match 优先，存在时忽略 If-Modified-Since
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.After(since) {
+			return false
+		}
+	}
+	c.AbortWithStatus(http.StatusNotModified)
+	return true
+}
+
+// etagMatches If-None-Match 中是否有与 etag 弱匹配的值
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// dropValidators 错误响应不带 ETag 和 Last-Modified，也不允许缓存
+func dropValidators(c *gin.Context) {
+	c.Header("ETag", "")
+	c.Header("Last-Modified", "")
+	c.Header("Cache-Control", "no-store")
+}
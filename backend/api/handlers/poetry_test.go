@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"poem/backend/services"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCategoriesNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo, db := testdb.Poetry(t)
+	category := models.Category{Name: "tangshi", DisplayName: "唐诗"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	service := services.NewPoetryService(repo)
+	h := NewPoetryHandler(service)
+	h.EnableHTTPCache(time.Minute)
+	router := gin.New()
+	router.GET("/categories", h.GetCategories)
+
+	get := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/categories", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := get("", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("first response %d with ETag %q", first.Code, etag)
+	}
+	if cc := first.Header().Get("Cache-Control"); cc != "public, max-age=60" {
+		t.Fatalf("Cache-Control = %q, want public, max-age=60", cc)
+	}
+
+	revalidated := get("If-None-Match", etag)
+	if revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+		t.Fatalf("revalidation with matching ETag = %d, body %q", revalidated.Code, revalidated.Body.String())
+	}
+	if got := revalidated.Header().Get("ETag"); got != etag {
+		t.Fatalf("304 ETag = %q, want %q", got, etag)
+	}
+	if got := get("If-None-Match", `W/"other", `+etag).Code; got != http.StatusNotModified {
+		t.Fatalf("ETag in a list = %d, want 304", got)
+	}
+	if got := get("If-None-Match", `W/"other"`).Code; got != http.StatusOK {
+		t.Fatalf("other ETag = %d, want 200", got)
+	}
+
+	// 语料变化后旧 ETag 失效
+	if err := repository.BumpDataVersion(db, models.DataVersionCorpus); err != nil {
+		t.Fatal(err)
+	}
+	changed := get("If-None-Match", etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Fatalf("after data change = %d with ETag %q", changed.Code, changed.Header().Get("ETag"))
+	}
+}
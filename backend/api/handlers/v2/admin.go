@@ -0,0 +1,447 @@
+package v2
+
+import (
+	"poem/backend/api/middleware"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/pkg/response"
+	"poem/backend/services/corpus"
+	"poem/backend/services/user"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 管理后台处理器
+type AdminHandler struct {
+	userService   *user.UserService
+	corpusService *corpus.CorpusService
+}
+
+// NewAdminHandler 创建管理后台处理器
+func NewAdminHandler(userService *user.UserService, corpusService *corpus.CorpusService) *AdminHandler {
+	return &AdminHandler{
+		userService:   userService,
+		corpusService: corpusService,
+	}
+}
+
+// SetUserRole 修改用户角色，用户需重新登录后新角色才生效
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	info, err := h.userService.SetRole(c.Request.Context(), uint(id), req.Role)
+	if err != nil {
+		switch err {
+		case user.ErrInvalidRole:
+			response.BadRequest(c, err.Error())
+		case user.ErrUserNotFound:
+			response.NotFound(c, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.Success(c, info)
+}
+
+// GetWork 获取作品及其修改记录
+func (h *AdminHandler) GetWork(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.corpusService.GetWork(c.Request.Context(), id)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// CreateWork 新建作品
+func (h *AdminHandler) CreateWork(c *gin.Context) {
+	var req corpus.WorkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	work, err := h.corpusService.CreateWork(c.Request.Context(), corpusEdit(c, req.Reason), &req)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, work)
+}
+
+// UpdateWork 修改作品，只更新请求中出现的字段
+func (h *AdminHandler) UpdateWork(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var req corpus.WorkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	work, err := h.corpusService.UpdateWork(c.Request.Context(), corpusEdit(c, req.Reason), id, &req)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, work)
+}
+
+// DeleteWork 删除作品
+func (h *AdminHandler) DeleteWork(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.corpusService.DeleteWork(c.Request.Context(), corpusEdit(c, c.Query("reason")), id); err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "作品已删除", nil)
+}
+
+// GetAuthor 获取作者
+func (h *AdminHandler) GetAuthor(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	author, err := h.corpusService.GetAuthor(c.Request.Context(), id)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, author)
+}
+
+// CreateAuthor 新建作者
+func (h *AdminHandler) CreateAuthor(c *gin.Context) {
+	var req corpus.AuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	author, err := h.corpusService.CreateAuthor(c.Request.Context(), corpusEdit(c, req.Reason), &req)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, author)
+}
+
+// UpdateAuthor 修改作者，只更新请求中出现的字段
+func (h *AdminHandler) UpdateAuthor(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var req corpus.AuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	author, err := h.corpusService.UpdateAuthor(c.Request.Context(), corpusEdit(c, req.Reason), id, &req)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, author)
+}
+
+// DeleteAuthor 删除没有作品的作者
+func (h *AdminHandler) DeleteAuthor(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.corpusService.DeleteAuthor(c.Request.Context(), corpusEdit(c, c.Query("reason")), id); err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "作者已删除", nil)
+}
+
+// ListComments 列出注释，可按 work_id、type 过滤
+func (h *AdminHandler) ListComments(c *gin.Context) {
+	filter := models.CommentFilter{Type: c.Query("type")}
+	if workID, err := strconv.ParseUint(c.Query("work_id"), 10, 32); err == nil {
+		filter.WorkID = uint(workID)
+	}
+
+	result, err := h.corpusService.ListComments(c.Request.Context(), filter, pageQuery(c))
+	if err == corpus.ErrInvalidCursor {
+		response.BadRequest(c, "无效的分页游标")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetComment 获取注释
+func (h *AdminHandler) GetComment(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	comment, err := h.corpusService.GetComment(c.Request.Context(), id)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, comment)
+}
+
+// CreateComment 为作品新增注释
+func (h *AdminHandler) CreateComment(c *gin.Context) {
+	var req corpus.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	comment, err := h.corpusService.CreateComment(c.Request.Context(), corpusEdit(c, req.Reason), &req)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, comment)
+}
+
+// UpdateComment 修改注释
+func (h *AdminHandler) UpdateComment(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var req corpus.CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	comment, err := h.corpusService.UpdateComment(c.Request.Context(), corpusEdit(c, req.Reason), id, &req)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, comment)
+}
+
+// DeleteComment 删除注释
+func (h *AdminHandler) DeleteComment(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.corpusService.DeleteComment(c.Request.Context(), corpusEdit(c, c.Query("reason")), id); err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "注释已删除", nil)
+}
+
+// ListOverrides 列出后台对语料的修改记录，可按 entity_type（work/author）过滤
+func (h *AdminHandler) ListOverrides(c *gin.Context) {
+	overrides, err := h.corpusService.ListOverrides(c.Request.Context(), c.Query("entity_type"))
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, overrides)
+}
+
+// ListRevisions 列出修订历史，可按 entity_type、editor_id 过滤
+func (h *AdminHandler) ListRevisions(c *gin.Context) {
+	filter := models.RevisionFilter{EntityType: c.Query("entity_type")}
+	if editorID, err := strconv.ParseUint(c.Query("editor_id"), 10, 32); err == nil {
+		filter.EditorID = uint(editorID)
+	}
+	h.listRevisions(c, filter)
+}
+
+// ListWorkRevisions 列出作品及其注释的修订历史
+func (h *AdminHandler) ListWorkRevisions(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	h.listRevisions(c, models.RevisionFilter{WorkID: id})
+}
+
+// ListAuthorRevisions 列出作者的修订历史
+func (h *AdminHandler) ListAuthorRevisions(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	h.listRevisions(c, models.RevisionFilter{EntityType: models.OverrideEntityAuthor, EntityID: id})
+}
+
+func (h *AdminHandler) listRevisions(c *gin.Context, filter models.RevisionFilter) {
+	result, err := h.corpusService.ListRevisions(c.Request.Context(), filter, pageQuery(c))
+	if err == corpus.ErrInvalidCursor {
+		response.BadRequest(c, "无效的分页游标")
+		return
+	}
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetRevisionDiff 获取修订前后的逐行差异
+func (h *AdminHandler) GetRevisionDiff(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	diff, err := h.corpusService.DiffRevision(c.Request.Context(), id)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.Success(c, diff)
+}
+
+// RollbackRevision 回滚到指定修订完成后的状态
+func (h *AdminHandler) RollbackRevision(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"max=255"`
+	}
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "参数错误: "+err.Error())
+			return
+		}
+	}
+
+	// 修订列表按作品编辑权限开放，回滚还需要修订对象对应的编辑权限
+	revision, err := h.corpusService.GetRevision(c.Request.Context(), id)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+	if !auth.HasPermission(middleware.GetRole(c), revisionPermission(revision.EntityType)) {
+		response.Forbidden(c, "无权回滚该修订")
+		return
+	}
+
+	result, err := h.corpusService.Rollback(c.Request.Context(), corpusEdit(c, req.Reason), id)
+	if err != nil {
+		corpusError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已回滚", result)
+}
+
+// revisionPermission 回滚修订所需的权限
+func revisionPermission(entityType string) string {
+	switch entityType {
+	case models.OverrideEntityAuthor:
+		return auth.PermEditAuthors
+	case models.RevisionEntityComment:
+		return auth.PermModerateComments
+	default:
+		return auth.PermEditWorks
+	}
+}
+
+// corpusEdit 当前编辑及修改原因
+func corpusEdit(c *gin.Context, reason string) corpus.Edit {
+	editorID, _ := middleware.GetUserID(c)
+	return corpus.Edit{EditorID: editorID, Reason: reason}
+}
+
+// pageQuery 读取分页参数：page、page_size、cursor、with_total
+func pageQuery(c *gin.Context) models.PageQuery {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.Query("page_size")) // 未指定时使用配置的默认值
+	return models.PageQuery{
+		Page:      page,
+		PageSize:  pageSize,
+		Cursor:    c.Query("cursor"),
+		WithTotal: c.DefaultQuery("with_total", "true") != "false",
+	}
+}
+
+// idParam 解析路径中的 :id，失败时直接返回 400
+func idParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func corpusError(c *gin.Context, err error) {
+	switch err {
+	case corpus.ErrWorkNotFound, corpus.ErrAuthorNotFound, corpus.ErrCommentNotFound, corpus.ErrRevisionNotFound:
+		response.NotFound(c, err.Error())
+	case corpus.ErrCategoryNotFound, corpus.ErrDynastyNotFound, corpus.ErrIncompleteWork, corpus.ErrIncompleteAuthor:
+		response.BadRequest(c, err.Error())
+	case corpus.ErrAuthorHasWorks, corpus.ErrNothingToRollback, corpus.ErrRevisionStale:
+		response.Error(c, 409, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
@@ -0,0 +1,148 @@
+package v2
+
+import (
+	"poem/backend/api/middleware"
+	"poem/backend/models"
+	"poem/backend/pkg/response"
+	"poem/backend/services/corpus"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CorrectionHandler 读者纠错处理器
+type CorrectionHandler struct {
+	correctionService *corpus.CorrectionService
+}
+
+// NewCorrectionHandler 创建纠错处理器
+func NewCorrectionHandler(correctionService *corpus.CorrectionService) *CorrectionHandler {
+	return &CorrectionHandler{
+		correctionService: correctionService,
+	}
+}
+
+// Submit 为作品提交纠错
+func (h *CorrectionHandler) Submit(c *gin.Context) {
+	workID, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var req corpus.CorrectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	correction, err := h.correctionService.Submit(c.Request.Context(), userID, workID, &req)
+	if err != nil {
+		correctionError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "已提交，等待编辑审核", correction)
+}
+
+// ListMine 获取当前用户提交的纠错
+func (h *CorrectionHandler) ListMine(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	result, err := h.correctionService.ListByUser(c.Request.Context(), userID, pageQuery(c))
+	if err != nil {
+		correctionError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// ListQueue 审核队列，默认只列出待审核的纠错，status=all 列出全部
+func (h *CorrectionHandler) ListQueue(c *gin.Context) {
+	filter := models.CorrectionFilter{Status: c.DefaultQuery("status", models.CorrectionPending)}
+	if filter.Status == "all" {
+		filter.Status = ""
+	}
+
+	result, err := h.correctionService.List(c.Request.Context(), filter, pageQuery(c))
+	if err != nil {
+		correctionError(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Get 获取纠错详情及与当前版本的差异
+func (h *CorrectionHandler) Get(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	detail, err := h.correctionService.Get(c.Request.Context(), id)
+	if err != nil {
+		correctionError(c, err)
+		return
+	}
+
+	response.Success(c, detail)
+}
+
+// Approve 采纳纠错
+func (h *CorrectionHandler) Approve(c *gin.Context) {
+	h.review(c, true)
+}
+
+// Reject 驳回纠错，note 为必填的驳回原因
+func (h *CorrectionHandler) Reject(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *CorrectionHandler) review(c *gin.Context, approve bool) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	var req corpus.ReviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "参数错误: "+err.Error())
+			return
+		}
+	}
+
+	reviewerID, _ := middleware.GetUserID(c)
+	var (
+		correction *models.Correction
+		err        error
+	)
+	if approve {
+		correction, err = h.correctionService.Approve(c.Request.Context(), reviewerID, id, req.Note)
+	} else {
+		correction, err = h.correctionService.Reject(c.Request.Context(), reviewerID, id, req.Note)
+	}
+	if err != nil {
+		correctionError(c, err)
+		return
+	}
+
+	response.Success(c, correction)
+}
+
+func correctionError(c *gin.Context, err error) {
+	switch err {
+	case corpus.ErrCorrectionNotFound:
+		response.NotFound(c, err.Error())
+	case corpus.ErrEmptyCorrection, corpus.ErrRejectReason:
+		response.BadRequest(c, err.Error())
+	case corpus.ErrCorrectionReviewed:
+		response.Error(c, 409, err.Error())
+	case corpus.ErrTooManyCorrections:
+		response.Error(c, 429, err.Error())
+	case corpus.ErrInvalidCursor:
+		response.BadRequest(c, "无效的分页游标")
+	default:
+		corpusError(c, err)
+	}
+}
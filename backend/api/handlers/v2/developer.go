@@ -0,0 +1,99 @@
+package v2
+
+import (
+	"poem/backend/api/middleware"
+	"poem/backend/pkg/response"
+	"poem/backend/services/developer"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeveloperHandler 第三方开发者API密钥处理器
+type DeveloperHandler struct {
+	apiKeyService *developer.APIKeyService
+}
+
+// NewDeveloperHandler 创建开发者处理器
+func NewDeveloperHandler(apiKeyService *developer.APIKeyService) *DeveloperHandler {
+	return &DeveloperHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateKey 创建API密钥，明文只在此时返回一次
+func (h *DeveloperHandler) CreateKey(c *gin.Context) {
+	var req developer.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	created, err := h.apiKeyService.Create(c.Request.Context(), userID, &req)
+	if err != nil {
+		developerError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "请妥善保存密钥，它不会再次显示", created)
+}
+
+// ListKeys 列出当前用户的API密钥
+func (h *DeveloperHandler) ListKeys(c *gin.Context) {
+	userID, _ := middleware.GetUserID(c)
+	keys, err := h.apiKeyService.List(c.Request.Context(), userID)
+	if err != nil {
+		developerError(c, err)
+		return
+	}
+
+	response.Success(c, keys)
+}
+
+// RevokeKey 吊销API密钥
+func (h *DeveloperHandler) RevokeKey(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(c)
+	if err := h.apiKeyService.Revoke(c.Request.Context(), userID, id); err != nil {
+		developerError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "密钥已吊销", nil)
+}
+
+// KeyUsage 获取API密钥最近每天的调用次数，days 默认 30，最多 90
+func (h *DeveloperHandler) KeyUsage(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+
+	userID, _ := middleware.GetUserID(c)
+	report, err := h.apiKeyService.Usage(c.Request.Context(), userID, id, days)
+	if err != nil {
+		developerError(c, err)
+		return
+	}
+
+	response.Success(c, report)
+}
+
+func developerError(c *gin.Context, err error) {
+	switch err {
+	case developer.ErrAPIKeyNotFound:
+		response.NotFound(c, err.Error())
+	case developer.ErrInvalidScope:
+		response.BadRequest(c, err.Error())
+	case developer.ErrTooManyAPIKeys:
+		response.Error(c, 409, err.Error())
+	default:
+		response.InternalError(c, err.Error())
+	}
+}
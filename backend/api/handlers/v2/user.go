@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"errors"
 	"poem/backend/api/middleware"
 	"poem/backend/pkg/response"
 	"poem/backend/services/user"
@@ -29,7 +30,7 @@ func (h *UserHandler) Register(c *gin.Context) {
 		return
 	}
 
-	result, err := h.userService.Register(c.Request.Context(), &req)
+	result, err := h.userService.Register(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
 		if err == user.ErrUserAlreadyExists {
 			response.Error(c, 409, "用户名已存在")
@@ -50,8 +51,14 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	result, err := h.userService.Login(c.Request.Context(), &req)
+	result, err := h.userService.Login(c.Request.Context(), &req, clientInfo(c))
 	if err != nil {
+		var locked *user.LoginLockedError
+		if errors.As(err, &locked) {
+			c.Header("Retry-After", strconv.Itoa(locked.RetryAfterSeconds()))
+			response.Error(c, 429, locked.Error())
+			return
+		}
 		if err == user.ErrInvalidCredentials {
 			response.Error(c, 401, "用户名或密码错误")
 			return
@@ -84,10 +91,10 @@ func (h *UserHandler) GetProfile(c *gin.Context) {
 	response.Success(c, profile)
 }
 
-// RefreshToken 刷新token
+// RefreshToken 使用刷新令牌换取新的access token，刷新令牌同时轮换
 func (h *UserHandler) RefreshToken(c *gin.Context) {
 	var req struct {
-		Token string `json:"token" binding:"required"`
+		RefreshToken string `json:"refresh_token" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -95,15 +102,71 @@ func (h *UserHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	newToken, err := h.userService.RefreshToken(req.Token)
+	result, err := h.userService.RefreshSession(c.Request.Context(), req.RefreshToken, clientInfo(c))
+	if err != nil {
+		if err == user.ErrInvalidRefreshToken || err == user.ErrRefreshTokenReused || err == user.ErrUserDisabled {
+			response.Unauthorized(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// Logout 注销当前会话
+func (h *UserHandler) Logout(c *gin.Context) {
+	claims, exists := middleware.GetClaims(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	if err := h.userService.Logout(c.Request.Context(), claims); err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "已退出登录", nil)
+}
+
+// ListSessions 列出当前用户的登录会话
+func (h *UserHandler) ListSessions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	sessions, err := h.userService.ListSessions(c.Request.Context(), userID, middleware.GetSessionID(c))
 	if err != nil {
-		response.Unauthorized(c, "Token刷新失败")
+		response.InternalError(c, err.Error())
 		return
 	}
 
-	response.Success(c, gin.H{
-		"token": newToken,
-	})
+	response.Success(c, sessions)
+}
+
+// RevokeSession 注销指定会话（如在其他设备上登出）
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	err := h.userService.RevokeSession(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		if err == user.ErrSessionNotFound {
+			response.Error(c, 404, "会话不存在")
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "会话已注销", nil)
 }
 
 // GetProfileByID 根据ID获取用户资料（公开接口）
@@ -163,3 +226,81 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	response.Success(c, profile)
 }
+
+// ChangePassword 修改密码，成功后其他设备上的会话全部失效
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		response.Unauthorized(c, "未登录")
+		return
+	}
+
+	var req user.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	err := h.userService.ChangePassword(c.Request.Context(), userID, middleware.GetSessionID(c), &req)
+	if err != nil {
+		if err == user.ErrWrongPassword {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "密码已修改，其他设备需重新登录", nil)
+}
+
+// ForgotPassword 申请找回密码。无论邮箱是否注册都返回相同结果
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var req user.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.userService.RequestPasswordReset(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		if err == user.ErrPasswordResetDisabled {
+			response.Error(c, 503, err.Error())
+			return
+		}
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	response.SuccessWithMessage(c, "如果该邮箱已注册，重置密码的邮件将很快送达", nil)
+}
+
+// ResetPassword 使用邮件中的令牌重置密码
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var req user.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "参数错误: "+err.Error())
+		return
+	}
+
+	if err := h.userService.ResetPassword(c.Request.Context(), &req); err != nil {
+		switch err {
+		case user.ErrInvalidResetToken:
+			response.BadRequest(c, err.Error())
+		case user.ErrPasswordResetDisabled:
+			response.Error(c, 503, err.Error())
+		default:
+			response.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	response.SuccessWithMessage(c, "密码已重置，请重新登录", nil)
+}
+
+// clientInfo 收集请求方的IP和User-Agent，记录到会话上
+func clientInfo(c *gin.Context) user.ClientInfo {
+	return user.ClientInfo{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/pkg/response"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyAuthenticator 校验API密钥并计入用量
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, int64, error)
+}
+
+// APIKeyAuth 识别 X-API-Key 请求头。没有该请求头的请求直接放行；
+// 密钥无效返回 401，超出每日上限返回 429。响应头 X-Quota-Limit、X-Quota-Remaining 为当日额度
+func APIKeyAuth(authenticator APIKeyAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			c.Next()
+			return
+		}
+
+		key, used, err := authenticator.Authenticate(c.Request.Context(), rawKey)
+		if key != nil {
+			remaining := int64(key.DailyQuota) - used
+			if remaining < 0 {
+				remaining = 0
+			}
+			c.Header("X-Quota-Limit", strconv.Itoa(key.DailyQuota))
+			c.Header("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+		}
+
+		switch err {
+		case nil:
+			c.Set("api_key", key)
+			c.Next()
+		case auth.ErrInvalidAPIKey:
+			response.Unauthorized(c, err.Error())
+			c.Abort()
+		case auth.ErrAPIKeyQuotaExceeded:
+			// 额度在 UTC 零点重置
+			now := time.Now().UTC()
+			reset := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(reset.Sub(now))))
+			response.Error(c, 429, err.Error())
+			c.Abort()
+		default:
+			slog.ErrorContext(c.Request.Context(), "authenticate api key failed", "error", err)
+			response.InternalError(c, "API密钥校验失败")
+			c.Abort()
+		}
+	}
+}
+
+// RequireScope 通过API密钥访问时要求密钥拥有指定权限范围，其他请求不受影响
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := GetAPIKey(c); ok && !key.HasScope(scope) {
+			response.Forbidden(c, "API密钥没有 "+scope+" 权限")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAPIKey 从上下文获取当前请求使用的API密钥
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	key, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	return key.(*models.APIKey), true
+}
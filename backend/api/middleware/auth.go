@@ -1,9 +1,9 @@
 package middleware
 
 import (
-	"strings"
 	"poem/backend/pkg/auth"
 	"poem/backend/pkg/response"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,12 +11,14 @@ import (
 // AuthMiddleware JWT认证中间件
 type AuthMiddleware struct {
 	jwtManager *auth.JWTManager
+	denylist   *auth.Denylist
 }
 
 // NewAuthMiddleware 创建认证中间件
-func NewAuthMiddleware(jwtManager *auth.JWTManager) *AuthMiddleware {
+func NewAuthMiddleware(jwtManager *auth.JWTManager, denylist *auth.Denylist) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtManager: jwtManager,
+		denylist:   denylist,
 	}
 }
 
@@ -47,9 +49,15 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// 检查Token是否已被吊销（登出、修改密码、禁用账号）
+		if m.isRevoked(c, claims) {
+			response.Unauthorized(c, "Token已失效，请重新登录")
+			c.Abort()
+			return
+		}
+
 		// 将用户信息存入上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
+		setClaims(c, claims)
 
 		c.Next()
 	}
@@ -62,9 +70,8 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		if authHeader != "" {
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) == 2 && parts[0] == "Bearer" {
-				if claims, err := m.jwtManager.ValidateToken(parts[1]); err == nil {
-					c.Set("user_id", claims.UserID)
-					c.Set("username", claims.Username)
+				if claims, err := m.jwtManager.ValidateToken(parts[1]); err == nil && !m.isRevoked(c, claims) {
+					setClaims(c, claims)
 				}
 			}
 		}
@@ -72,6 +79,21 @@ func (m *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	}
 }
 
+func (m *AuthMiddleware) isRevoked(c *gin.Context, claims *auth.Claims) bool {
+	if m.denylist == nil || claims.ExpiresAt == nil {
+		return false
+	}
+	return m.denylist.IsRevoked(c.Request.Context(), claims.ID, claims.ExpiresAt.Time)
+}
+
+func setClaims(c *gin.Context, claims *auth.Claims) {
+	c.Set("claims", claims)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("role", claims.Role)
+	c.Set("session_id", claims.SessionID)
+}
+
 // GetUserID 从上下文获取用户ID
 func GetUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get("user_id")
@@ -89,3 +111,25 @@ func GetUsername(c *gin.Context) (string, bool) {
 	}
 	return username.(string), true
 }
+
+// GetRole 从上下文获取当前用户角色，引入角色之前签发的token视为普通用户
+func GetRole(c *gin.Context) string {
+	if role := c.GetString("role"); role != "" {
+		return role
+	}
+	return auth.RoleUser
+}
+
+// GetSessionID 从上下文获取当前登录会话ID
+func GetSessionID(c *gin.Context) string {
+	return c.GetString("session_id")
+}
+
+// GetClaims 从上下文获取当前token的完整声明
+func GetClaims(c *gin.Context) (*auth.Claims, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	return claims.(*auth.Claims), true
+}
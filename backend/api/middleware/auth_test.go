@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"poem/backend/internal/testdb"
+	"poem/backend/pkg/auth"
+	"poem/backend/repository"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAuthRevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testdb.New(t)
+	store, _ := repository.NewRevokedTokenRepository(db)
+	denylist := auth.NewDenylist(store, 100)
+	jwtManager, err := auth.NewJWTManager(auth.Config{Secret: "middleware-test-secret-at-least-32-chars", TokenDuration: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/me", NewAuthMiddleware(jwtManager, denylist).RequireAuth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	kept, _, err := jwtManager.GenerateToken(1, "reader", "user", "s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, claims, err := jwtManager.GenerateToken(1, "reader", "user", "s2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(revoked); got != http.StatusOK {
+		t.Fatalf("status before revocation = %d, want 200", got)
+	}
+
+	if err := denylist.Revoke(context.Background(), claims.ID, claims.UserID, "logout", claims.ExpiresAt.Time); err != nil {
+		t.Fatal(err)
+	}
+	if got := serve(revoked); got != http.StatusUnauthorized {
+		t.Fatalf("status of revoked token = %d, want 401", got)
+	}
+	if got := serve(kept); got != http.StatusOK {
+		t.Fatalf("status of another token of the user = %d, want 200", got)
+	}
+
+	// 吊销名单查不到时拒绝未缓存的token
+	fresh, _, err := jwtManager.GenerateToken(1, "reader", "user", "s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	if got := serve(fresh); got != http.StatusUnauthorized {
+		t.Fatalf("status while the denylist store fails = %d, want 401", got)
+	}
+}
@@ -5,13 +5,20 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// CORS 跨域中间件
-func CORS() gin.HandlerFunc {
+// CORS 跨域中间件，origins 为允许的来源，包含 "*" 时允许全部来源
+func CORS(origins []string) gin.HandlerFunc {
 	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"}
+	for _, origin := range origins {
+		if origin == "*" {
+			config.AllowAllOrigins = true
+		}
+	}
+	if !config.AllowAllOrigins {
+		config.AllowOrigins = origins
+	}
 	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-	config.ExposeHeaders = []string{"Content-Length"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", "X-API-Key", RequestIDHeader, "traceparent", "tracestate"}
+	config.ExposeHeaders = []string{"Content-Length", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Quota-Limit", "X-Quota-Remaining", RequestIDHeader}
 
 	return cors.New(config)
 }
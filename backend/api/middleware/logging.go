@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"poem/backend/pkg/logging"
+	"poem/backend/pkg/response"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader 请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID 沿用上游（负载均衡、调用方）传入的 X-Request-ID，没有或不合法时生成新的；
+// 请求ID写入响应头，并放入请求的 context，之后的日志都会带上 request_id
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.New().String()
+		}
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// validRequestID 只接受不超过 128 个字符的可见 ASCII，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// AccessLog 每个请求结束后记录一条访问日志，5xx 记为 error，其余为 info
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID, ok := GetUserID(c); ok {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(userID)))
+		}
+		if apiKey, ok := GetAPIKey(c); ok {
+			attrs = append(attrs, slog.Uint64("api_key_id", uint64(apiKey.ID)))
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("errors", c.Errors.String()))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery 捕获处理器中的 panic，记录堆栈并返回 500
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered",
+			"error", err,
+			"stack", string(debug.Stack()),
+		)
+		response.InternalError(c, "服务器内部错误")
+		c.Abort()
+	})
+}
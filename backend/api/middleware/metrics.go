@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"poem/backend/pkg/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics 记录请求数、耗时和进行中的请求数。route 使用路由模板（如 /api/v1/poems/:id），
+// 未匹配任何路由的请求（含前端页面）统一记为 "unmatched"，避免路径参数导致序列数膨胀
+func Metrics(registry *metrics.Registry) gin.HandlerFunc {
+	requests := registry.NewCounter("http_requests_total",
+		"Total number of HTTP requests by method, route and status.", "method", "route", "status")
+	duration := registry.NewHistogram("http_request_duration_seconds",
+		"HTTP request latency in seconds by method, route and status.", nil, "method", "route", "status")
+	inFlight := registry.NewGauge("http_requests_in_flight",
+		"Number of HTTP requests currently being served.").With()
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requests.With(c.Request.Method, route, status).Inc()
+		duration.With(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"poem/backend/pkg/metrics"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsRouteLabel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := metrics.NewRegistry()
+	router := gin.New()
+	router.Use(Metrics(registry))
+	router.GET("/api/v1/poems/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/api/v1/poems/1", "/api/v1/poems/2", "/no/such/page"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	text := registry.Text()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/api/v1/poems/:id",status="200"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/poems/:id",status="200"} 2`,
+		`http_requests_in_flight 0`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+	// 路径参数不应出现在标签里
+	if strings.Contains(text, "/api/v1/poems/1") {
+		t.Errorf("raw path leaked into route label:\n%s", text)
+	}
+}
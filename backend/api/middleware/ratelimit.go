@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"poem/backend/pkg/ratelimit"
+	"poem/backend/pkg/response"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit 令牌桶限流，API密钥请求按密钥计数，已登录用户按用户ID计数，匿名请求按IP计数。
+// name 区分路由组，不同组使用各自的桶；需要按密钥或用户计数时放在 APIKeyAuth、OptionalAuth 或 RequireAuth 之后。
+// 响应头 X-RateLimit-Limit 为桶容量，X-RateLimit-Remaining 为剩余次数，X-RateLimit-Reset 为补满所需秒数
+func RateLimit(store ratelimit.Store, name string, limit ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := name + ":ip:" + c.ClientIP()
+		if apiKey, ok := GetAPIKey(c); ok {
+			key = fmt.Sprintf("%s:key:%d", name, apiKey.ID)
+		} else if userID, ok := GetUserID(c); ok {
+			key = fmt.Sprintf("%s:user:%d", name, userID)
+		}
+
+		result, err := store.Take(c.Request.Context(), key, limit)
+		if err != nil {
+			// 限流存储不可用时放行，不影响正常访问
+			slog.ErrorContext(c.Request.Context(), "rate limit store failed", "key", key, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			response.Error(c, 429, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"poem/backend/pkg/ratelimit"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRateLimitRouter 每分钟 2 次的限流路由，只信任 trusted 中的代理
+func newRateLimitRouter(t *testing.T, trusted []string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	if err := router.SetTrustedProxies(trusted); err != nil {
+		t.Fatal(err)
+	}
+	router.Use(RateLimit(ratelimit.NewMemoryStore(), "test", ratelimit.Per(2, time.Minute)))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func serveFrom(router *gin.Engine, remoteAddr, forwardedFor string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	if forwardedFor != "" {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeadersAnd429(t *testing.T) {
+	router := newRateLimitRouter(t, nil)
+
+	for _, remaining := range []string{"1", "0"} {
+		w := serveFrom(router, "192.0.2.1:1234", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Fatalf("X-RateLimit-Limit = %q, want 2", got)
+		}
+		if got := w.Header().Get("X-RateLimit-Remaining"); got != remaining {
+			t.Fatalf("X-RateLimit-Remaining = %q, want %s", got, remaining)
+		}
+		if w.Header().Get("X-RateLimit-Reset") == "" || w.Header().Get("Retry-After") != "" {
+			t.Fatalf("allowed response headers = %v", w.Header())
+		}
+	}
+
+	w := serveFrom(router, "192.0.2.1:1234", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	// 每 30 秒补充一个令牌，补满两个需要 60 秒
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want 30", got)
+	}
+	if got := w.Header().Get("X-RateLimit-Reset"); got != "60" {
+		t.Fatalf("X-RateLimit-Reset = %q, want 60", got)
+	}
+	var body struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Success || body.Error == "" {
+		t.Fatalf("429 body = %s", w.Body.String())
+	}
+
+	// 其他客户端不受影响
+	if w := serveFrom(router, "192.0.2.2:1234", ""); w.Code != http.StatusOK {
+		t.Fatalf("other client status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitClientIPBehindProxy(t *testing.T) {
+	const proxy = "10.0.0.1:443"
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		router := newRateLimitRouter(t, []string{"10.0.0.0/8"})
+		// 经可信代理转发的不同客户端各用各的桶
+		for _, client := range []string{"198.51.100.1", "198.51.100.2", "198.51.100.3"} {
+			for i := 0; i < 2; i++ {
+				if w := serveFrom(router, proxy, client); w.Code != http.StatusOK {
+					t.Fatalf("client %s request %d status = %d, want 200", client, i+1, w.Code)
+				}
+			}
+		}
+		if w := serveFrom(router, proxy, "198.51.100.1"); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("third request of one client status = %d, want 429", w.Code)
+		}
+	})
+
+	t.Run("untrusted proxy", func(t *testing.T) {
+		router := newRateLimitRouter(t, []string{"10.0.0.0/8"})
+		// 不可信来源伪造的 X-Forwarded-For 被忽略，按连接地址计数
+		const attacker = "203.0.113.9:5555"
+		for i, forged := range []string{"198.51.100.1", "198.51.100.2"} {
+			if w := serveFrom(router, attacker, forged); w.Code != http.StatusOK {
+				t.Fatalf("request %d status = %d, want 200", i+1, w.Code)
+			}
+		}
+		if w := serveFrom(router, attacker, "198.51.100.3"); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("request with a fresh forged address status = %d, want 429", w.Code)
+		}
+	})
+}
+
+func TestRateLimitKeyedByUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Test-User"); id != "" {
+			c.Set("user_id", uint(len(id)))
+		}
+	})
+	router.Use(RateLimit(ratelimit.NewMemoryStore(), "test", ratelimit.Per(1, time.Minute)))
+	router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	serve := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	// 同一IP上的匿名请求和两个用户各有一个桶
+	for _, user := range []string{"", "a", "bb"} {
+		if got := serve(user); got != http.StatusOK {
+			t.Fatalf("first request of %q = %d, want 200", user, got)
+		}
+	}
+	if got := serve("a"); got != http.StatusTooManyRequests {
+		t.Fatalf("second request of user a = %d, want 429", got)
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"poem/backend/pkg/auth"
+	"poem/backend/pkg/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole 要求当前用户为指定角色之一，需放在 RequireAuth 之后
+func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := GetRole(c)
+		for _, r := range roles {
+			if r == role {
+				c.Next()
+				return
+			}
+		}
+		response.Forbidden(c, "无权访问")
+		c.Abort()
+	}
+}
+
+// RequirePermission 要求当前用户的角色拥有指定权限，需放在 RequireAuth 之后
+func (m *AuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasPermission(GetRole(c), permission) {
+			response.Forbidden(c, "无权访问")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
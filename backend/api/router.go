@@ -1,13 +1,24 @@
 package api
 
 import (
+	"context"
+	"fmt"
+	"path/filepath"
 	"poem/backend/api/handlers"
 	v2 "poem/backend/api/handlers/v2"
 	"poem/backend/api/middleware"
 	apiv2 "poem/backend/api/v2"
+	"poem/backend/config"
 	"poem/backend/pkg/auth"
+	"poem/backend/pkg/mail"
+	"poem/backend/pkg/metrics"
+	"poem/backend/pkg/ratelimit"
 	"poem/backend/repository"
 	"poem/backend/services"
+	"poem/backend/services/cache"
+	"poem/backend/services/corpus"
+	"poem/backend/services/developer"
+	"poem/backend/services/health"
 	"poem/backend/services/user"
 	"strings"
 	"time"
@@ -16,69 +27,268 @@ import (
 	"gorm.io/gorm"
 )
 
-// SetupRouter 设置路由
-func SetupRouter(poetryService *services.PoetryService, db *gorm.DB) *gin.Engine {
-	router := gin.Default()
+// SetupRouter 设置路由。后台任务（吊销名单清理、限流桶清理）在 ctx 取消时停止
+func SetupRouter(ctx context.Context, cfg *config.Config, poetryService *services.PoetryService, db *gorm.DB) (*gin.Engine, error) {
+	jwtManager, err := auth.NewJWTManager(auth.Config{
+		Algorithm:       cfg.JWT.Algorithm,
+		Secret:          cfg.JWT.Secret,
+		SecretID:        cfg.JWT.SecretID,
+		PreviousSecrets: cfg.JWT.PreviousSecrets,
+		KeysDir:         cfg.JWT.KeysDir,
+		ActiveKeyID:     cfg.JWT.ActiveKeyID,
+		TokenDuration:   cfg.JWT.TokenDuration,
+		Issuer:          cfg.JWT.Issuer,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("初始化JWT失败: %w", err)
+	}
+
+	// 请求ID和 trace 最先生成，访问日志和 panic 日志都能带上
+	router := gin.New()
+	// gin 默认信任所有代理的 X-Forwarded-For，改为只信任配置的代理
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("server.trusted_proxies 无效: %w", err)
+	}
+	router.Use(middleware.RequestID(), middleware.Tracing(), middleware.AccessLog(), middleware.Recovery())
 
-	// 使用中间件
-	router.Use(middleware.CORS())
+	// 指标：HTTP 请求、数据库语句、搜索耗时、吊销名单缓存命中和 ETL 导入行数
+	registry := metrics.NewRegistry()
+	if cfg.Metrics.Enabled {
+		registry.RegisterRuntime()
+		router.Use(middleware.Metrics(registry))
+		if err := repository.InstrumentDB(db, registry); err != nil {
+			return nil, fmt.Errorf("初始化数据库指标失败: %w", err)
+		}
+		poetryService.EnableMetrics(registry)
+		registerImportMetrics(registry, poetryService)
+		router.GET("/metrics", gin.WrapH(registry.Handler(cfg.Metrics.Token)))
+	}
 
-	// 创建处理器
+	// 使用中间件，未配置跨域来源时不允许跨域
+	if len(cfg.Server.CORSOrigins) > 0 {
+		router.Use(middleware.CORS(cfg.Server.CORSOrigins))
+	}
+
+	// 创建处理器；语料只在 ETL 和管理后台编辑时变化，热点读接口缓存查询结果并支持 304
 	poetryHandler := handlers.NewPoetryHandler(poetryService)
+	caches := make(map[string]func() (uint64, uint64))
+	if cfg.Cache.Enabled {
+		poetryCache := cache.NewLRU(cfg.Cache.Size, cfg.Cache.TTL)
+		poetryService.EnableCache(poetryCache, cfg.Cache.CheckInterval)
+		poetryHandler.EnableHTTPCache(cfg.Cache.MaxAge)
+		caches["poetry"] = poetryCache.Stats
+	}
 
 	// 初始化用户模块
-	jwtManager := auth.NewJWTManager("your-secret-key-change-in-production", 7*24*time.Hour)
 	userRepo, _ := repository.NewUserRepository(db)
-	userService := user.NewUserService(userRepo, jwtManager)
+	sessionRepo, _ := repository.NewSessionRepository(db)
+	revokedRepo, _ := repository.NewRevokedTokenRepository(db)
+	denylist := auth.NewDenylist(revokedRepo, 10000)
+	denylist.StartPruner(ctx, time.Hour)
+	if cfg.Metrics.Enabled {
+		caches["denylist"] = denylist.CacheStats
+		registerCacheMetrics(registry, caches)
+	}
+	userService := user.NewUserService(userRepo, sessionRepo, jwtManager, denylist)
+	resetRepo, err := repository.NewPasswordResetRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("初始化找回密码失败: %w", err)
+	}
+	mailer, err := newMailer(cfg.Mail)
+	if err != nil {
+		return nil, err
+	}
+	userService.EnablePasswordReset(resetRepo, mailer, cfg.Mail.PasswordResetURL)
+	attemptRepo, err := repository.NewLoginAttemptRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("初始化登录防护失败: %w", err)
+	}
+	userService.EnableLoginThrottle(attemptRepo, user.LoginThrottleConfig{
+		MaxUserFailures: cfg.Login.MaxUserFailures,
+		MaxIPFailures:   cfg.Login.MaxIPFailures,
+		BaseLockout:     cfg.Login.BaseLockout,
+		MaxLockout:      cfg.Login.MaxLockout,
+		FailureWindow:   cfg.Login.FailureWindow,
+	})
 	userHandler := v2.NewUserHandler(userService)
+	corpusRepo, err := repository.NewCorpusRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("初始化语料编辑失败: %w", err)
+	}
+	corpusService := corpus.NewCorpusService(corpusRepo)
+	adminHandler := v2.NewAdminHandler(userService, corpusService)
+
+	correctionRepo, err := repository.NewCorrectionRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("初始化纠错失败: %w", err)
+	}
+	correctionHandler := v2.NewCorrectionHandler(corpus.NewCorrectionService(correctionRepo, corpusService, userRepo))
 
-	// API v1 路由组
-	v1 := router.Group("/api/v1")
+	apiKeyRepo, err := repository.NewAPIKeyRepository(db)
+	if err != nil {
+		return nil, fmt.Errorf("初始化API密钥失败: %w", err)
+	}
+	apiKeyService := developer.NewAPIKeyService(apiKeyRepo)
+	developerHandler := v2.NewDeveloperHandler(apiKeyService)
+
+	// 限流：API密钥请求按密钥计数，登录用户按用户计数，匿名请求按IP计数
+	limitStore := ratelimit.NewMemoryStore()
+	limitStore.StartJanitor(ctx, time.Minute)
+	listLimit, err := rateLimit(limitStore, "list", cfg.Limits.List)
+	if err != nil {
+		return nil, err
+	}
+	detailLimit, err := rateLimit(limitStore, "detail", cfg.Limits.Detail)
+	if err != nil {
+		return nil, err
+	}
+	searchLimit, err := rateLimit(limitStore, "search", cfg.Limits.Search)
+	if err != nil {
+		return nil, err
+	}
+	randomLimit, err := rateLimit(limitStore, "random", cfg.Limits.Random)
+	if err != nil {
+		return nil, err
+	}
+
+	// API v1 路由组，第三方应用通过 X-API-Key 访问时按密钥的权限范围和每日额度限制
+	v1 := router.Group("/api/v1",
+		middleware.APIKeyAuth(apiKeyService),
+		middleware.NewAuthMiddleware(jwtManager, denylist).OptionalAuth(),
+	)
 	{
+		read := v1.Group("", middleware.RequireScope(auth.ScopePoetryRead))
+
 		// 目录相关
-		v1.GET("/dynasties", poetryHandler.GetDynasties)
-		v1.GET("/categories", poetryHandler.GetCategories)
+		read.GET("/dynasties", listLimit, poetryHandler.GetDynasties)
+		read.GET("/categories", listLimit, poetryHandler.GetCategories)
+		read.GET("/anthologies", listLimit, poetryHandler.GetAnthologies)
 
 		// 诗词相关
-		v1.GET("/poems", poetryHandler.GetPoems)
-		v1.GET("/poems/:id", poetryHandler.GetPoemByID)
-		v1.GET("/poems/random", poetryHandler.GetRandomPoem)
+		read.GET("/poems", listLimit, poetryHandler.GetPoems)
+		read.GET("/poems/:id", detailLimit, poetryHandler.GetPoemByID)
+		read.GET("/poems/random", randomLimit, poetryHandler.GetRandomPoem)
 
 		// 作者相关
-		v1.GET("/authors", poetryHandler.GetAuthors)
-		v1.GET("/authors/:name", poetryHandler.GetAuthorByName)
-		v1.GET("/authors/:name/poems", poetryHandler.GetAuthorPoems)
+		read.GET("/authors", listLimit, poetryHandler.GetAuthors)
+		read.GET("/authors/:name", detailLimit, poetryHandler.GetAuthorByName)
+		read.GET("/authors/:name/poems", listLimit, poetryHandler.GetAuthorPoems)
 
 		// 搜索
-		v1.GET("/search", poetryHandler.Search)
+		v1.GET("/search", middleware.RequireScope(auth.ScopePoetrySearch), searchLimit, poetryHandler.Search)
 	}
 
 	// API v2 路由组
-	v2Router := apiv2.NewRouter(userHandler, jwtManager)
+	v2Router := apiv2.NewRouter(userHandler, adminHandler, correctionHandler, developerHandler, jwtManager, denylist)
 	v2 := router.Group("/api/v2")
 	v2Router.SetupRoutes(v2)
 
 	// 静态文件服务 - 前端构建产物
-	router.Static("/assets", "../frontend/dist/assets")
-	router.StaticFile("/favicon.svg", "../frontend/dist/favicon.svg")
+	frontendDir := cfg.Server.FrontendDir
+	if frontendDir != "" {
+		router.Static("/assets", filepath.Join(frontendDir, "assets"))
+		router.StaticFile("/favicon.svg", filepath.Join(frontendDir, "favicon.svg"))
+	}
 
 	// SPA 前端路由支持
 	router.NoRoute(func(c *gin.Context) {
-		// 如果是 API 请求但未匹配到路由，返回 404
-		if strings.HasPrefix(c.Request.URL.Path, "/api") {
+		// 如果是 API 请求或未提供前端，返回 404
+		if frontendDir == "" || strings.HasPrefix(c.Request.URL.Path, "/api") {
 			c.JSON(404, gin.H{"code": 404, "message": "Not Found"})
 			return
 		}
 		// 其他请求返回前端入口文件
-		c.File("../frontend/dist/index.html")
+		c.File(filepath.Join(frontendDir, "index.html"))
 	})
 
-	// 健康检查
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ok",
-		})
+	// 公钥发布，供其他服务验证本服务签发的token
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(200, gin.H{"keys": jwtManager.JWKS()})
 	})
 
-	return router
+	// 健康检查：/healthz 存活，/readyz 就绪；/health 保留为 /healthz 的别名
+	healthHandler := handlers.NewHealthHandler(health.NewHealthService(repository.NewHealthRepository(db)))
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/health", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+
+	return router, nil
+}
+
+// newMailer 根据配置创建邮件发送器
+func newMailer(cfg config.MailConfig) (mail.Mailer, error) {
+	switch cfg.Driver {
+	case "", "log":
+		return mail.LogMailer{}, nil
+	case "file":
+		return mail.FileMailer{Dir: cfg.Dir, From: cfg.From}, nil
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("MAIL_DRIVER=smtp 时必须配置 SMTP_HOST")
+		}
+		return mail.SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}, nil
+	default:
+		return nil, fmt.Errorf("未知的 MAIL_DRIVER: %s", cfg.Driver)
+	}
+}
+
+// rateLimit 按配置创建路由组的限流中间件，spec 为 "off" 时不限流
+func rateLimit(store ratelimit.Store, name, spec string) (gin.HandlerFunc, error) {
+	limit, enabled, err := ratelimit.ParseLimit(spec)
+	if err != nil {
+		return nil, fmt.Errorf("限流配置 %s: %w", name, err)
+	}
+	if !enabled {
+		return func(c *gin.Context) { c.Next() }, nil
+	}
+	return middleware.RateLimit(store, name, limit), nil
+}
+
+// registerCacheMetrics 输出各缓存的累计命中/未命中次数，命中率 = hit / (hit + miss)
+func registerCacheMetrics(registry *metrics.Registry, caches map[string]func() (hits, misses uint64)) {
+	registry.NewCounterFunc("cache_requests_total", "Cache lookups by cache and result (hit or miss).",
+		[]string{"cache", "result"}, func() []metrics.Sample {
+			samples := make([]metrics.Sample, 0, 2*len(caches))
+			for name, stats := range caches {
+				hits, misses := stats()
+				samples = append(samples,
+					metrics.Sample{LabelValues: []string{name, "hit"}, Value: float64(hits)},
+					metrics.Sample{LabelValues: []string{name, "miss"}, Value: float64(misses)},
+				)
+			}
+			return samples
+		})
+}
+
+// registerImportMetrics 输出最近一次 ETL（manage etl）导入的行数和完成时间，ETL 在独立进程中运行，采集时从数据库读取
+func registerImportMetrics(registry *metrics.Registry, poetryService *services.PoetryService) {
+	registry.NewGaugeFunc("etl_imported_rows", "Rows imported by the last ETL run, by table.",
+		[]string{"table"}, func() []metrics.Sample {
+			run, err := poetryService.LatestImportRun(context.Background())
+			if err != nil || run == nil {
+				return nil
+			}
+			return []metrics.Sample{
+				{LabelValues: []string{"works"}, Value: float64(run.Works)},
+				{LabelValues: []string{"authors"}, Value: float64(run.Authors)},
+				{LabelValues: []string{"author_aliases"}, Value: float64(run.AuthorAliases)},
+				{LabelValues: []string{"comments"}, Value: float64(run.Comments)},
+				{LabelValues: []string{"anthology_works"}, Value: float64(run.AnthologyWorks)},
+			}
+		})
+	registry.NewGaugeFunc("etl_last_run_timestamp_seconds", "Unix time the last ETL run finished.",
+		nil, func() []metrics.Sample {
+			run, err := poetryService.LatestImportRun(context.Background())
+			if err != nil || run == nil {
+				return nil
+			}
+			return []metrics.Sample{{Value: float64(run.FinishedAt.Unix())}}
+		})
 }
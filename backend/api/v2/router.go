@@ -10,18 +10,28 @@ import (
 
 // Router v2路由
 type Router struct {
-	userHandler   *v2.UserHandler
-	authMiddleware *middleware.AuthMiddleware
+	userHandler       *v2.UserHandler
+	adminHandler      *v2.AdminHandler
+	correctionHandler *v2.CorrectionHandler
+	developerHandler  *v2.DeveloperHandler
+	authMiddleware    *middleware.AuthMiddleware
 }
 
 // NewRouter 创建v2路由
 func NewRouter(
 	userHandler *v2.UserHandler,
+	adminHandler *v2.AdminHandler,
+	correctionHandler *v2.CorrectionHandler,
+	developerHandler *v2.DeveloperHandler,
 	jwtManager *auth.JWTManager,
+	denylist *auth.Denylist,
 ) *Router {
 	return &Router{
-		userHandler:   userHandler,
-		authMiddleware: middleware.NewAuthMiddleware(jwtManager),
+		userHandler:       userHandler,
+		adminHandler:      adminHandler,
+		correctionHandler: correctionHandler,
+		developerHandler:  developerHandler,
+		authMiddleware:    middleware.NewAuthMiddleware(jwtManager, denylist),
 	}
 }
 
@@ -34,6 +44,8 @@ func (r *Router) SetupRoutes(rg *gin.RouterGroup) {
 		public.POST("/auth/register", r.userHandler.Register)
 		public.POST("/auth/login", r.userHandler.Login)
 		public.POST("/auth/refresh", r.userHandler.RefreshToken)
+		public.POST("/auth/password/forgot", r.userHandler.ForgotPassword)
+		public.POST("/auth/password/reset", r.userHandler.ResetPassword)
 
 		// 公开用户信息
 		public.GET("/users/:id", r.userHandler.GetProfileByID)
@@ -43,8 +55,69 @@ func (r *Router) SetupRoutes(rg *gin.RouterGroup) {
 	protected := rg.Group("")
 	protected.Use(r.authMiddleware.RequireAuth())
 	{
+		// 会话管理
+		protected.POST("/auth/logout", r.userHandler.Logout)
+		protected.GET("/auth/sessions", r.userHandler.ListSessions)
+		protected.DELETE("/auth/sessions/:id", r.userHandler.RevokeSession)
+
 		// 用户信息
 		protected.GET("/users/profile", r.userHandler.GetProfile)
 		protected.PUT("/users/profile", r.userHandler.UpdateProfile)
+		protected.PUT("/users/password", r.userHandler.ChangePassword)
+
+		// 读者纠错
+		protected.POST("/works/:id/corrections", r.correctionHandler.Submit)
+		protected.GET("/users/corrections", r.correctionHandler.ListMine)
+
+		// 第三方开发者API密钥
+		keys := protected.Group("/developer/keys")
+		keys.GET("", r.developerHandler.ListKeys)
+		keys.POST("", r.developerHandler.CreateKey)
+		keys.DELETE("/:id", r.developerHandler.RevokeKey)
+		keys.GET("/:id/usage", r.developerHandler.KeyUsage)
+	}
+
+	// 管理后台路由，按权限细分
+	admin := rg.Group("/admin")
+	admin.Use(r.authMiddleware.RequireAuth())
+	{
+		admin.PUT("/users/:id/role", r.authMiddleware.RequirePermission(auth.PermManageUsers), r.adminHandler.SetUserRole)
+
+		// 语料编辑，修改会在重新导入后保留
+		works := admin.Group("/works", r.authMiddleware.RequirePermission(auth.PermEditWorks))
+		works.GET("/:id", r.adminHandler.GetWork)
+		works.POST("", r.adminHandler.CreateWork)
+		works.PUT("/:id", r.adminHandler.UpdateWork)
+		works.DELETE("/:id", r.adminHandler.DeleteWork)
+		works.GET("/:id/revisions", r.adminHandler.ListWorkRevisions)
+
+		authors := admin.Group("/authors", r.authMiddleware.RequirePermission(auth.PermEditAuthors))
+		authors.GET("/:id", r.adminHandler.GetAuthor)
+		authors.POST("", r.adminHandler.CreateAuthor)
+		authors.PUT("/:id", r.adminHandler.UpdateAuthor)
+		authors.DELETE("/:id", r.adminHandler.DeleteAuthor)
+		authors.GET("/:id/revisions", r.adminHandler.ListAuthorRevisions)
+
+		comments := admin.Group("/comments", r.authMiddleware.RequirePermission(auth.PermModerateComments))
+		comments.GET("", r.adminHandler.ListComments)
+		comments.GET("/:id", r.adminHandler.GetComment)
+		comments.POST("", r.adminHandler.CreateComment)
+		comments.PUT("/:id", r.adminHandler.UpdateComment)
+		comments.DELETE("/:id", r.adminHandler.DeleteComment)
+
+		admin.GET("/overrides", r.authMiddleware.RequirePermission(auth.PermEditWorks), r.adminHandler.ListOverrides)
+
+		// 修订历史：diff 返回修订本身及正文的逐行差异；回滚按修订对象另行检查权限
+		revisions := admin.Group("/revisions", r.authMiddleware.RequirePermission(auth.PermEditWorks))
+		revisions.GET("", r.adminHandler.ListRevisions)
+		revisions.GET("/:id/diff", r.adminHandler.GetRevisionDiff)
+		revisions.POST("/:id/rollback", r.adminHandler.RollbackRevision)
+
+		// 纠错审核，采纳时修改作品并记录修订
+		corrections := admin.Group("/corrections", r.authMiddleware.RequirePermission(auth.PermReviewCorrections))
+		corrections.GET("", r.correctionHandler.ListQueue)
+		corrections.GET("/:id", r.correctionHandler.Get)
+		corrections.POST("/:id/approve", r.correctionHandler.Approve)
+		corrections.POST("/:id/reject", r.correctionHandler.Reject)
 	}
 }
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"poem/backend/migrations"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"strconv"
+	"strings"
+)
+
+func runAuthors() {
+	if len(os.Args) < 2 {
+		printAuthorsUsage()
+		os.Exit(1)
+	}
+
+	sub := os.Args[1]
+	args := os.Args[2:]
+
+	switch sub {
+	case "find":
+		runAuthorsFind(args)
+	case "merge":
+		runAuthorsMerge(args)
+	case "alias":
+		runAuthorsAlias(args)
+	case "profile":
+		runAuthorsProfile(args)
+	case "reparse":
+		runAuthorsReparse(args)
+	default:
+		fmt.Printf("Unknown authors command: %s\n", sub)
+		printAuthorsUsage()
+		os.Exit(1)
+	}
+}
+
+func printAuthorsUsage() {
+	fmt.Println("Usage: manage authors <command> [flags]")
+	fmt.Println("Commands:")
+	fmt.Println("  find   -name NAME                          List authors and aliases matching a name")
+	fmt.Println("  merge  -from ID -into ID                   Move works and aliases of one author into another")
+	fmt.Println("  alias  -author ID -name NAME [-dynasty D] [-type T]  Add an alias (字/号/异写) to an author")
+	fmt.Println("  profile -author ID [-birth Y] [-death Y] [-courtesy 字] [-art 号] [-native 籍贯] ...  Correct an author profile")
+	fmt.Println("  reparse                                    Re-extract profiles from biographies (manual ones are kept)")
+}
+
+func openPoetryRepository(dbPath string) *repository.PoetryRepository {
+	driver, dsn := dbSource(dbPath)
+	fmt.Printf("Using database: %s\n", describeSource(driver, dsn))
+
+	repo, db, err := repository.NewPoetryRepository(driver, dsn)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := migrations.Check(context.Background(), db); err != nil {
+		log.Fatal("Database schema check failed, run `manage migrate up` first: ", err)
+	}
+	return repo
+}
+
+// bumpCorpusVersion 修改作者后递增语料的数据版本，运行中的服务端据此清空缓存
+func bumpCorpusVersion(repo *repository.PoetryRepository) {
+	if err := repository.BumpDataVersion(repo.DB(), models.DataVersionCorpus); err != nil {
+		fmt.Printf("Warning: failed to bump data version, cached responses may be stale: %v\n", err)
+	}
+}
+
+func runAuthorsFind(args []string) {
+	fs := flag.NewFlagSet("authors find", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	name := fs.String("name", "", "Author name or alias")
+	fs.Parse(args)
+
+	if *name == "" {
+		log.Fatal("-name is required")
+	}
+
+	db := openPoetryRepository(*dbPath).DB()
+
+	var aliases []models.AuthorAlias
+	db.Where("name = ?", *name).Find(&aliases)
+
+	ids := []uint{}
+	for _, a := range aliases {
+		ids = append(ids, a.AuthorID)
+	}
+
+	var authors []models.Author
+	db.Preload("Aliases").Where("name = ? OR id IN ?", *name, ids).Order("id asc").Find(&authors)
+	if len(authors) == 0 {
+		fmt.Println("No authors found")
+		return
+	}
+
+	for _, a := range authors {
+		var works int64
+		db.Model(&models.Work{}).Where("author_id = ?", a.ID).Count(&works)
+		fmt.Printf("#%d %s (%s) works=%d\n", a.ID, a.Name, a.Dynasty, works)
+		for _, alias := range a.Aliases {
+			fmt.Printf("    alias: %s (%s) [%s]\n", alias.Name, alias.Dynasty, alias.Type)
+		}
+	}
+}
+
+func runAuthorsMerge(args []string) {
+	fs := flag.NewFlagSet("authors merge", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	from := fs.Uint("from", 0, "ID of the author to merge away")
+	into := fs.Uint("into", 0, "ID of the author to keep")
+	fs.Parse(args)
+
+	if *from == 0 || *into == 0 {
+		log.Fatal("-from and -into are required")
+	}
+
+	repo := openPoetryRepository(*dbPath)
+	if err := repo.MergeAuthors(context.Background(), uint(*from), uint(*into)); err != nil {
+		log.Fatal("Failed to merge authors:", err)
+	}
+	bumpCorpusVersion(repo)
+
+	fmt.Printf("✅ Author #%d merged into #%d\n", *from, *into)
+}
+
+func runAuthorsAlias(args []string) {
+	fs := flag.NewFlagSet("authors alias", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	authorID := fs.Uint("author", 0, "Author ID")
+	name := fs.String("name", "", "Alias, e.g. 东坡居士")
+	dynasty := fs.String("dynasty", "", "Dynasty the alias appears under (empty matches any)")
+	aliasType := fs.String("type", "alias", "courtesy(字), pseudonym(号) or alias")
+	fs.Parse(args)
+
+	if *authorID == 0 || *name == "" {
+		log.Fatal("-author and -name are required")
+	}
+
+	repo := openPoetryRepository(*dbPath)
+	alias, err := repo.AddAuthorAlias(context.Background(), uint(*authorID), *name, *dynasty, *aliasType)
+	if err != nil {
+		log.Fatal("Failed to add alias:", err)
+	}
+	bumpCorpusVersion(repo)
+
+	fmt.Printf("✅ Alias %q now points to author #%d\n", alias.Name, alias.AuthorID)
+}
+
+func runAuthorsProfile(args []string) {
+	fs := flag.NewFlagSet("authors profile", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	authorID := fs.Uint("author", 0, "Author ID")
+	birth := fs.String("birth", "", "Birth year, negative for BC (empty clears)")
+	birthApprox := fs.Bool("birth-approx", false, "Birth year is uncertain")
+	death := fs.String("death", "", "Death year, negative for BC (empty clears)")
+	deathApprox := fs.Bool("death-approx", false, "Death year is uncertain")
+	courtesy := fs.String("courtesy", "", "Courtesy name (字)")
+	artName := fs.String("art", "", "Art name (号)")
+	native := fs.String("native", "", "Native place (籍贯)")
+	eras := fs.String("eras", "", "Comma separated era names (年号)")
+	fs.Parse(args)
+
+	if *authorID == 0 {
+		log.Fatal("-author is required")
+	}
+
+	repo := openPoetryRepository(*dbPath)
+
+	var author models.Author
+	if err := repo.DB().First(&author, *authorID).Error; err != nil {
+		log.Fatal("Failed to load author:", err)
+	}
+
+	// 只覆盖显式给出的字段
+	profile := author.Profile
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "birth":
+			profile.BirthYear = parseYearFlag(*birth)
+		case "birth-approx":
+			profile.BirthApprox = *birthApprox
+		case "death":
+			profile.DeathYear = parseYearFlag(*death)
+		case "death-approx":
+			profile.DeathApprox = *deathApprox
+		case "courtesy":
+			profile.CourtesyName = *courtesy
+		case "art":
+			profile.ArtName = *artName
+		case "native":
+			profile.NativePlace = *native
+		case "eras":
+			profile.EraNames = models.JSONArr{}
+			for _, era := range strings.Split(*eras, ",") {
+				if era = strings.TrimSpace(era); era != "" {
+					profile.EraNames = append(profile.EraNames, era)
+				}
+			}
+		}
+	})
+
+	if _, err := repo.UpdateAuthorProfile(context.Background(), author.ID, profile); err != nil {
+		log.Fatal("Failed to update profile:", err)
+	}
+	bumpCorpusVersion(repo)
+
+	fmt.Printf("✅ Profile of %s (#%d) updated\n", author.Name, author.ID)
+}
+
+func parseYearFlag(v string) *int {
+	if v == "" {
+		return nil
+	}
+	year, err := strconv.Atoi(v)
+	if err != nil {
+		log.Fatalf("Invalid year %q: %v", v, err)
+	}
+	return &year
+}
+
+func runAuthorsReparse(args []string) {
+	fs := flag.NewFlagSet("authors reparse", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	fs.Parse(args)
+
+	repo := openPoetryRepository(*dbPath)
+	db := repo.DB()
+
+	var authors []models.Author
+	db.Where("biography <> '' AND (profile_source IS NULL OR profile_source <> ?)", "manual").Find(&authors)
+
+	for i := range authors {
+		authors[i].Profile = parseProfile(authors[i].Biography)
+		db.Save(&authors[i])
+	}
+	bumpCorpusVersion(repo)
+
+	fmt.Printf("✅ Reparsed %d author profiles\n", len(authors))
+}
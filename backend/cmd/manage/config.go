@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"poem/backend/config"
+)
+
+func runConfig() {
+	if len(os.Args) < 2 || os.Args[1] != "print" {
+		printConfigUsage()
+		os.Exit(1)
+	}
+	runConfigPrint(os.Args[2:])
+}
+
+func printConfigUsage() {
+	fmt.Println("Usage: manage config print [flags]")
+	fmt.Println("  print [-config FILE] [-env ENV] [-port N] [-db PATH] [-data PATH]")
+	fmt.Println("        Print the effective server configuration as YAML, with secrets redacted.")
+	fmt.Println()
+	fmt.Println("Configuration is layered: defaults for the environment, then the config file")
+	fmt.Println("(-config or CONFIG_FILE), then environment variables, then flags.")
+}
+
+func runConfigPrint(args []string) {
+	cfg, err := config.Load(args)
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
+	out, err := cfg.Redacted().YAML()
+	if err != nil {
+		log.Fatalf("Failed to encode configuration: %v", err)
+	}
+	os.Stdout.Write(out)
+}
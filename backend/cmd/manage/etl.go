@@ -1,18 +1,23 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"poem/backend/migrations"
 	"poem/backend/models"
+	"poem/backend/pkg/biography"
+	"poem/backend/repository"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // RawPoem 原始 JSON 结构
@@ -32,6 +37,17 @@ type RawPoem struct {
 	Notes      []string `json:"notes"` // 注释
 }
 
+// paragraphs 返回正文，兼容 paragraphs/content/para 三种字段
+func (rp RawPoem) paragraphs() []string {
+	if len(rp.Paragraphs) > 0 {
+		return rp.Paragraphs
+	}
+	if len(rp.Content) > 0 {
+		return rp.Content
+	}
+	return rp.Para
+}
+
 // RawAuthor 原始作者 JSON 结构
 type RawAuthor struct {
 	ID          string `json:"id"`
@@ -73,29 +89,68 @@ type RawYouMengYing struct {
 
 // Cache for IDs
 var (
-	authorCache = make(map[string]uint)
-	catCache    = make(map[string]uint)
-	cacheMutex  sync.RWMutex
+	authorCache    = make(map[string]uint)
+	catCache       = make(map[string]uint)
+	anthologyCache = make(map[string]uint)
+	dynastyCache   = make(map[string]uint)
+	aliasIndex     = make(map[string]aliasRule)
+	catNames       = make(map[uint]string)
+	authorKeys     = make(map[uint]string)
+	sourceKeyCount = make(map[string]int)
+	cacheMutex     sync.RWMutex
 )
 
+// aliasRule 别名规则：Name|Dynasty 指向正名 AuthorName|AuthorDynasty，Dynasty 为空表示不限朝代
+type aliasRule struct {
+	Name          string
+	Dynasty       string
+	Type          string
+	AuthorName    string
+	AuthorDynasty string
+}
+
+// defaultAliasRules 内置的常见字号，数据源里偶尔以这些名字署名
+var defaultAliasRules = []aliasRule{
+	{Name: "李太白", Type: "alias", AuthorName: "李白", AuthorDynasty: "唐"},
+	{Name: "青莲居士", Type: "pseudonym", AuthorName: "李白", AuthorDynasty: "唐"},
+	{Name: "杜少陵", Type: "alias", AuthorName: "杜甫", AuthorDynasty: "唐"},
+	{Name: "少陵野老", Type: "pseudonym", AuthorName: "杜甫", AuthorDynasty: "唐"},
+	{Name: "王摩诘", Type: "alias", AuthorName: "王维", AuthorDynasty: "唐"},
+	{Name: "白乐天", Type: "alias", AuthorName: "白居易", AuthorDynasty: "唐"},
+	{Name: "香山居士", Type: "pseudonym", AuthorName: "白居易", AuthorDynasty: "唐"},
+	{Name: "苏东坡", Type: "alias", AuthorName: "苏轼", AuthorDynasty: "宋"},
+	{Name: "东坡居士", Type: "pseudonym", AuthorName: "苏轼", AuthorDynasty: "宋"},
+	{Name: "易安居士", Type: "pseudonym", AuthorName: "李清照", AuthorDynasty: "宋"},
+	{Name: "稼轩居士", Type: "pseudonym", AuthorName: "辛弃疾", AuthorDynasty: "宋"},
+}
+
 func runETL() {
 	// 1. 初始化数据库
-	dbPath := getDBPath("poems.db")
+	setupLogging()
 
-	fmt.Printf("Using database: %s\n", dbPath)
+	driver, dsn := dbSource("poems.db")
+	startedAt := time.Now()
 
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+	slog.Info("starting etl", "db", describeSource(driver, dsn))
+
+	db, err := repository.OpenDB(driver, dsn)
 	if err != nil {
 		log.Fatalf("failed to connect database: %v", err)
 	}
+	if err := migrations.Check(context.Background(), db); err != nil {
+		log.Fatalf("database schema check failed, run `manage migrate up` first: %v", err)
+	}
 
-	// 迁移模式 - 重新创建表
-	db.Migrator().DropTable(&models.Work{}, &models.Author{}, &models.Category{}, &models.Comment{})
-	err = db.AutoMigrate(&models.Category{}, &models.Author{}, &models.Work{}, &models.Comment{})
-	if err != nil {
-		log.Fatalf("failed to migrate database: %v", err)
+	// 重建前保留已有的别名（含 manage authors merge 产生的），导入时据此归并作者
+	aliasRules := loadAliasRules(db)
+	manualProfiles := loadManualProfiles(db)
+
+	// 清空语料表后重新导入；表结构由 manage migrate 维护，corpus_overrides 保存管理后台的修改，不随语料重建
+	if err := db.SetupJoinTable(&models.Work{}, "Anthologies", &models.AnthologyWork{}); err != nil {
+		log.Fatalf("failed to setup join table: %v", err)
+	}
+	if err := repository.TruncateCorpus(db); err != nil {
+		log.Fatalf("failed to clear corpus tables: %v", err)
 	}
 
 	// 2. 确定数据目录
@@ -127,24 +182,26 @@ func runETL() {
 		log.Fatal("Could not find chinese-poetry data directory")
 	}
 
-	fmt.Printf("Data root: %s\n", rootDir)
+	slog.Info("found data root", "dir", rootDir)
 
 	// 3. 种子分类数据
 	seedCategories(db)
+	seedDynasties(db)
+	seedAnthologies(db)
 
 	// 4. 处理全唐诗
 	processDir(db, filepath.Join(rootDir, "全唐诗"), "quantangshi", "唐", func(filename string) bool {
 		return strings.HasPrefix(filename, "poet.tang.")
 	})
 	processAuthors(db, filepath.Join(rootDir, "全唐诗", "authors.tang.json"), "唐")
-	processPoemFile(db, filepath.Join(rootDir, "全唐诗", "唐诗三百首.json"), "quantangshi", "唐")
+	processAnthology(db, filepath.Join(rootDir, "全唐诗", "唐诗三百首.json"), "tangshi300", "quantangshi", "唐")
 
 	// 5. 处理宋词
 	processDir(db, filepath.Join(rootDir, "宋词"), "songci", "宋", func(filename string) bool {
 		return strings.HasPrefix(filename, "ci.song.")
 	})
 	processAuthors(db, filepath.Join(rootDir, "宋词", "author.song.json"), "宋")
-	processPoemFile(db, filepath.Join(rootDir, "宋词", "宋词三百首.json"), "songci", "宋")
+	processAnthology(db, filepath.Join(rootDir, "宋词", "宋词三百首.json"), "songci300", "songci", "宋")
 
 	// 6. 处理元曲
 	processPoemFile(db, filepath.Join(rootDir, "元曲", "yuanqu.json"), "yuanqu", "元")
@@ -168,7 +225,7 @@ func runETL() {
 	processChuCi(db, filepath.Join(rootDir, "楚辞", "chuci.json"), "chuci")
 
 	// 11. 处理曹操诗集
-	processPoemFile(db, filepath.Join(rootDir, "曹操诗集", "caocao.json"), "caocao", "汉/魏")
+	processPoemFile(db, filepath.Join(rootDir, "曹操诗集", "caocao.json"), "caocao", "汉")
 
 	// 12. 处理纳兰性德
 	processPoemFile(db, filepath.Join(rootDir, "纳兰性德", "纳兰性德诗集.json"), "nalan", "清")
@@ -182,7 +239,49 @@ func runETL() {
 	})
 	processPoemFile(db, filepath.Join(rootDir, "五代诗词", "nantang", "poetrys.json"), "wudai", "五代")
 
-	fmt.Println("Done!")
+	// 15. 重新应用管理后台的修改，再写回别名和手工修正过的作者资料
+	applyCorpusOverrides(db)
+	remapRevisions(db)
+	remapCorrections(db)
+	saveAliasRules(db, aliasRules)
+	restoreManualProfiles(db, manualProfiles)
+
+	// 16. 导入完成后再建全文搜索索引（PostgreSQL/MySQL），比逐行维护索引快
+	if err := repository.EnsureSearchIndexes(db); err != nil {
+		slog.Error("create search indexes failed, search falls back to LIKE scans", "error", err)
+	}
+
+	// 17. 记录本次导入的行数，服务端通过 /metrics 输出
+	recordImportRun(db, startedAt)
+
+	// 18. 递增语料的数据版本，运行中的服务端据此清空缓存、更新 ETag
+	if err := repository.BumpDataVersion(db, models.DataVersionCorpus); err != nil {
+		slog.Error("bump data version failed, cached responses may be stale", "error", err)
+	}
+
+	slog.Info("etl finished")
+}
+
+func recordImportRun(db *gorm.DB, startedAt time.Time) {
+	run := models.ImportRun{StartedAt: startedAt}
+	db.Model(&models.Work{}).Count(&run.Works)
+	db.Model(&models.Author{}).Count(&run.Authors)
+	db.Model(&models.AuthorAlias{}).Count(&run.AuthorAliases)
+	db.Model(&models.Comment{}).Count(&run.Comments)
+	db.Model(&models.AnthologyWork{}).Count(&run.AnthologyWorks)
+	run.FinishedAt = time.Now()
+	if err := db.Create(&run).Error; err != nil {
+		slog.Error("record import run failed", "error", err)
+		return
+	}
+	slog.Info("import run recorded",
+		"works", run.Works,
+		"authors", run.Authors,
+		"author_aliases", run.AuthorAliases,
+		"comments", run.Comments,
+		"anthology_works", run.AnthologyWorks,
+		"duration", run.FinishedAt.Sub(startedAt).Round(time.Second).String(),
+	)
 }
 
 func seedCategories(db *gorm.DB) {
@@ -206,18 +305,203 @@ func seedCategories(db *gorm.DB) {
 		db.FirstOrCreate(&c, models.Category{Name: c.Name})
 		cacheMutex.Lock()
 		catCache[c.Name] = c.ID
+		catNames[c.ID] = c.Name
 		cacheMutex.Unlock()
 	}
 }
 
+func seedDynasties(db *gorm.DB) {
+	for i, d := range models.DefaultDynasties {
+		d.SortOrder = i + 1
+		db.FirstOrCreate(&d, models.Dynasty{Code: d.Code})
+		cacheMutex.Lock()
+		dynastyCache[d.Name] = d.ID
+		cacheMutex.Unlock()
+	}
+}
+
+// getDynastyID 返回朝代 ID，数据中出现未收录的朝代时追加到表末尾并告警，
+// 应在 models.DefaultDynasties 或朝代别名中补上；写入失败时终止导入
+func getDynastyID(db *gorm.DB, name string) uint {
+	cacheMutex.RLock()
+	id, ok := dynastyCache[name]
+	cacheMutex.RUnlock()
+	if ok {
+		return id
+	}
+	if name == "" {
+		log.Fatal("dynasty name is empty")
+	}
+
+	dynasty := models.Dynasty{Code: unknownDynastyCode(name), Name: name, SortOrder: len(models.DefaultDynasties) + 1}
+	if err := db.Where(models.Dynasty{Name: name}).FirstOrCreate(&dynasty).Error; err != nil {
+		log.Fatalf("failed to create dynasty %q: %v", name, err)
+	}
+	slog.Warn("unknown dynasty added, add it to the dynasty seed or aliases", "name", name, "code", dynasty.Code)
+
+	cacheMutex.Lock()
+	dynastyCache[name] = dynasty.ID
+	cacheMutex.Unlock()
+	return dynasty.ID
+}
+
+// unknownDynastyCode 未收录朝代的代码。代码用于接口参数，只能是 ASCII，
+// 由名称的哈希生成，同名朝代每次导入得到相同的代码
+func unknownDynastyCode(name string) string {
+	sum := fnv.New32a()
+	sum.Write([]byte(name))
+	return fmt.Sprintf("x-%08x", sum.Sum32())
+}
+
+func seedAnthologies(db *gorm.DB) {
+	anthologies := []models.Anthology{
+		{Name: "tangshi300", DisplayName: "唐诗三百首", Description: "清代蘅塘退士编选的唐诗选本"},
+		{Name: "songci300", DisplayName: "宋词三百首", Description: "清代上彊村民编选的宋词选本"},
+	}
+
+	for _, a := range anthologies {
+		db.FirstOrCreate(&a, models.Anthology{Name: a.Name})
+		cacheMutex.Lock()
+		anthologyCache[a.Name] = a.ID
+		cacheMutex.Unlock()
+	}
+}
+
+func getAnthologyID(name string) uint {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	return anthologyCache[name]
+}
+
 func getCategoryID(name string) uint {
 	cacheMutex.RLock()
 	defer cacheMutex.RUnlock()
 	return catCache[name]
 }
 
+// workSourceKey 计算作品的 SourceKey，同一数据源中完全相同的作品依次追加 #2、#3……
+func workSourceKey(catID, authorID uint, originalID, title string, paragraphs []string) string {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	key := models.WorkSourceKey(catNames[catID], originalID, authorKeys[authorID], title, paragraphs)
+	sourceKeyCount[key]++
+	if n := sourceKeyCount[key]; n > 1 {
+		key = fmt.Sprintf("%s#%d", key, n)
+	}
+	return key
+}
+
+// loadAliasRules 读取内置别名和数据库中已有的别名，并建立导入时使用的索引
+func loadAliasRules(db *gorm.DB) []aliasRule {
+	rules := append([]aliasRule{}, defaultAliasRules...)
+
+	if db.Migrator().HasTable(&models.AuthorAlias{}) {
+		var saved []aliasRule
+		db.Table("author_aliases").
+			Select("author_aliases.name, author_aliases.dynasty, author_aliases.type, authors.name AS author_name, authors.dynasty AS author_dynasty").
+			Joins("JOIN authors ON authors.id = author_aliases.author_id").
+			Scan(&saved)
+		rules = append(rules, saved...)
+	}
+
+	cacheMutex.Lock()
+	for i, r := range rules {
+		r.AuthorDynasty = models.NormalizeDynasty(r.AuthorDynasty)
+		if r.Dynasty != "" {
+			r.Dynasty = models.NormalizeDynasty(r.Dynasty)
+		}
+		rules[i] = r
+		aliasIndex[r.Name+"|"+r.Dynasty] = r
+	}
+	cacheMutex.Unlock()
+	return rules
+}
+
+// resolveAuthorAlias 把别名换成正名并统一朝代写法，未命中时原样返回
+func resolveAuthorAlias(name, dynasty string) (string, string) {
+	dynasty = models.NormalizeDynasty(dynasty)
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+	if r, ok := aliasIndex[name+"|"+dynasty]; ok {
+		return r.AuthorName, r.AuthorDynasty
+	}
+	if r, ok := aliasIndex[name+"|"]; ok {
+		return r.AuthorName, r.AuthorDynasty
+	}
+	return name, dynasty
+}
+
+// saveAliasRules 导入完成后写回别名，正名不在本次数据中的规则会被跳过
+func saveAliasRules(db *gorm.DB, rules []aliasRule) {
+	saved := 0
+	for _, r := range rules {
+		var author models.Author
+		if err := db.Where("name = ? AND dynasty = ?", r.AuthorName, r.AuthorDynasty).First(&author).Error; err != nil {
+			continue
+		}
+		alias := models.AuthorAlias{AuthorID: author.ID, Name: r.Name, Dynasty: r.Dynasty, Type: r.Type}
+		if err := db.Where(models.AuthorAlias{Name: r.Name, Dynasty: r.Dynasty}).FirstOrCreate(&alias).Error; err == nil {
+			saved++
+		}
+	}
+	slog.Info("saved author aliases", "aliases", saved)
+}
+
+// parseProfile 从简介中解析作者资料
+func parseProfile(desc string) models.AuthorProfile {
+	p := biography.Parse(desc)
+	return models.AuthorProfile{
+		BirthYear:    p.BirthYear,
+		BirthApprox:  p.BirthApprox,
+		DeathYear:    p.DeathYear,
+		DeathApprox:  p.DeathApprox,
+		CourtesyName: p.CourtesyName,
+		ArtName:      p.ArtName,
+		NativePlace:  p.NativePlace,
+		EraNames:     models.JSONArr(p.EraNames),
+		Source:       "parsed",
+	}
+}
+
+// loadManualProfiles 读取手工修正过的作者资料，按 name|dynasty 索引
+func loadManualProfiles(db *gorm.DB) map[string]models.AuthorProfile {
+	profiles := make(map[string]models.AuthorProfile)
+	if !db.Migrator().HasColumn(&models.Author{}, "profile_source") {
+		return profiles
+	}
+
+	var authors []models.Author
+	db.Where("profile_source = ?", "manual").Find(&authors)
+	for _, a := range authors {
+		profiles[a.Name+"|"+models.NormalizeDynasty(a.Dynasty)] = a.Profile
+	}
+	return profiles
+}
+
+// restoreManualProfiles 把手工修正的资料覆盖到重新导入的作者上
+func restoreManualProfiles(db *gorm.DB, profiles map[string]models.AuthorProfile) {
+	if len(profiles) == 0 {
+		return
+	}
+	restored := 0
+	for key, profile := range profiles {
+		parts := strings.SplitN(key, "|", 2)
+		var author models.Author
+		if err := db.Where("name = ? AND dynasty = ?", parts[0], parts[1]).First(&author).Error; err != nil {
+			continue
+		}
+		author.Profile = profile
+		if err := db.Save(&author).Error; err == nil {
+			restored++
+		}
+	}
+	slog.Info("restored manual author profiles", "profiles", restored)
+}
+
 func getOrCreateAuthor(db *gorm.DB, name string, dynasty string) uint {
-	key := name + "|" + dynasty
+	name, dynasty = resolveAuthorAlias(name, dynasty)
+	key := models.AuthorSourceKey(name, dynasty)
 	cacheMutex.RLock()
 	if id, ok := authorCache[key]; ok {
 		cacheMutex.RUnlock()
@@ -229,27 +513,27 @@ func getOrCreateAuthor(db *gorm.DB, name string, dynasty string) uint {
 	// Use db (which could be a transaction) to query
 	err := db.Where("name = ? AND dynasty = ?", name, dynasty).First(&author).Error
 	if err != nil {
-		author = models.Author{Name: name, Dynasty: dynasty}
+		author = models.Author{Name: name, Dynasty: dynasty, DynastyID: getDynastyID(db, dynasty), SourceKey: key}
 		db.Create(&author)
 	}
 
 	cacheMutex.Lock()
 	authorCache[key] = author.ID
+	authorKeys[author.ID] = key
 	cacheMutex.Unlock()
 	return author.ID
 }
 
 func processAuthors(db *gorm.DB, filePath string, dynasty string) {
-	fmt.Printf("Processing authors %s... ", filepath.Base(filePath))
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filePath, err)
+		slog.Error("read file failed", "file", filePath, "error", err)
 		return
 	}
 
 	var rawAuthors []RawAuthor
 	if err := json.Unmarshal(content, &rawAuthors); err != nil {
-		log.Printf("Error unmarshal file %s: %v", filePath, err)
+		slog.Error("parse file failed", "file", filePath, "error", err)
 		return
 	}
 
@@ -260,44 +544,51 @@ func processAuthors(db *gorm.DB, filePath string, dynasty string) {
 				desc = ra.Desc
 			}
 
+			name, authorDynasty := resolveAuthorAlias(ra.Name, dynasty)
+
 			var author models.Author
-			err := tx.Where("name = ? AND dynasty = ?", ra.Name, dynasty).First(&author).Error
+			err := tx.Where("name = ? AND dynasty = ?", name, authorDynasty).First(&author).Error
 			if err == nil {
 				// Update existing author with bio
 				if author.Biography == "" && desc != "" {
 					author.Biography = desc
+					author.Profile = parseProfile(desc)
 					tx.Save(&author)
 				}
 			} else {
 				// Create new
 				author = models.Author{
-					Name:      ra.Name,
-					Dynasty:   dynasty,
+					Name:      name,
+					Dynasty:   authorDynasty,
+					DynastyID: getDynastyID(tx, authorDynasty),
 					Biography: desc,
+					Profile:   parseProfile(desc),
+					SourceKey: models.AuthorSourceKey(name, authorDynasty),
 				}
 				tx.Create(&author)
 			}
 
-			key := ra.Name + "|" + dynasty
+			key := models.AuthorSourceKey(name, authorDynasty)
 			cacheMutex.Lock()
 			authorCache[key] = author.ID
+			authorKeys[author.ID] = key
 			cacheMutex.Unlock()
 		}
 		return nil
 	})
 
 	if err != nil {
-		fmt.Printf("Failed: %v\n", err)
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
 	} else {
-		fmt.Printf("Done (%d authors)\n", len(rawAuthors))
+		slog.Info("imported file", "file", filepath.Base(filePath), "authors", len(rawAuthors))
 	}
 }
 
 func processDir(db *gorm.DB, dirPath string, categoryName string, defaultDynasty string, filter func(string) bool) {
-	fmt.Printf("Processing dir %s...\n", dirPath)
+	slog.Info("processing dir", "dir", dirPath)
 	files, err := os.ReadDir(dirPath)
 	if err != nil {
-		log.Printf("Error reading dir %s: %v", dirPath, err)
+		slog.Error("read dir failed", "dir", dirPath, "error", err)
 		return
 	}
 
@@ -315,34 +606,16 @@ func processDir(db *gorm.DB, dirPath string, categoryName string, defaultDynasty
 	}
 }
 
-func normalizeDynasty(d string) string {
-	d = strings.TrimSpace(d)
-	switch strings.ToLower(d) {
-	case "tang":
-		return "唐"
-	case "song":
-		return "宋"
-	case "yuan":
-		return "元"
-	case "ming":
-		return "明"
-	case "qing":
-		return "清"
-	}
-	return d
-}
-
 func processFile(db *gorm.DB, filePath string, catID uint, defaultDynasty string) {
-	fmt.Printf("Processing file %s... ", filepath.Base(filePath))
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filePath, err)
+		slog.Error("read file failed", "file", filePath, "error", err)
 		return
 	}
 
 	var rawPoems []RawPoem
 	if err := json.Unmarshal(content, &rawPoems); err != nil {
-		log.Printf("Error unmarshal file %s: %v", filePath, err)
+		slog.Error("parse file failed", "file", filePath, "error", err)
 		return
 	}
 
@@ -350,14 +623,7 @@ func processFile(db *gorm.DB, filePath string, catID uint, defaultDynasty string
 	err = db.Transaction(func(tx *gorm.DB) error {
 		for _, rp := range rawPoems {
 			// Field normalization
-			paragraphs := rp.Paragraphs
-			if len(paragraphs) == 0 && len(rp.Content) > 0 {
-				paragraphs = rp.Content
-			}
-			if len(paragraphs) == 0 && len(rp.Para) > 0 {
-				paragraphs = rp.Para
-			}
-
+			paragraphs := rp.paragraphs()
 			if len(paragraphs) == 0 {
 				continue
 			}
@@ -371,7 +637,7 @@ func processFile(db *gorm.DB, filePath string, catID uint, defaultDynasty string
 			if dynasty == "" {
 				dynasty = defaultDynasty
 			} else {
-				dynasty = normalizeDynasty(dynasty)
+				dynasty = models.NormalizeDynasty(dynasty)
 			}
 
 			authorName := rp.Author
@@ -397,6 +663,7 @@ func processFile(db *gorm.DB, filePath string, catID uint, defaultDynasty string
 				Volume:     rp.Volume,
 				Section:    rp.Section,
 				Prologue:   rp.Prologue,
+				SourceKey:  workSourceKey(catID, authorID, rp.ID, title, paragraphs),
 			}
 
 			if err := tx.Create(&work).Error; err != nil {
@@ -423,9 +690,9 @@ func processFile(db *gorm.DB, filePath string, catID uint, defaultDynasty string
 	})
 
 	if err != nil {
-		fmt.Printf("Failed: %v\n", err)
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
 	} else {
-		fmt.Printf("Done (%d works)\n", len(rawPoems))
+		slog.Info("imported file", "file", filepath.Base(filePath), "works", len(rawPoems))
 	}
 }
 
@@ -434,11 +701,130 @@ func processPoemFile(db *gorm.DB, filePath string, categoryName string, defaultD
 	processFile(db, filePath, catID, defaultDynasty)
 }
 
+// processAnthology 导入选集文件：作品优先关联全集中已有的记录，找不到时才新建
+func processAnthology(db *gorm.DB, filePath string, anthologyName string, categoryName string, defaultDynasty string) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		slog.Error("read file failed", "file", filePath, "error", err)
+		return
+	}
+
+	var rawPoems []RawPoem
+	if err := json.Unmarshal(content, &rawPoems); err != nil {
+		slog.Error("parse file failed", "file", filePath, "error", err)
+		return
+	}
+
+	anthologyID := getAnthologyID(anthologyName)
+	catID := getCategoryID(categoryName)
+	matched, created := 0, 0
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for i, rp := range rawPoems {
+			paragraphs := rp.paragraphs()
+			if len(paragraphs) == 0 {
+				continue
+			}
+
+			title := rp.Title
+			if title == "" {
+				title = rp.Rhythmic
+			}
+
+			dynasty := defaultDynasty
+			if rp.Dynasty != "" {
+				dynasty = models.NormalizeDynasty(rp.Dynasty)
+			}
+
+			authorName := rp.Author
+			if authorName == "" {
+				authorName = "Unknown"
+			}
+			authorID := getOrCreateAuthor(tx, authorName, dynasty)
+
+			workID := findExistingWork(tx, catID, authorID, title, paragraphs)
+			if workID != 0 {
+				matched++
+			} else {
+				work := models.Work{
+					CategoryID: catID,
+					AuthorID:   authorID,
+					Title:      title,
+					Rhythmic:   rp.Rhythmic,
+					Content:    models.JSONArr(paragraphs),
+					OriginalID: rp.ID,
+					SourceKey:  workSourceKey(catID, authorID, rp.ID, title, paragraphs),
+				}
+				if err := tx.Create(&work).Error; err != nil {
+					continue
+				}
+				workID = work.ID
+				created++
+			}
+
+			link := models.AnthologyWork{AnthologyID: anthologyID, WorkID: workID, SortOrder: i + 1}
+			tx.Where(models.AnthologyWork{AnthologyID: anthologyID, WorkID: workID}).FirstOrCreate(&link)
+		}
+		return nil
+	})
+
+	if err != nil {
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
+	} else {
+		slog.Info("imported anthology", "file", filepath.Base(filePath), "matched", matched, "new", created)
+	}
+}
+
+// findExistingWork 在全集中查找同一首作品：先按作者+标题，标题重复时再比对正文；
+// 标题对不上时在同一作者的作品中比对正文，作者对不上时在同标题的作品中比对。
+// 只按有索引的列查询候选，正文在内存中比较，避免每首作品都扫描整个分类
+func findExistingWork(tx *gorm.DB, catID, authorID uint, title string, paragraphs []string) uint {
+	var candidates []models.Work
+	tx.Where("category_id = ? AND author_id = ? AND title = ?", catID, authorID, title).Find(&candidates)
+	if len(candidates) == 1 {
+		return candidates[0].ID
+	}
+	if id := matchParagraphs(candidates, paragraphs); id != 0 {
+		return id
+	}
+
+	var sameAuthor []models.Work
+	tx.Select("id", "content").Where("category_id = ? AND author_id = ?", catID, authorID).Find(&sameAuthor)
+	if id := matchParagraphs(sameAuthor, paragraphs); id != 0 {
+		return id
+	}
+
+	var sameTitle []models.Work
+	tx.Select("id", "content").Where("category_id = ? AND title = ?", catID, title).Find(&sameTitle)
+	return matchParagraphs(sameTitle, paragraphs)
+}
+
+// matchParagraphs 返回正文与 paragraphs 相同的第一首作品，没有时返回 0
+func matchParagraphs(works []models.Work, paragraphs []string) uint {
+	for _, w := range works {
+		if sameParagraphs(w.Content, paragraphs) {
+			return w.ID
+		}
+	}
+	return 0
+}
+
+func sameParagraphs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if strings.TrimSpace(a[i]) != strings.TrimSpace(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func processSiShuWuJing(db *gorm.DB, filePath string, defaultAuthor string, categoryName string) {
-	fmt.Printf("Processing %s... ", filepath.Base(filePath))
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filePath, err)
+		slog.Error("read file failed", "file", filePath, "error", err)
 		return
 	}
 
@@ -450,7 +836,7 @@ func processSiShuWuJing(db *gorm.DB, filePath string, defaultAuthor string, cate
 		if err2 := json.Unmarshal(content, &singleObj); err2 == nil {
 			rawData = []RawSiShuWuJing{singleObj}
 		} else {
-			log.Printf("Error unmarshal file %s: %v", filePath, err)
+			slog.Error("parse file failed", "file", filePath, "error", err)
 			return
 		}
 	}
@@ -466,6 +852,7 @@ func processSiShuWuJing(db *gorm.DB, filePath string, defaultAuthor string, cate
 				AuthorID:   authorID,
 				Title:      d.Chapter,
 				Content:    models.JSONArr(d.Paragraphs),
+				SourceKey:  workSourceKey(catID, authorID, "", d.Chapter, d.Paragraphs),
 			}
 			tx.Create(&work)
 		}
@@ -473,23 +860,22 @@ func processSiShuWuJing(db *gorm.DB, filePath string, defaultAuthor string, cate
 	})
 
 	if err != nil {
-		fmt.Printf("Failed: %v\n", err)
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
 	} else {
-		fmt.Printf("Done (%d works)\n", len(rawData))
+		slog.Info("imported file", "file", filepath.Base(filePath), "works", len(rawData))
 	}
 }
 
 func processYouMengYing(db *gorm.DB, filePath string, categoryName string) {
-	fmt.Printf("Processing %s... ", filepath.Base(filePath))
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filePath, err)
+		slog.Error("read file failed", "file", filePath, "error", err)
 		return
 	}
 
 	var rawData []RawYouMengYing
 	if err := json.Unmarshal(content, &rawData); err != nil {
-		log.Printf("Error unmarshal file %s: %v", filePath, err)
+		slog.Error("parse file failed", "file", filePath, "error", err)
 		return
 	}
 
@@ -499,11 +885,13 @@ func processYouMengYing(db *gorm.DB, filePath string, categoryName string) {
 		authorID := getOrCreateAuthor(tx, "张潮", "清")
 
 		for i, d := range rawData {
+			title := fmt.Sprintf("幽梦影-%d", i+1)
 			work := models.Work{
 				CategoryID: catID,
 				AuthorID:   authorID,
-				Title:      fmt.Sprintf("幽梦影-%d", i+1),
+				Title:      title,
 				Content:    models.JSONArr([]string{d.Content}),
+				SourceKey:  workSourceKey(catID, authorID, "", title, []string{d.Content}),
 			}
 			tx.Create(&work)
 
@@ -534,23 +922,22 @@ func processYouMengYing(db *gorm.DB, filePath string, categoryName string) {
 	})
 
 	if err != nil {
-		fmt.Printf("Failed: %v\n", err)
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
 	} else {
-		fmt.Printf("Done (%d items)\n", len(rawData))
+		slog.Info("imported file", "file", filepath.Base(filePath), "items", len(rawData))
 	}
 }
 
 func processShiJing(db *gorm.DB, filePath string, categoryName string) {
-	fmt.Printf("Processing %s... ", filepath.Base(filePath))
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filePath, err)
+		slog.Error("read file failed", "file", filePath, "error", err)
 		return
 	}
 
 	var rawPoems []RawPoem
 	if err := json.Unmarshal(content, &rawPoems); err != nil {
-		log.Printf("Error unmarshal file %s: %v", filePath, err)
+		slog.Error("parse file failed", "file", filePath, "error", err)
 		return
 	}
 
@@ -568,6 +955,7 @@ func processShiJing(db *gorm.DB, filePath string, categoryName string) {
 				Volume:     rp.Chapter,                 // 国风
 				Section:    rp.Section,                 // 周南
 				Content:    models.JSONArr(rp.Content), // Shijing uses 'content'
+				SourceKey:  workSourceKey(catID, authorID, "", rp.Title, rp.Content),
 			}
 			tx.Create(&work)
 		}
@@ -575,23 +963,22 @@ func processShiJing(db *gorm.DB, filePath string, categoryName string) {
 	})
 
 	if err != nil {
-		fmt.Printf("Failed: %v\n", err)
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
 	} else {
-		fmt.Printf("Done (%d works)\n", len(rawPoems))
+		slog.Info("imported file", "file", filepath.Base(filePath), "works", len(rawPoems))
 	}
 }
 
 func processChuCi(db *gorm.DB, filePath string, categoryName string) {
-	fmt.Printf("Processing %s... ", filepath.Base(filePath))
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Printf("Error reading file %s: %v", filePath, err)
+		slog.Error("read file failed", "file", filePath, "error", err)
 		return
 	}
 
 	var rawPoems []RawPoem
 	if err := json.Unmarshal(content, &rawPoems); err != nil {
-		log.Printf("Error unmarshal file %s: %v", filePath, err)
+		slog.Error("parse file failed", "file", filePath, "error", err)
 		return
 	}
 
@@ -611,6 +998,7 @@ func processChuCi(db *gorm.DB, filePath string, categoryName string) {
 				Title:      rp.Title,
 				Section:    rp.Section, // 离骚
 				Content:    models.JSONArr(rp.Content),
+				SourceKey:  workSourceKey(catID, authorID, "", rp.Title, rp.Content),
 			}
 			tx.Create(&work)
 		}
@@ -618,8 +1006,8 @@ func processChuCi(db *gorm.DB, filePath string, categoryName string) {
 	})
 
 	if err != nil {
-		fmt.Printf("Failed: %v\n", err)
+		slog.Error("import file failed", "file", filepath.Base(filePath), "error", err)
 	} else {
-		fmt.Printf("Done (%d works)\n", len(rawPoems))
+		slog.Info("imported file", "file", filepath.Base(filePath), "works", len(rawPoems))
 	}
 }
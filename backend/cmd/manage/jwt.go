@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"poem/backend/pkg/auth"
+	"time"
+)
+
+func runJWT() {
+	if len(os.Args) < 2 || os.Args[1] != "genkey" {
+		printJWTUsage()
+		os.Exit(1)
+	}
+	runJWTGenKey(os.Args[2:])
+}
+
+func printJWTUsage() {
+	fmt.Println("Usage: manage jwt genkey [flags]")
+	fmt.Println("  genkey -alg RS256|EdDSA -dir DIR [-kid ID]  Write a new private key to DIR/<kid>.pem")
+	fmt.Println()
+	fmt.Println("To rotate keys, generate a new key into JWT_KEYS_DIR and point JWT_ACTIVE_KID at it.")
+	fmt.Println("Keep the old key file until its tokens have expired, or replace it with its public key (<kid>.pub.pem).")
+}
+
+func runJWTGenKey(args []string) {
+	fs := flag.NewFlagSet("jwt genkey", flag.ExitOnError)
+	alg := fs.String("alg", auth.AlgEdDSA, "Key algorithm: RS256 or EdDSA")
+	dir := fs.String("dir", "keys", "Directory to write the key to (JWT_KEYS_DIR)")
+	kid := fs.String("kid", "", "Key ID, defaults to <alg>-<date>")
+	fs.Parse(args)
+
+	if *kid == "" {
+		*kid = fmt.Sprintf("%s-%s", *alg, time.Now().Format("20060102150405"))
+	}
+
+	var key crypto.PrivateKey
+	var err error
+	switch *alg {
+	case auth.AlgRS256:
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case auth.AlgEdDSA:
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		log.Fatalf("Unsupported algorithm %q", *alg)
+	}
+	if err != nil {
+		log.Fatal("Failed to generate key:", err)
+	}
+
+	data, err := auth.MarshalPrivateKeyPEM(key)
+	if err != nil {
+		log.Fatal("Failed to encode key:", err)
+	}
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		log.Fatal("Failed to create key directory:", err)
+	}
+	path := filepath.Join(*dir, *kid+".pem")
+	if _, err := os.Stat(path); err == nil {
+		log.Fatalf("%s already exists", path)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		log.Fatal("Failed to write key:", err)
+	}
+
+	fmt.Printf("✅ Wrote %s key %s to %s\n", *alg, *kid, path)
+	fmt.Printf("   JWT_ALGORITHM=%s JWT_KEYS_DIR=%s JWT_ACTIVE_KID=%s\n", *alg, *dir, *kid)
+}
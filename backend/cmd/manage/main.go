@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"poem/backend/pkg/logging"
+	"poem/backend/repository"
 )
 
 func main() {
@@ -21,6 +24,14 @@ func main() {
 		runMigrate()
 	case "etl":
 		runETL()
+	case "authors":
+		runAuthors()
+	case "user":
+		runUser()
+	case "jwt":
+		runJWT()
+	case "config":
+		runConfig()
 	default:
 		fmt.Printf("Unknown command: %s\n", cmd)
 		printUsage()
@@ -31,8 +42,15 @@ func main() {
 func printUsage() {
 	fmt.Println("Usage: manage <command> [args]")
 	fmt.Println("Commands:")
-	fmt.Println("  migrate  Run database migrations (users table)")
+	fmt.Println("  migrate  Manage the database schema (up|down|status|to N)")
 	fmt.Println("  etl      Run ETL process to import poems (requires chinese-poetry data)")
+	fmt.Println("  authors  Find, merge and alias authors (find|merge|alias)")
+	fmt.Println("  user     Manage user accounts (create-admin|grant|disable)")
+	fmt.Println("  jwt      Generate JWT signing keys (genkey)")
+	fmt.Println("  config   Show the effective server configuration (print)")
+	fmt.Println()
+	fmt.Println("Commands use the SQLite file given by -db (default: poems.db in the project root).")
+	fmt.Println("Set DB_DRIVER=postgres|mysql and DB_DSN to use another database.")
 }
 
 func findProjectRoot() string {
@@ -86,3 +104,36 @@ func getDBPath(customPath string) string {
 	root := findProjectRoot()
 	return filepath.Join(root, "poems.db")
 }
+
+// dbSource 数据库驱动和数据源，与服务端一样通过 DB_DRIVER、DB_DSN 选择 PostgreSQL 或 MySQL，
+// 未设置时使用 SQLite 文件（见 getDBPath）
+func dbSource(dbPath string) (driver, dsn string) {
+	driver = os.Getenv("DB_DRIVER")
+	if driver == "" || driver == repository.DriverSQLite {
+		return repository.DriverSQLite, getDBPath(dbPath)
+	}
+	return driver, os.Getenv("DB_DSN")
+}
+
+// describeSource 用于输出的数据源描述，不含 DSN 中的密码
+func describeSource(driver, dsn string) string {
+	if driver == repository.DriverSQLite {
+		return dsn
+	}
+	return driver + " (DB_DSN)"
+}
+
+// setupLogging 长时间运行的命令（如 etl）输出结构化日志，级别和格式与服务端一样
+// 通过 LOG_LEVEL、LOG_FORMAT 设置，默认 info 级别的文本格式
+func setupLogging() {
+	level, format := os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")
+	if level == "" {
+		level = "info"
+	}
+	logger, err := logging.New(os.Stderr, level, format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+}
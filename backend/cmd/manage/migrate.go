@@ -1,81 +1,163 @@
 package main
 
 import (
-	"database/sql"
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
-
-	_ "github.com/glebarez/sqlite"
+	"poem/backend/migrations"
+	"poem/backend/repository"
+	"strconv"
+	"strings"
 )
 
 func runMigrate() {
-	// Parse flags
-	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
-	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
-	fs.Parse(os.Args)
+	// 不带子命令（或直接跟 -db）时等同于 up，兼容旧的 manage migrate -db X
+	sub := "up"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+
+	switch sub {
+	case "up":
+		runMigrateUp(args)
+	case "down":
+		runMigrateDown(args)
+	case "status":
+		runMigrateStatus(args)
+	case "to":
+		runMigrateTo(args)
+	default:
+		fmt.Printf("Unknown migrate command: %s\n", sub)
+		printMigrateUsage()
+		os.Exit(1)
+	}
+}
 
-	finalDBPath := getDBPath(*dbPath)
+func printMigrateUsage() {
+	fmt.Println("Usage: manage migrate <command> [flags]")
+	fmt.Println("Commands:")
+	fmt.Println("  up                 Apply all pending migrations (default)")
+	fmt.Println("  down [-steps N]    Roll back the last N applied migrations (default 1)")
+	fmt.Println("  status             List migrations and whether they are applied")
+	fmt.Println("  to N               Migrate up or down to version N (0 rolls back everything)")
+	fmt.Println("All commands accept -db PATH.")
+}
 
-	fmt.Printf("Using database: %s\n", finalDBPath)
+func openMigrator(dbPath string) *migrations.Migrator {
+	driver, dsn := dbSource(dbPath)
+	fmt.Printf("Using database: %s\n", describeSource(driver, dsn))
 
-	// Connect to database
-	db, err := sql.Open("sqlite", finalDBPath)
+	db, err := repository.OpenDB(driver, dsn)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	defer db.Close()
-
-	// Find SQL file
-	root := findProjectRoot()
-	
-	// Search candidates for SQL file
-	candidates := []string{
-		filepath.Join(root, "backend", "migrations", "create_users_table.sql"),
-		filepath.Join(root, "migrations", "create_users_table.sql"),
+	m, err := migrations.New(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
 	}
+	return m
+}
 
-	var foundPath string
-	for _, p := range candidates {
-		if _, err := os.Stat(p); err == nil {
-			foundPath = p
-			break
-		}
+func runMigrateUp(args []string) {
+	fs := flag.NewFlagSet("migrate up", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	fs.Parse(args)
+
+	m := openMigrator(*dbPath)
+	done, err := m.Up(context.Background())
+	printApplied(done, "Applied")
+	if err != nil {
+		log.Fatal(err)
 	}
+	if len(done) == 0 {
+		fmt.Printf("Already at version %d, nothing to do\n", m.Latest())
+		return
+	}
+	fmt.Printf("✅ Migrated to version %d\n", m.Latest())
+}
 
-	if foundPath == "" {
-		// Fallback for robustness: try looking around if not found in standard location
-		possiblePaths := []string{
-			"backend/migrations/create_users_table.sql",
-			"migrations/create_users_table.sql",
-			"../../migrations/create_users_table.sql",
-		}
-		for _, p := range possiblePaths {
-			if _, err := os.Stat(p); err == nil {
-				foundPath = p
-				break
-			}
-		}
-		if foundPath == "" {
-			log.Fatalf("Failed to read SQL file. Searched in %v and fallback paths", candidates)
-		}
+func runMigrateDown(args []string) {
+	fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	steps := fs.Int("steps", 1, "Number of migrations to roll back")
+	fs.Parse(args)
+
+	if *steps < 1 {
+		log.Fatal("-steps must be at least 1")
 	}
 
-	fmt.Printf("Applying migration from: %s\n", foundPath)
+	m := openMigrator(*dbPath)
+	ctx := context.Background()
+	done, err := m.Down(ctx, *steps)
+	printApplied(done, "Rolled back")
+	if err != nil {
+		log.Fatal(err)
+	}
+	printVersion(ctx, m)
+}
+
+func runMigrateTo(args []string) {
+	if len(args) < 1 || strings.HasPrefix(args[0], "-") {
+		printMigrateUsage()
+		os.Exit(1)
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil || version < 0 {
+		log.Fatalf("Invalid version: %s", args[0])
+	}
+
+	fs := flag.NewFlagSet("migrate to", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	fs.Parse(args[1:])
 
-	// Read SQL file
-	sqlBytes, err := os.ReadFile(foundPath)
+	m := openMigrator(*dbPath)
+	ctx := context.Background()
+	done, err := m.To(ctx, version)
+	printApplied(done, "Migrated")
 	if err != nil {
-		log.Fatal("Failed to read SQL file:", err)
+		log.Fatal(err)
 	}
+	printVersion(ctx, m)
+}
+
+func runMigrateStatus(args []string) {
+	fs := flag.NewFlagSet("migrate status", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	fs.Parse(args)
 
-	// Execute SQL
-	_, err = db.Exec(string(sqlBytes))
+	m := openMigrator(*dbPath)
+	status, err := m.Status(context.Background())
 	if err != nil {
-		log.Fatal("Failed to execute SQL:", err)
+		log.Fatal("Failed to read migration status:", err)
+	}
+
+	fmt.Printf("%-8s %-32s %-8s %s\n", "VERSION", "NAME", "STATUS", "APPLIED AT")
+	pending := 0
+	for _, s := range status {
+		state, appliedAt := "pending", ""
+		if s.Applied {
+			state, appliedAt = "applied", s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		fmt.Printf("%-8s %-32s %-8s %s\n", fmt.Sprintf("%04d", s.Version), s.Name, state, appliedAt)
+	}
+	fmt.Printf("Latest version: %d, pending: %d\n", m.Latest(), pending)
+}
+
+func printApplied(done []migrations.Migration, verb string) {
+	for _, mig := range done {
+		fmt.Printf("%s %04d_%s\n", verb, mig.Version, mig.Name)
 	}
+}
 
-	fmt.Println("✅ Migration completed successfully!")
+func printVersion(ctx context.Context, m *migrations.Migrator) {
+	version, err := m.Version(ctx)
+	if err != nil {
+		log.Fatal("Failed to read schema version:", err)
+	}
+	fmt.Printf("✅ Now at version %d (latest %d)\n", version, m.Latest())
 }
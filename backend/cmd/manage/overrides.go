@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"poem/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// applyCorpusOverrides 重新应用管理后台对作者和作品的修改。
+// 先处理作者的新建和修改，作品引用的作者才能找到；删除作者放在最后，
+// 因为被删作者原有的作品此时已按修改记录删除或改到其他作者名下
+func applyCorpusOverrides(db *gorm.DB) {
+	var overrides []models.CorpusOverride
+	if err := db.Order("id").Find(&overrides).Error; err != nil {
+		slog.Error("load corpus overrides failed", "error", err)
+		return
+	}
+	if len(overrides) == 0 {
+		return
+	}
+
+	applied, skipped := 0, 0
+	count := func(err error, o models.CorpusOverride) {
+		if err != nil {
+			slog.Warn("skip corpus override", "entity", o.EntityType, "source_key", o.SourceKey, "action", o.Action, "error", err)
+			skipped++
+			return
+		}
+		applied++
+	}
+
+	var authorDeletes []models.CorpusOverride
+	for _, o := range overrides {
+		if o.EntityType != models.OverrideEntityAuthor {
+			continue
+		}
+		if o.Action == models.OverrideActionDelete {
+			authorDeletes = append(authorDeletes, o)
+			continue
+		}
+		count(applyAuthorOverride(db, o), o)
+	}
+	for _, o := range overrides {
+		if o.EntityType == models.OverrideEntityWork {
+			count(applyWorkOverride(db, o), o)
+		}
+	}
+	for _, o := range authorDeletes {
+		count(deleteOverriddenAuthor(db, o), o)
+	}
+
+	slog.Info("applied corpus overrides", "applied", applied, "skipped", skipped)
+}
+
+func applyAuthorOverride(db *gorm.DB, o models.CorpusOverride) error {
+	var patch models.AuthorPatch
+	if err := json.Unmarshal([]byte(o.Data), &patch); err != nil {
+		return err
+	}
+
+	var author models.Author
+	if o.Action == models.OverrideActionCreate {
+		author.SourceKey = o.SourceKey
+	} else if err := db.Where("source_key = ?", o.SourceKey).First(&author).Error; err != nil {
+		return err
+	}
+
+	patch.ApplyTo(&author)
+	if patch.Dynasty != nil {
+		author.DynastyID = getDynastyID(db, author.Dynasty)
+	}
+	return db.Omit(clause.Associations).Save(&author).Error
+}
+
+func deleteOverriddenAuthor(db *gorm.DB, o models.CorpusOverride) error {
+	var author models.Author
+	if err := db.Where("source_key = ?", o.SourceKey).First(&author).Error; err != nil {
+		return err
+	}
+
+	var works int64
+	db.Model(&models.Work{}).Where("author_id = ?", author.ID).Count(&works)
+	if works > 0 {
+		return fmt.Errorf("author still has %d works", works)
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("author_id = ?", author.ID).Delete(&models.AuthorAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Author{}, author.ID).Error
+	})
+}
+
+func applyWorkOverride(db *gorm.DB, o models.CorpusOverride) error {
+	var work models.Work
+	if o.Action == models.OverrideActionCreate {
+		work.SourceKey = o.SourceKey
+	} else if err := db.Where("source_key = ?", o.SourceKey).First(&work).Error; err != nil {
+		return err
+	}
+
+	if o.Action == models.OverrideActionDelete {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("work_id = ?", work.ID).Delete(&models.Comment{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("work_id = ?", work.ID).Delete(&models.AnthologyWork{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&models.Work{}, work.ID).Error
+		})
+	}
+
+	var patch models.WorkPatch
+	if err := json.Unmarshal([]byte(o.Data), &patch); err != nil {
+		return err
+	}
+
+	patch.ApplyTo(&work)
+	if patch.AuthorKey != nil {
+		var author models.Author
+		if err := db.Where("source_key = ?", *patch.AuthorKey).First(&author).Error; err != nil {
+			return fmt.Errorf("author %s: %w", *patch.AuthorKey, err)
+		}
+		work.AuthorID = author.ID
+	}
+	if patch.Category != nil {
+		catID := getCategoryID(*patch.Category)
+		if catID == 0 {
+			return fmt.Errorf("unknown category %s", *patch.Category)
+		}
+		work.CategoryID = catID
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(&work).Error; err != nil {
+			return err
+		}
+		if patch.Comments == nil {
+			return nil
+		}
+
+		// 注释以修改记录中的完整列表为准
+		if err := tx.Where("work_id = ?", work.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		for _, c := range *patch.Comments {
+			comment := models.Comment{
+				WorkID:         work.ID,
+				Content:        c.Content,
+				Type:           c.Type,
+				Commenter:      c.Commenter,
+				ParagraphIndex: c.ParagraphIndex,
+			}
+			if err := tx.Create(&comment).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// remapRevisions 重新导入后作品和作者的ID可能变化，按 source_key 更新修订历史中的ID。
+// 已删除的记录找不到对应行，保留原来的ID。注释没有稳定的键，entity_id 无法更新，
+// 回滚时会拒绝早于本次导入的注释修订；快照里引用的作者、作品在回滚时按 source_key 解析
+func remapRevisions(db *gorm.DB) {
+	if !db.Migrator().HasTable(&models.WorkRevision{}) {
+		return
+	}
+
+	statements := []string{
+		`UPDATE work_revisions SET entity_id = COALESCE((SELECT id FROM works WHERE works.source_key = work_revisions.source_key), entity_id)
+			WHERE entity_type = 'work'`,
+		`UPDATE work_revisions SET work_id = COALESCE((SELECT id FROM works WHERE works.source_key = work_revisions.source_key), work_id)
+			WHERE entity_type IN ('work', 'comment')`,
+		`UPDATE work_revisions SET entity_id = COALESCE((SELECT id FROM authors WHERE authors.source_key = work_revisions.source_key), entity_id)
+			WHERE entity_type = 'author'`,
+	}
+	var updated int64
+	for _, stmt := range statements {
+		result := db.Exec(stmt)
+		if result.Error != nil {
+			slog.Error("remap revision history failed", "error", result.Error)
+			return
+		}
+		updated += result.RowsAffected
+	}
+	slog.Info("remapped revision history", "rows", updated)
+}
+
+// remapCorrections 按纠错记录的 work_key、author_key 更新其中的作品和作者ID，
+// 对应记录已被删除时保留原来的ID
+func remapCorrections(db *gorm.DB) {
+	if !db.Migrator().HasTable(&models.Correction{}) {
+		return
+	}
+
+	statements := []string{
+		`UPDATE corrections SET work_id = COALESCE((SELECT id FROM works WHERE works.source_key = corrections.work_key), work_id)
+			WHERE work_key <> ''`,
+		`UPDATE corrections SET author_id = COALESCE((SELECT id FROM authors WHERE authors.source_key = corrections.author_key), author_id)
+			WHERE author_key <> ''`,
+	}
+	var updated int64
+	for _, stmt := range statements {
+		result := db.Exec(stmt)
+		if result.Error != nil {
+			slog.Error("remap corrections failed", "error", result.Error)
+			return
+		}
+		updated += result.RowsAffected
+	}
+	slog.Info("remapped corrections", "rows", updated)
+}
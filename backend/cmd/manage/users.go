@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"poem/backend/pkg/auth"
+	"poem/backend/repository"
+	"poem/backend/services/user"
+	"strings"
+)
+
+func runUser() {
+	if len(os.Args) < 2 {
+		printUserUsage()
+		os.Exit(1)
+	}
+
+	sub := os.Args[1]
+	args := os.Args[2:]
+
+	switch sub {
+	case "create-admin":
+		runUserCreateAdmin(args)
+	case "grant":
+		runUserGrant(args)
+	case "disable":
+		runUserDisable(args)
+	default:
+		fmt.Printf("Unknown user command: %s\n", sub)
+		printUserUsage()
+		os.Exit(1)
+	}
+}
+
+func printUserUsage() {
+	fmt.Println("Usage: manage user <command> [flags]")
+	fmt.Println("Commands:")
+	fmt.Println("  create-admin -username NAME [-password P] [-email E]  Create an admin account (prompts for the password)")
+	fmt.Println("  grant   -username NAME -role user|editor|admin         Change the role of an account")
+	fmt.Println("  disable -username NAME                                 Disable an account and revoke all of its tokens")
+}
+
+// openUserService 在管理命令中构造用户服务，不签发token，因此不需要JWT管理器
+func openUserService(dbPath string) (*user.UserService, repository.UserRepository) {
+	db := openPoetryRepository(dbPath).DB()
+
+	userRepo, err := repository.NewUserRepository(db)
+	if err != nil {
+		log.Fatal("Failed to migrate users:", err)
+	}
+	sessionRepo, err := repository.NewSessionRepository(db)
+	if err != nil {
+		log.Fatal("Failed to migrate sessions:", err)
+	}
+	revokedRepo, err := repository.NewRevokedTokenRepository(db)
+	if err != nil {
+		log.Fatal("Failed to migrate revoked tokens:", err)
+	}
+
+	denylist := auth.NewDenylist(revokedRepo, 0)
+	return user.NewUserService(userRepo, sessionRepo, nil, denylist), userRepo
+}
+
+func runUserDisable(args []string) {
+	fs := flag.NewFlagSet("user disable", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	username := fs.String("username", "", "Username of the account to disable")
+	fs.Parse(args)
+
+	if *username == "" {
+		log.Fatal("-username is required")
+	}
+
+	ctx := context.Background()
+	userService, userRepo := openUserService(*dbPath)
+
+	u, err := userRepo.GetByUsername(ctx, *username)
+	if err != nil {
+		log.Fatal("User not found:", err)
+	}
+	if err := userService.DisableUser(ctx, u.ID); err != nil {
+		log.Fatal("Failed to disable user:", err)
+	}
+
+	fmt.Printf("✅ User %s (#%d) disabled, all sessions revoked\n", u.Username, u.ID)
+}
+
+func runUserCreateAdmin(args []string) {
+	fs := flag.NewFlagSet("user create-admin", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	username := fs.String("username", "", "Username of the new admin")
+	password := fs.String("password", "", "Password (read from stdin when omitted)")
+	email := fs.String("email", "", "Email address")
+	fs.Parse(args)
+
+	if *username == "" {
+		log.Fatal("-username is required")
+	}
+	if *password == "" {
+		*password = readPassword()
+	}
+	if len(*password) < 6 {
+		log.Fatal("Password must be at least 6 characters")
+	}
+
+	userService, _ := openUserService(*dbPath)
+	u, err := userService.CreateUser(context.Background(), &user.RegisterRequest{
+		Username: *username,
+		Password: *password,
+		Email:    *email,
+	}, auth.RoleAdmin)
+	if err == user.ErrUserAlreadyExists {
+		log.Fatalf("User %s already exists, use `manage user grant -username %s -role admin` instead", *username, *username)
+	}
+	if err != nil {
+		log.Fatal("Failed to create admin:", err)
+	}
+
+	fmt.Printf("✅ Admin %s (#%d) created\n", u.Username, u.ID)
+}
+
+func readPassword() string {
+	fmt.Print("Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatal("Failed to read password:", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
+
+func runUserGrant(args []string) {
+	fs := flag.NewFlagSet("user grant", flag.ExitOnError)
+	dbPath := fs.String("db", "poems.db", "Path to SQLite database")
+	username := fs.String("username", "", "Username of the account")
+	role := fs.String("role", "", "New role: user, editor or admin")
+	fs.Parse(args)
+
+	if *username == "" || *role == "" {
+		log.Fatal("-username and -role are required")
+	}
+	if !auth.ValidRole(*role) {
+		log.Fatalf("Unknown role %q", *role)
+	}
+
+	ctx := context.Background()
+	userService, userRepo := openUserService(*dbPath)
+
+	u, err := userRepo.GetByUsername(ctx, *username)
+	if err != nil {
+		log.Fatal("User not found:", err)
+	}
+	if _, err := userService.SetRole(ctx, u.ID, *role); err != nil {
+		log.Fatal("Failed to change role:", err)
+	}
+
+	fmt.Printf("✅ %s (#%d) is now %s; existing sessions were signed out\n", u.Username, u.ID, *role)
+}
@@ -1,59 +1,226 @@
 package config
 
 import (
-	"os"
-	"path/filepath"
+	"time"
 )
 
-// Config 应用配置
+// devJWTSecret 开发环境未配置 JWT_SECRET 时使用的默认密钥，生产环境必须显式配置
+const devJWTSecret = "your-secret-key-change-in-production"
+
+// 运行环境
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+)
+
+// Config 应用配置。按 默认值 → 配置文件 → 环境变量 → 命令行参数 的顺序叠加，
+// yaml 标签为配置文件中的键，env 标签为对应的环境变量，secret 标记的项在打印时隐藏
 type Config struct {
-	Port     string
-	DataPath string
-	DBPath   string
-	Env      string
-}
-
-// Load 加载配置
-func Load() *Config {
-	// 获取项目根目录
-	rootDir, err := os.Getwd()
-	if err != nil {
-		rootDir = "."
-	}
+	Env        string           `yaml:"env" env:"ENV"`
+	DataPath   string           `yaml:"data_path" env:"DATA_PATH"` // chinese-poetry 数据目录，ETL 使用
+	Server     ServerConfig     `yaml:"server"`
+	Database   DatabaseConfig   `yaml:"database"`
+	Pagination PaginationConfig `yaml:"pagination"`
+	JWT        JWTConfig        `yaml:"jwt"`
+	Mail       MailConfig       `yaml:"mail"`
+	Login      LoginConfig      `yaml:"login"`
+	Limits     RateLimitConfig  `yaml:"rate_limit"`
+	Metrics    MetricsConfig    `yaml:"metrics"`
+	Cache      CacheConfig      `yaml:"cache"`
+	Log        LogConfig        `yaml:"log"`
+	Tracing    TracingConfig    `yaml:"tracing"`
+}
 
-	// 向上查找项目根目录
-	var dataDirName = "chinese-poetry-master"
-	for {
-		if _, err := os.Stat(filepath.Join(rootDir, "chinese-poetry-master")); err == nil {
-			dataDirName = "chinese-poetry-master"
-			break
-		}
-		if _, err := os.Stat(filepath.Join(rootDir, "chinese-poetry")); err == nil {
-			dataDirName = "chinese-poetry"
-			break
-		}
-		parent := filepath.Dir(rootDir)
-		if parent == rootDir {
-			break
-		}
-		rootDir = parent
-	}
+// ServerConfig HTTP服务配置
+type ServerConfig struct {
+	Port        int      `yaml:"port" env:"PORT"`
+	FrontendDir string   `yaml:"frontend_dir" env:"FRONTEND_DIR"` // 前端构建产物目录，为空时不提供静态文件
+	CORSOrigins []string `yaml:"cors_origins" env:"CORS_ORIGINS"` // 允许跨域的来源，"*" 表示全部，为空时不允许跨域
+	// TrustedProxies 可信反向代理的 IP 或 CIDR。只有来自这些地址的请求才按 X-Forwarded-For 取客户端IP，
+	// 为空时一律使用连接的对端地址，避免客户端伪造IP绕过限流和登录锁定
+	TrustedProxies []string `yaml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
 
-	dataPath := filepath.Join(rootDir, dataDirName)
-	// 数据库路径默认为项目根目录下的 poems.db
-	dbPath := filepath.Join(rootDir, "poems.db")
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"` // 读取请求头的超时
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"SERVER_READ_TIMEOUT"`               // 读取整个请求（含请求体）的超时
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`             // 从读完请求头到写完响应的超时
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`               // keep-alive 连接的空闲超时
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`       // 收到 SIGINT/SIGTERM 后等待进行中请求完成的时间
+}
 
-	return &Config{
-		Port:     getEnv("PORT", "8080"),
-		DataPath: getEnv("DATA_PATH", dataPath),
-		DBPath:   getEnv("DB_PATH", dbPath),
-		Env:      getEnv("ENV", "development"),
+// DatabaseConfig 数据库配置
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver" env:"DB_DRIVER"`         // sqlite、postgres 或 mysql
+	Path            string        `yaml:"path" env:"DB_PATH"`             // SQLite 数据库文件
+	DSN             string        `yaml:"dsn" env:"DB_DSN" secret:"true"` // PostgreSQL/MySQL 连接串，可能含密码
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	SlowQuery       time.Duration `yaml:"slow_query" env:"DB_SLOW_QUERY"` // 超过该耗时的语句记录为慢查询，0 表示不记录
+}
+
+// DataSource 传给驱动的数据源：SQLite 为文件路径，其他数据库为 DSN
+func (d DatabaseConfig) DataSource() string {
+	if d.Driver == "sqlite" {
+		return d.Path
 	}
+	return d.DSN
+}
+
+// PaginationConfig 列表接口的分页大小
+type PaginationConfig struct {
+	DefaultPageSize int `yaml:"default_page_size" env:"DEFAULT_PAGE_SIZE"` // 未指定或超出上限时使用
+	MaxPageSize     int `yaml:"max_page_size" env:"MAX_PAGE_SIZE"`
+}
+
+// JWTConfig JWT签名配置
+type JWTConfig struct {
+	Algorithm       string            `yaml:"algorithm" env:"JWT_ALGORITHM"`                             // HS256（默认）、RS256、EdDSA
+	Secret          string            `yaml:"secret" env:"JWT_SECRET" secret:"true"`                     // HS256 密钥
+	SecretID        string            `yaml:"secret_id" env:"JWT_SECRET_ID"`                             // HS256 密钥的 kid
+	PreviousSecrets map[string]string `yaml:"previous_secrets" env:"JWT_PREVIOUS_SECRETS" secret:"true"` // kid → secret，轮换后仅用于验证；环境变量格式 "kid:secret,kid:secret"
+	KeysDir         string            `yaml:"keys_dir" env:"JWT_KEYS_DIR"`                               // PEM 密钥目录，文件名即 kid
+	ActiveKeyID     string            `yaml:"active_kid" env:"JWT_ACTIVE_KID"`                           // 用于签名的 kid
+	TokenDuration   time.Duration     `yaml:"token_duration" env:"JWT_TOKEN_DURATION"`                   // access token有效期，如 15m，过期后用刷新令牌续期
+	Issuer          string            `yaml:"issuer" env:"JWT_ISSUER"`                                   // 写入并校验 iss
+}
+
+// MailConfig 邮件发送配置
+type MailConfig struct {
+	Driver           string `yaml:"driver" env:"MAIL_DRIVER"` // log（只写日志）、file、smtp
+	Dir              string `yaml:"dir" env:"MAIL_DIR"`       // file 驱动保存 .eml 文件的目录
+	From             string `yaml:"from" env:"MAIL_FROM"`     // 发件人地址
+	SMTPHost         string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort         int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername     string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword     string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	PasswordResetURL string `yaml:"password_reset_url" env:"PASSWORD_RESET_URL"` // 前端重置密码页面，令牌以 token 参数附加
 }
 
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+// LoginConfig 登录防暴力破解配置
+type LoginConfig struct {
+	MaxUserFailures int           `yaml:"max_failures" env:"LOGIN_MAX_FAILURES"`       // 同一用户名连续失败多少次后锁定，0 表示不限
+	MaxIPFailures   int           `yaml:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES"` // 同一IP连续失败多少次后锁定，0 表示不限
+	BaseLockout     time.Duration `yaml:"lockout" env:"LOGIN_LOCKOUT"`                 // 首次锁定时长，之后每次失败翻倍
+	MaxLockout      time.Duration `yaml:"max_lockout" env:"LOGIN_MAX_LOCKOUT"`         // 锁定时长上限
+	FailureWindow   time.Duration `yaml:"failure_window" env:"LOGIN_FAILURE_WINDOW"`   // 多久没有失败后重新计数
+}
+
+// LogConfig 日志配置
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug、info、warn、error
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json 或 text
+}
+
+// TracingConfig OpenTelemetry 链路追踪配置
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`         // none、stdout（本地调试）或 otlp
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`         // OTLP/HTTP 地址，为空时使用 OTEL_EXPORTER_OTLP_ENDPOINT 或 http://localhost:4318
+	ServiceName string  `yaml:"service_name" env:"TRACING_SERVICE_NAME"` // 上报的服务名
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // 采样比例 0~1，上游已采样的请求总是记录
+}
+
+// MetricsConfig Prometheus 指标接口 /metrics
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" env:"METRICS_ENABLED"`
+	Token   string `yaml:"token" env:"METRICS_TOKEN" secret:"true"` // 非空时抓取需携带 "Authorization: Bearer <token>"
+}
+
+// CacheConfig 分类、作者列表和诗词详情的查询缓存及 HTTP 协商缓存
+type CacheConfig struct {
+	Enabled       bool          `yaml:"enabled" env:"CACHE_ENABLED"`
+	Size          int           `yaml:"size" env:"CACHE_SIZE"`                     // 内存中最多缓存的查询结果数
+	TTL           time.Duration `yaml:"ttl" env:"CACHE_TTL"`                       // 查询结果的最长缓存时间
+	CheckInterval time.Duration `yaml:"check_interval" env:"CACHE_CHECK_INTERVAL"` // 读取数据版本的间隔，语料修改后最多延迟这么久生效
+	MaxAge        time.Duration `yaml:"max_age" env:"CACHE_MAX_AGE"`               // 响应头 Cache-Control 的 max-age，0 表示每次都需重新验证
+}
+
+// RateLimitConfig /api/v1 各路由组的限流额度，格式为 "次数/s|m|h"，"off" 表示不限流
+type RateLimitConfig struct {
+	List   string `yaml:"list" env:"RATE_LIMIT_LIST"`     // 列表和目录接口
+	Detail string `yaml:"detail" env:"RATE_LIMIT_DETAIL"` // 诗词和作者详情
+	Search string `yaml:"search" env:"RATE_LIMIT_SEARCH"` // 搜索（LIKE 查询）
+	Random string `yaml:"random" env:"RATE_LIMIT_RANDOM"` // 随机诗词（ORDER BY RANDOM()）
+}
+
+// Defaults 返回运行环境的默认配置。相对路径以 backend 目录为工作目录
+func Defaults(env string) *Config {
+	cfg := &Config{
+		Env:      env,
+		DataPath: "../chinese-poetry",
+		Server: ServerConfig{
+			Port:              8080,
+			FrontendDir:       "../frontend/dist",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			Driver:          "sqlite",
+			Path:            "../poems.db",
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+			SlowQuery:       200 * time.Millisecond,
+		},
+		Pagination: PaginationConfig{
+			DefaultPageSize: 20,
+			MaxPageSize:     100,
+		},
+		JWT: JWTConfig{
+			Algorithm:     "HS256",
+			TokenDuration: 15 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver:           "log",
+			Dir:              "mail",
+			From:             "noreply@localhost",
+			SMTPPort:         587,
+			PasswordResetURL: "http://localhost:5173/reset-password",
+		},
+		Login: LoginConfig{
+			MaxUserFailures: 5,
+			MaxIPFailures:   20,
+			BaseLockout:     30 * time.Second,
+			MaxLockout:      time.Hour,
+			FailureWindow:   15 * time.Minute,
+		},
+		Limits: RateLimitConfig{
+			List:   "120/m",
+			Detail: "300/m",
+			Search: "20/m",
+			Random: "30/m",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Cache: CacheConfig{
+			Enabled:       true,
+			Size:          10000,
+			TTL:           10 * time.Minute,
+			CheckInterval: 5 * time.Second,
+			MaxAge:        time.Minute,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "poetry-api",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+	}
+
+	// 开发和测试环境允许任意来源跨域访问（前端开发服务器）；生产环境默认同源，需要时显式配置
+	if env != EnvProduction {
+		cfg.Server.CORSOrigins = []string{"*"}
+	}
+	// 生产环境输出 JSON 日志，便于日志系统采集
+	if env == EnvProduction {
+		cfg.Log.Format = "json"
 	}
-	return defaultValue
+	return cfg
 }
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Load 加载配置：先按运行环境取默认值，再依次叠加配置文件、环境变量和命令行参数，最后校验。
+// 配置文件由 -config 参数或 CONFIG_FILE 环境变量指定，未指定时只使用默认值和环境变量。
+// args 为命令行参数（不含程序名），支持 -config、-env、-port、-db、-data
+func Load(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "配置文件路径（YAML）")
+	env := fs.String("env", "", "运行环境: development、test、production")
+	port := fs.Int("port", 0, "HTTP端口")
+	dbPath := fs.String("db", "", "数据库路径")
+	dataPath := fs.String("data", "", "chinese-poetry 数据目录")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if *configFile != "" {
+		var err error
+		if data, err = os.ReadFile(*configFile); err != nil {
+			return nil, fmt.Errorf("读取配置文件: %w", err)
+		}
+	}
+
+	// 运行环境决定默认值，优先级与其他配置项相同：参数 > 环境变量 > 配置文件
+	envName := *env
+	if envName == "" {
+		envName = os.Getenv("ENV")
+	}
+	if envName == "" && data != nil {
+		var peek struct {
+			Env string `yaml:"env"`
+		}
+		if err := yaml.Unmarshal(data, &peek); err != nil {
+			return nil, fmt.Errorf("解析配置文件 %s: %w", *configFile, err)
+		}
+		envName = peek.Env
+	}
+	if envName == "" {
+		envName = EnvDevelopment
+	}
+
+	cfg := Defaults(envName)
+	if data != nil {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("解析配置文件 %s: %w", *configFile, err)
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem()); err != nil {
+		return nil, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Env = *env
+		case "port":
+			cfg.Server.Port = *port
+		case "db":
+			cfg.Database.Path = *dbPath
+		case "data":
+			cfg.DataPath = *dataPath
+		}
+	})
+
+	// 开发密钥是公开的，只给 HS256 兜底；非对称算法不需要 secret，填上反而会多出一把可伪造token的密钥
+	if cfg.JWT.Secret == "" && cfg.Env != EnvProduction && (cfg.JWT.Algorithm == "" || cfg.JWT.Algorithm == "HS256") {
+		cfg.JWT.Secret = devJWTSecret
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyEnv 用 env 标签对应的环境变量覆盖配置项
+func applyEnv(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := applyEnv(value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(value, raw); err != nil {
+			return fmt.Errorf("环境变量 %s=%q: %w", name, raw, err)
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	case v.Kind() == reflect.Map && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(parseKeyValues(raw)))
+	default:
+		return fmt.Errorf("不支持的配置类型 %s", v.Type())
+	}
+	return nil
+}
+
+// splitList 解析逗号分隔的列表
+func splitList(s string) []string {
+	result := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseKeyValues 解析 "k1:v1,k2:v2" 格式
+func parseKeyValues(s string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if ok && k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"poem/backend/pkg/logging"
+	"poem/backend/pkg/ratelimit"
+	"poem/backend/pkg/tracing"
+	"reflect"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validate 校验配置，返回所有问题
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Env == EnvDevelopment || c.Env == EnvTest || c.Env == EnvProduction,
+		"env 应为 development、test 或 production，当前为 %q", c.Env)
+	check(c.Server.Port > 0 && c.Server.Port < 65536, "server.port 无效: %d", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout >= 0 && c.Server.ReadTimeout >= 0 && c.Server.WriteTimeout >= 0 && c.Server.IdleTimeout >= 0,
+		"server 超时不能为负数，0 表示不限")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout 必须大于 0")
+	for _, proxy := range c.Server.TrustedProxies {
+		check(validProxy(proxy), "server.trusted_proxies 应为 IP 或 CIDR，当前为 %q", proxy)
+	}
+	switch c.Database.Driver {
+	case "sqlite":
+		check(c.Database.Path != "", "database.path 不能为空")
+	case "postgres", "mysql":
+		check(c.Database.DSN != "", "database.driver 为 %s 时必须配置 database.dsn", c.Database.Driver)
+	default:
+		check(false, "database.driver 应为 sqlite、postgres 或 mysql，当前为 %q", c.Database.Driver)
+	}
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns 不能为负数")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns 不能为负数")
+	check(c.Database.SlowQuery >= 0, "database.slow_query 不能为负数")
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	check(c.Log.Format == logging.FormatJSON || c.Log.Format == logging.FormatText,
+		"log.format 应为 json 或 text，当前为 %q", c.Log.Format)
+	check(c.Tracing.Exporter == tracing.ExporterNone || c.Tracing.Exporter == tracing.ExporterStdout || c.Tracing.Exporter == tracing.ExporterOTLP,
+		"tracing.exporter 应为 none、stdout 或 otlp，当前为 %q", c.Tracing.Exporter)
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio 应在 0 到 1 之间")
+	if c.Cache.Enabled {
+		check(c.Cache.Size > 0, "cache.size 必须大于 0")
+		check(c.Cache.TTL > 0, "cache.ttl 必须大于 0")
+		check(c.Cache.CheckInterval >= 0 && c.Cache.MaxAge >= 0, "cache.check_interval 和 cache.max_age 不能为负数")
+	}
+	check(c.Pagination.DefaultPageSize > 0 && c.Pagination.DefaultPageSize <= c.Pagination.MaxPageSize,
+		"pagination.default_page_size 应在 1 到 max_page_size(%d) 之间", c.Pagination.MaxPageSize)
+
+	switch c.JWT.Algorithm {
+	case "HS256":
+		check(c.JWT.Secret != "", "jwt.secret 未配置（生产环境必须设置 JWT_SECRET）")
+		check(c.Env != EnvProduction || c.JWT.Secret != devJWTSecret, "生产环境不能使用默认的 jwt.secret")
+		check(c.Env != EnvProduction || len(c.JWT.Secret) >= 32, "生产环境的 jwt.secret 至少 32 个字符")
+	case "RS256", "EdDSA":
+		check(c.JWT.KeysDir != "", "jwt.algorithm 为 %s 时必须配置 jwt.keys_dir", c.JWT.Algorithm)
+	default:
+		check(false, "jwt.algorithm 应为 HS256、RS256 或 EdDSA，当前为 %q", c.JWT.Algorithm)
+	}
+	check(c.JWT.TokenDuration > 0, "jwt.token_duration 必须大于 0")
+
+	switch c.Mail.Driver {
+	case "log", "file":
+	case "smtp":
+		check(c.Mail.SMTPHost != "", "mail.driver 为 smtp 时必须配置 mail.smtp_host")
+		check(c.Mail.SMTPPort > 0 && c.Mail.SMTPPort < 65536, "mail.smtp_port 无效: %d", c.Mail.SMTPPort)
+	default:
+		check(false, "mail.driver 应为 log、file 或 smtp，当前为 %q", c.Mail.Driver)
+	}
+
+	check(c.Login.MaxUserFailures >= 0 && c.Login.MaxIPFailures >= 0, "login 失败次数阈值不能为负数")
+	check(c.Login.BaseLockout > 0 && c.Login.MaxLockout >= c.Login.BaseLockout,
+		"login.lockout 必须大于 0 且不超过 login.max_lockout")
+
+	for name, spec := range map[string]string{
+		"list": c.Limits.List, "detail": c.Limits.Detail, "search": c.Limits.Search, "random": c.Limits.Random,
+	} {
+		if _, _, err := ratelimit.ParseLimit(spec); err != nil {
+			check(false, "rate_limit.%s: %v", name, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Redacted 返回隐藏了密钥等敏感项的副本
+func (c *Config) Redacted() *Config {
+	cp := *c
+	redact(reflect.ValueOf(&cp).Elem())
+	return &cp
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			redact(value)
+			continue
+		}
+		if field.Tag.Get("secret") != "true" || value.IsZero() {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString("******")
+		case reflect.Map:
+			// 保留 kid，隐藏密钥；副本共享原来的 map，需要新建
+			masked := make(map[string]string, value.Len())
+			for _, k := range value.MapKeys() {
+				masked[k.String()] = "******"
+			}
+			value.Set(reflect.ValueOf(masked))
+		}
+	}
+}
+
+// YAML 以配置文件格式输出
+func (c *Config) YAML() ([]byte, error) {
+	var buf strings.Builder
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	return []byte(buf.String()), nil
+}
+
+// validProxy 是否为 IP 或 CIDR
+func validProxy(s string) bool {
+	if _, _, err := net.ParseCIDR(s); err == nil {
+		return true
+	}
+	return net.ParseIP(s) != nil
+}
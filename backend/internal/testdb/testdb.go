@@ -0,0 +1,93 @@
+// Package testdb 为测试创建数据库，测试结束时自动关闭。
+//
+// 默认使用临时目录中的 SQLite 文件；FromEnv 与服务端一样通过 DB_DRIVER、DB_DSN
+// 选择 PostgreSQL 或 MySQL，用于在真实数据库上运行仓库测试。
+package testdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"poem/backend/migrations"
+	"poem/backend/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// Open 在临时目录创建空的 SQLite 数据库，不执行迁移
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := open(t, repository.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SQLite 同一时间只允许一个写入者，测试里的并发请求排队执行
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+// New 创建已执行全部迁移的 SQLite 数据库
+func New(t testing.TB) *gorm.DB {
+	t.Helper()
+	db := Open(t)
+	Migrate(t, db)
+	return db
+}
+
+// FromEnv 按 DB_DRIVER、DB_DSN 打开数据库并执行全部迁移，未设置时同 New。
+// 外部数据库中已有的数据不会清除，调用方需自行清理
+func FromEnv(t testing.TB) *gorm.DB {
+	t.Helper()
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" || driver == repository.DriverSQLite {
+		return New(t)
+	}
+	db := open(t, driver, os.Getenv("DB_DSN"))
+	Migrate(t, db)
+	return db
+}
+
+// Migrate 执行全部未执行的迁移
+func Migrate(t testing.TB, db *gorm.DB) {
+	t.Helper()
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatalf("migrations up: %v", err)
+	}
+}
+
+// Poetry 在已迁移的 SQLite 数据库上创建诗词仓库，返回仓库和它使用的连接
+func Poetry(t testing.TB) (*repository.PoetryRepository, *gorm.DB) {
+	t.Helper()
+	repo, db, err := repository.NewPoetryRepository(repository.DriverSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	sqlDB.SetMaxOpenConns(1)
+	Migrate(t, db)
+	return repo, db
+}
+
+func open(t testing.TB, driver, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := repository.OpenDB(driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
@@ -1,41 +1,154 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
 	"poem/backend/api"
 	"poem/backend/config"
+	"poem/backend/migrations"
+	"poem/backend/models"
+	"poem/backend/pkg/logging"
+	"poem/backend/pkg/tracing"
 	"poem/backend/repository"
 	"poem/backend/services"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// 加载配置
-	cfg := config.Load()
+	// 加载配置：默认值 → 配置文件 → 环境变量 → 命令行参数
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "加载配置失败:", err)
+		os.Exit(1)
+	}
+
+	// 结构化日志，标准库 log 的输出也经由 slog
+	logger, err := logging.New(os.Stderr, cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "初始化日志失败:", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	if os.Getenv(gin.EnvGinMode) == "" && cfg.Env == config.EnvProduction {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	gin.DebugPrintRouteFunc = func(method, path, handler string, handlers int) {
+		slog.Debug("route", "method", method, "path", path, "handler", handler)
+	}
+
+	slog.Info("starting poetry api",
+		"env", cfg.Env,
+		"data_path", cfg.DataPath,
+		"db_driver", cfg.Database.Driver,
+		"db_path", cfg.Database.Path,
+	)
 
-	log.Printf("启动中国古诗词API服务...")
-	log.Printf("数据路径: %s", cfg.DataPath)
-	log.Printf("数据库路径: %s", cfg.DBPath)
-	log.Printf("运行环境: %s", cfg.Env)
+	// 链路追踪
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		Environment: cfg.Env,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		fatal("setup tracing failed", err)
+	}
 
 	// 初始化Repository层
-	poetryRepo, db, err := repository.NewPoetryRepository(cfg.DBPath)
+	poetryRepo, db, err := repository.NewPoetryRepository(cfg.Database.Driver, cfg.Database.DataSource())
 	if err != nil {
-		log.Fatal("初始化数据库失败:", err)
+		fatal("open database failed", err)
+	}
+	// 表结构由 manage migrate 维护，服务端不自动建表或改表
+	if err := migrations.Check(context.Background(), db); err != nil {
+		fatal("database schema check failed, run `manage migrate up` first", err)
+	}
+	if err := repository.ConfigurePool(db, cfg.Database.MaxOpenConns, cfg.Database.MaxIdleConns, cfg.Database.ConnMaxLifetime); err != nil {
+		fatal("configure connection pool failed", err)
+	}
+	repository.SetLogger(db, cfg.Database.SlowQuery)
+	if err := repository.InstrumentTracing(db); err != nil {
+		fatal("setup database tracing failed", err)
 	}
+	models.SetPageLimits(cfg.Pagination.DefaultPageSize, cfg.Pagination.MaxPageSize)
 
 	// 初始化Service层
 	poetryService := services.NewPoetryService(poetryRepo)
 
+	// 后台任务在服务器停止后取消
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
 	// 设置路由
-	router := api.SetupRouter(poetryService, db)
+	router, err := api.SetupRouter(workers, cfg, poetryService, db)
+	if err != nil {
+		fatal("setup router failed", err)
+	}
 
 	// 启动服务器
-	addr := ":" + cfg.Port
-	log.Printf("服务器启动在 http://localhost%s", addr)
-	log.Printf("API文档: http://localhost%s/api/v1", addr)
-	log.Printf("API v2: http://localhost%s/api/v2", addr)
+	addr := fmt.Sprintf(":%d", cfg.Server.Port)
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", addr, "api_v1", "/api/v1", "api_v2", "/api/v2")
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// 等待 SIGINT/SIGTERM；收到后恢复默认处理，再次发送信号可立即退出
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	select {
+	case err := <-serveErr:
+		stopSignals()
+		fatal("server failed", err)
+	case <-signals.Done():
+		stopSignals()
+	}
 
-	if err := router.Run(addr); err != nil {
-		log.Fatal("服务器启动失败:", err)
+	// 停止接受新连接，等待进行中的请求完成
+	slog.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("graceful shutdown timed out, closing connections", "error", err)
+		server.Close()
 	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("server stopped unexpectedly", "error", err)
+	}
+
+	// 发送剩余的 span
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Warn("flush traces failed", "error", err)
+	}
+
+	// 停止后台任务并关闭数据库（会等待正在执行的查询结束）
+	stopWorkers()
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			slog.Error("close database failed", "error", err)
+		}
+	}
+	slog.Info("server stopped")
+}
+
+// fatal 记录错误并退出
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
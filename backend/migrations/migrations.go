@@ -0,0 +1,390 @@
+// Package migrations 版本化的数据库迁移脚本。
+//
+// 每个数据库一个目录（sqlite、postgres、mysql，与 gorm Dialector.Name() 一致），
+// 脚本命名为 <版本>_<名称>.up.sql 和 <版本>_<名称>.down.sql，编译时嵌入二进制。
+// 已执行的版本记录在 schema_migrations 表中。
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"poem/backend/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sqlite/*.sql postgres/*.sql mysql/*.sql
+var scripts embed.FS
+
+// TableName 记录已执行版本的表
+const TableName = "schema_migrations"
+
+// ErrSchemaMismatch 数据库结构与当前程序要求的版本不一致
+var ErrSchemaMismatch = errors.New("数据库结构版本不匹配")
+
+// Migration 一个版本的迁移脚本
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 迁移的执行状态
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator 在一个数据库上执行迁移
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New 按数据库类型加载迁移脚本
+func New(db *gorm.DB) (*Migrator, error) {
+	list, err := load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: list}, nil
+}
+
+// load 读取某个数据库目录下的脚本，按版本排序，每个版本必须同时有 up 和 down
+func load(dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(scripts, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的数据库 %s: %w", dialect, err)
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		file := entry.Name()
+		base, direction := strings.TrimSuffix(file, ".sql"), ""
+		switch {
+		case strings.HasSuffix(base, ".up"):
+			base, direction = strings.TrimSuffix(base, ".up"), "up"
+		case strings.HasSuffix(base, ".down"):
+			base, direction = strings.TrimSuffix(base, ".down"), "down"
+		default:
+			return nil, fmt.Errorf("迁移脚本 %s/%s 缺少 .up 或 .down 后缀", dialect, file)
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("迁移脚本 %s/%s 的版本号无效", dialect, file)
+		}
+		content, err := scripts.ReadFile(path.Join(dialect, file))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("迁移版本 %d 的脚本名称不一致: %s 和 %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+	list := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("迁移版本 %d 缺少 up 或 down 脚本", m.Version)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Latest 当前程序要求的最新版本
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// applied 已执行的版本及执行时间，schema_migrations 不存在时返回空
+func (m *Migrator) applied(ctx context.Context) (map[int]time.Time, error) {
+	db := m.db.WithContext(ctx)
+	result := make(map[int]time.Time)
+	if !db.Migrator().HasTable(TableName) {
+		return result, nil
+	}
+	var rows []struct {
+		Version   int
+		AppliedAt time.Time
+	}
+	if err := db.Raw("SELECT version, applied_at FROM " + TableName).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.Version] = row.AppliedAt
+	}
+	return result, nil
+}
+
+// Version 已执行的最高版本，尚未迁移过时为 0
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status 列出每个版本的执行状态；数据库中有但程序里没有的版本（由更新的程序执行）也会列出
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]bool)
+	var list []Status
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		s := Status{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			s.Applied, s.AppliedAt = true, &at
+		}
+		list = append(list, s)
+	}
+	for v, at := range applied {
+		if !known[v] {
+			list = append(list, Status{Version: v, Name: "(unknown)", Applied: true, AppliedAt: &at})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+// Up 执行所有未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.To(ctx, m.Latest())
+}
+
+// Down 回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var versions []int
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	if steps > len(versions) {
+		steps = len(versions)
+	}
+	target := 0
+	if steps < len(versions) {
+		target = versions[steps]
+	}
+	return m.To(ctx, target)
+}
+
+// To 迁移到指定版本：比当前版本高时依次执行 up，低时依次执行 down，0 表示全部回滚
+func (m *Migrator) To(ctx context.Context, version int) ([]Migration, error) {
+	if version != 0 && m.find(version) == nil {
+		return nil, fmt.Errorf("迁移版本 %d 不存在，最新版本为 %d", version, m.Latest())
+	}
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var done []Migration
+	// 先回滚高于目标的版本，从新到旧
+	var rollback []int
+	for v := range applied {
+		if v > version {
+			rollback = append(rollback, v)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(rollback)))
+	for _, v := range rollback {
+		mig := m.find(v)
+		if mig == nil {
+			return done, fmt.Errorf("%w: 版本 %d 由更新的程序执行，当前程序没有它的回滚脚本", ErrSchemaMismatch, v)
+		}
+		if err := m.run(ctx, *mig, false); err != nil {
+			return done, err
+		}
+		done = append(done, *mig)
+	}
+	// 再执行不高于目标的未执行版本，从旧到新
+	for _, mig := range m.migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, mig, true); err != nil {
+			return done, err
+		}
+		done = append(done, mig)
+	}
+	return done, nil
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+// run 在一个事务中执行脚本并更新 schema_migrations。
+// MySQL 的 DDL 会隐式提交，脚本中途失败时已执行的语句不会回滚，需按报错手工处理后重试
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	script := mig.Down
+	if up {
+		script = mig.Up
+	}
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range SplitStatements(script) {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("迁移 %04d_%s 执行失败: %w\n%s", mig.Version, mig.Name, err, stmt)
+			}
+		}
+		if up {
+			return tx.Exec("INSERT INTO "+TableName+" (version, name, applied_at) VALUES (?, ?, ?)", mig.Version, mig.Name, time.Now()).Error
+		}
+		return tx.Exec("DELETE FROM "+TableName+" WHERE version = ?", mig.Version).Error
+	})
+}
+
+// ensureTable 创建 schema_migrations。首次创建时如果库里已有表（此前由 AutoMigrate 建表的旧库），
+// 先补齐旧库缺少的列，后续的建表脚本均为 IF NOT EXISTS，可直接在旧库上执行
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	db := m.db.WithContext(ctx)
+	if db.Migrator().HasTable(TableName) {
+		return nil
+	}
+	if err := adoptLegacy(db); err != nil {
+		return fmt.Errorf("升级旧库表结构失败: %w", err)
+	}
+	return db.Exec("CREATE TABLE IF NOT EXISTS " + TableName + " (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)").Error
+}
+
+// legacyModels 此前由 AutoMigrate 维护的模型
+var legacyModels = []interface{}{
+	&models.User{}, &models.UserFavorite{}, &models.UserHistory{},
+	&models.UserSession{}, &models.RevokedToken{}, &models.PasswordResetToken{},
+	&models.LoginThrottle{}, &models.AuthAuditLog{}, &models.APIKey{}, &models.APIKeyUsage{},
+	&models.Category{}, &models.Dynasty{}, &models.Author{}, &models.AuthorAlias{},
+	&models.Work{}, &models.Comment{}, &models.Anthology{}, &models.AnthologyWork{},
+	&models.CorpusOverride{}, &models.ImportRun{}, &models.WorkRevision{}, &models.Correction{},
+}
+
+// laterColumns 由后续迁移添加的列，旧库升级时不在这里补，交给对应的迁移
+var laterColumns = map[string]bool{
+	"corrections.work_key":   true,
+	"corrections.author_key": true,
+}
+
+// adoptLegacy 给旧库已有的表补上缺少的列。旧版 AutoMigrate 把 VIPLevel 映射为 v_ip_level，这里改回 vip_level
+func adoptLegacy(db *gorm.DB) error {
+	migrator := db.Migrator()
+	renames := map[string]string{"v_ip_level": "vip_level", "v_ip_expire_at": "vip_expire_at"}
+	for from, to := range renames {
+		if migrator.HasColumn(&models.User{}, from) && !migrator.HasColumn(&models.User{}, to) {
+			if err := migrator.RenameColumn(&models.User{}, from, to); err != nil {
+				return err
+			}
+		}
+	}
+	for _, model := range legacyModels {
+		if !migrator.HasTable(model) {
+			continue
+		}
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return err
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" || laterColumns[stmt.Schema.Table+"."+field.DBName] || migrator.HasColumn(model, field.DBName) {
+				continue
+			}
+			if err := migrator.AddColumn(model, field.Name); err != nil {
+				return fmt.Errorf("%s.%s: %w", stmt.Schema.Table, field.DBName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Check 检查数据库是否已迁移到当前程序要求的版本，未迁移或版本更新时返回 ErrSchemaMismatch
+func Check(ctx context.Context, db *gorm.DB) error {
+	m, err := New(db)
+	if err != nil {
+		return err
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	var pending, unknown []string
+	for _, s := range status {
+		switch {
+		case !s.Applied:
+			pending = append(pending, strconv.Itoa(s.Version))
+		case m.find(s.Version) == nil:
+			unknown = append(unknown, strconv.Itoa(s.Version))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: 数据库已迁移到更新的版本 %s，请升级程序", ErrSchemaMismatch, strings.Join(unknown, ", "))
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: 有未执行的迁移 %s，请先运行 manage migrate up", ErrSchemaMismatch, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// SplitStatements 去掉 -- 注释后按分号拆分 SQL 脚本，逐条执行：MySQL 驱动默认不允许一次执行多条语句。
+// 脚本中的字符串字面量不含分号和 --
+func SplitStatements(script string) []string {
+	var lines []string
+	for _, line := range strings.Split(script, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	var statements []string
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
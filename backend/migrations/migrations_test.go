@@ -0,0 +1,311 @@
+package migrations_test
+
+import (
+	"context"
+	"errors"
+	"poem/backend/internal/testdb"
+	"poem/backend/migrations"
+	"poem/backend/models"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// schema SQLite 中除 schema_migrations 外的表和索引，用于比较迁移前后的结构
+func schema(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var rows []struct {
+		Type string
+		Name string
+		SQL  string
+	}
+	err := db.Raw("SELECT type, name, COALESCE(sql, '') AS sql FROM sqlite_master " +
+		"WHERE name NOT IN ('" + migrations.TableName + "', 'sqlite_sequence') AND name NOT LIKE 'sqlite_autoindex%' ORDER BY type, name").
+		Scan(&rows).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	for _, r := range rows {
+		b.WriteString(r.Type + " " + r.Name + ": " + r.SQL + "\n")
+	}
+	return b.String()
+}
+
+func appliedVersions(t *testing.T, m *migrations.Migrator) []int {
+	t.Helper()
+	status, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int
+	for _, s := range status {
+		if s.Applied {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+func assertVersion(t *testing.T, m *migrations.Migrator, want int) {
+	t.Helper()
+	version, err := m.Version(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != want {
+		t.Fatalf("version = %d, want %d", version, want)
+	}
+	applied := appliedVersions(t, m)
+	if len(applied) != want {
+		t.Fatalf("applied versions = %v, want 1..%d", applied, want)
+	}
+	for i, v := range applied {
+		if v != i+1 {
+			t.Fatalf("applied versions = %v, want 1..%d", applied, want)
+		}
+	}
+}
+
+func TestMigrateRoundTrip(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := m.Latest()
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, m, latest)
+	if err := migrations.Check(ctx, db); err != nil {
+		t.Fatalf("check after up: %v", err)
+	}
+	full := schema(t, db)
+
+	// 逐个回滚再逐个升级，每个版本的 down 都应恢复到上一版本的结构
+	schemas := map[int]string{latest: full}
+	for v := latest - 1; v >= 0; v-- {
+		done, err := m.Down(ctx, 1)
+		if err != nil {
+			t.Fatalf("down to %d: %v", v, err)
+		}
+		if len(done) != 1 || done[0].Version != v+1 {
+			t.Fatalf("down to %d rolled back %v", v, done)
+		}
+		assertVersion(t, m, v)
+		schemas[v] = schema(t, db)
+	}
+	if schemas[0] != "" {
+		t.Fatalf("schema after full rollback:\n%s", schemas[0])
+	}
+	for v := 1; v <= latest; v++ {
+		if _, err := m.To(ctx, v); err != nil {
+			t.Fatalf("to %d: %v", v, err)
+		}
+		assertVersion(t, m, v)
+		if got := schema(t, db); got != schemas[v] {
+			t.Fatalf("schema at version %d differs after down and up:\ngot:\n%s\nwant:\n%s", v, got, schemas[v])
+		}
+	}
+
+	// 一次回滚多个版本，再直接迁移到中间版本
+	if _, err := m.Down(ctx, latest); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, m, 0)
+	if _, err := m.To(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, m, 2)
+	if got := schema(t, db); got != schemas[2] {
+		t.Fatalf("schema at version 2 differs:\ngot:\n%s\nwant:\n%s", got, schemas[2])
+	}
+	if err := migrations.Check(ctx, db); !errors.Is(err, migrations.ErrSchemaMismatch) {
+		t.Fatalf("check with pending migrations error = %v, want ErrSchemaMismatch", err)
+	}
+	if _, err := m.To(ctx, latest+1); err == nil {
+		t.Fatal("migrating to an unknown version succeeded")
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := schema(t, db); got != full {
+		t.Fatalf("schema after up differs:\ngot:\n%s\nwant:\n%s", got, full)
+	}
+}
+
+func TestNewerVersionInDatabase(t *testing.T) {
+	db := testdb.New(t)
+	ctx := context.Background()
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 更新的程序执行过的版本，当前程序没有它的脚本
+	newer := m.Latest() + 1
+	if err := db.Exec("INSERT INTO "+migrations.TableName+" (version, name, applied_at) VALUES (?, 'future', CURRENT_TIMESTAMP)", newer).Error; err != nil {
+		t.Fatal(err)
+	}
+	status, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := status[len(status)-1]; last.Version != newer || !last.Applied || last.Name != "(unknown)" {
+		t.Fatalf("last status = %+v, want unknown version %d", last, newer)
+	}
+	if err := migrations.Check(ctx, db); !errors.Is(err, migrations.ErrSchemaMismatch) {
+		t.Fatalf("check error = %v, want ErrSchemaMismatch", err)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, migrations.ErrSchemaMismatch) {
+		t.Fatalf("down past an unknown version error = %v, want ErrSchemaMismatch", err)
+	}
+}
+
+func TestAdoptLegacySchema(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+
+	// 模拟此前由 AutoMigrate 建表的旧库：VIP 列名为 v_ip_level，缺少后来加的列
+	legacy := []interface{}{
+		&models.User{}, &models.Category{}, &models.Dynasty{}, &models.Author{},
+		&models.Work{}, &models.Comment{}, &models.Correction{},
+	}
+	if err := db.AutoMigrate(legacy...); err != nil {
+		t.Fatal(err)
+	}
+	migrator := db.Migrator()
+	if err := migrator.RenameColumn(&models.User{}, "vip_level", "v_ip_level"); err != nil {
+		t.Fatal(err)
+	}
+	for _, idx := range []string{"idx_corrections_work_key"} {
+		if migrator.HasIndex(&models.Correction{}, idx) {
+			if err := migrator.DropIndex(&models.Correction{}, idx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	drops := []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.Correction{}, "work_key"},
+		{&models.Correction{}, "author_key"},
+		{&models.Author{}, "source_key"},
+		{&models.Work{}, "rhythmic"},
+	}
+	for _, d := range drops {
+		if err := migrator.DropColumn(d.model, d.column); err != nil {
+			t.Fatalf("drop %s: %v", d.column, err)
+		}
+	}
+	if err := db.Exec("INSERT INTO users (username, password_hash, v_ip_level) VALUES ('old', 'x', 3)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO works (title, content) VALUES ('静夜思', '[]')").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("up on legacy schema: %v", err)
+	}
+	assertVersion(t, m, m.Latest())
+	if err := migrations.Check(ctx, db); err != nil {
+		t.Fatalf("check after adopting legacy schema: %v", err)
+	}
+
+	for _, c := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.User{}, "vip_level"},
+		{&models.Correction{}, "work_key"},
+		{&models.Correction{}, "author_key"},
+		{&models.Author{}, "source_key"},
+		{&models.Work{}, "rhythmic"},
+		{&models.User{}, "role"},
+	} {
+		if !migrator.HasColumn(c.model, c.column) {
+			t.Errorf("column %s missing after adopting legacy schema", c.column)
+		}
+	}
+	if migrator.HasColumn(&models.User{}, "v_ip_level") {
+		t.Error("legacy column v_ip_level was not renamed")
+	}
+
+	// 旧数据保留
+	var user models.User
+	if err := db.Where("username = ?", "old").First(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.VIPLevel != 3 {
+		t.Fatalf("vip_level = %d, want 3", user.VIPLevel)
+	}
+	var works int64
+	if err := db.Model(&models.Work{}).Count(&works).Error; err != nil {
+		t.Fatal(err)
+	}
+	if works != 1 {
+		t.Fatalf("%d works after adopting legacy schema, want 1", works)
+	}
+}
+
+func TestCorrectionSourceKeyBackfill(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	m, err := migrations.New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.To(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// 0004 之前的纠错只记录ID
+	statements := []string{
+		"INSERT INTO authors (id, name, dynasty, source_key) VALUES (7, '李白', '唐', '李白|唐')",
+		"INSERT INTO works (id, author_id, title, content, source_key) VALUES (3, 7, '静夜思', '[]', 'tangshi|id:1')",
+		"INSERT INTO corrections (id, work_id, user_id, author_id) VALUES (1, 3, 1, 7)",
+		"INSERT INTO corrections (id, work_id, user_id) VALUES (2, 3, 1)",
+		"INSERT INTO corrections (id, work_id, user_id) VALUES (3, 99, 1)",
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows []struct {
+		ID        uint
+		WorkKey   string
+		AuthorKey string
+	}
+	if err := db.Table("corrections").Order("id").Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ work, author string }{
+		{"tangshi|id:1", "李白|唐"},
+		{"tangshi|id:1", ""},
+		{"", ""}, // 作品已不存在
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("%d corrections, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row.WorkKey != want[i].work || row.AuthorKey != want[i].author {
+			t.Errorf("correction %d keys = %q/%q, want %q/%q", row.ID, row.WorkKey, row.AuthorKey, want[i].work, want[i].author)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS api_key_usages;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS auth_audit_logs;
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS revoked_tokens;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS user_history;
+DROP TABLE IF EXISTS user_favorites;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,156 @@
+-- 需要 MySQL 8.0.13+。CREATE INDEX 不支持 IF NOT EXISTS，索引写在建表语句中
+
+-- 用户表。MySQL 没有部分索引，选填的联系方式用 NULLIF 函数索引，空字符串不参与唯一约束
+CREATE TABLE IF NOT EXISTS users (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    open_id VARCHAR(100),
+    union_id VARCHAR(100),
+    username VARCHAR(50) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    nickname VARCHAR(100),
+    avatar_url VARCHAR(500),
+    email VARCHAR(100),
+    phone VARCHAR(20),
+    gender BIGINT DEFAULT 0 CHECK(gender IN (0, 1, 2)),
+    birth_date DATETIME(3),
+    province VARCHAR(50),
+    city VARCHAR(50),
+    level BIGINT DEFAULT 1,
+    experience BIGINT DEFAULT 0,
+    coins BIGINT DEFAULT 0,
+    vip_level BIGINT DEFAULT 0,
+    vip_expire_at DATETIME(3),
+    status BIGINT DEFAULT 1 CHECK(status IN (0, 1)),
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK(role IN ('user', 'editor', 'admin')),
+    last_login_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    updated_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    UNIQUE KEY idx_users_open_id ((NULLIF(open_id, ''))),
+    UNIQUE KEY idx_users_email ((NULLIF(email, ''))),
+    UNIQUE KEY idx_users_phone ((NULLIF(phone, '')))
+) DEFAULT CHARSET=utf8mb4;
+
+-- 用户收藏表
+CREATE TABLE IF NOT EXISTS user_favorites (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    target_id BIGINT UNSIGNED NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK(target_type IN ('poem', 'author')),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, target_id, target_type),
+    KEY idx_favorites_user (user_id),
+    KEY idx_favorites_target (target_id, target_type)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 用户浏览历史表
+CREATE TABLE IF NOT EXISTS user_history (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    target_id BIGINT UNSIGNED NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK(target_type IN ('poem', 'author')),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    KEY idx_history_user (user_id),
+    KEY idx_history_created (created_at DESC)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 用户会话表（刷新令牌，只保存哈希）
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    family_id VARCHAR(36) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    access_jti VARCHAR(36),
+    access_exp DATETIME(3),
+    device VARCHAR(100),
+    ip VARCHAR(45),
+    user_agent VARCHAR(500),
+    expires_at DATETIME(3),
+    rotated_at DATETIME(3),
+    revoked_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    KEY idx_user_sessions_user_id (user_id),
+    KEY idx_user_sessions_family_id (family_id),
+    KEY idx_user_sessions_expires_at (expires_at),
+    KEY idx_user_sessions_access_jti (access_jti),
+    KEY idx_user_sessions_access_exp (access_exp)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 已吊销的access token（jti 吊销名单）
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT UNSIGNED,
+    reason VARCHAR(50),
+    expires_at DATETIME(3) NOT NULL,
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    KEY idx_revoked_tokens_user_id (user_id),
+    KEY idx_revoked_tokens_expires_at (expires_at)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 找回密码令牌（只保存哈希，一次性使用）
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    ip VARCHAR(45),
+    expires_at DATETIME(3) NOT NULL,
+    used_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    KEY idx_password_reset_tokens_user_id (user_id),
+    KEY idx_password_reset_tokens_expires_at (expires_at)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 登录失败计数（按用户名和IP），登录成功后删除
+CREATE TABLE IF NOT EXISTS login_throttles (
+    subject VARCHAR(120) PRIMARY KEY,
+    failures BIGINT NOT NULL DEFAULT 0,
+    last_failed_at DATETIME(3) NOT NULL,
+    locked_until DATETIME(3),
+    KEY idx_login_throttles_locked_until (locked_until)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 认证审计日志
+CREATE TABLE IF NOT EXISTS auth_audit_logs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    event VARCHAR(30) NOT NULL,
+    user_id BIGINT UNSIGNED,
+    username VARCHAR(50),
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    detail VARCHAR(255),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    KEY idx_auth_audit_logs_event (event),
+    KEY idx_auth_audit_logs_user_id (user_id),
+    KEY idx_auth_audit_logs_username (username),
+    KEY idx_auth_audit_logs_ip (ip),
+    KEY idx_auth_audit_logs_created_at (created_at)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 第三方开发者API密钥（只保存哈希）
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT UNSIGNED NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,                   -- JSON数组，如 ["poetry:read"]
+    daily_quota BIGINT NOT NULL,
+    last_used_at DATETIME(3),
+    expires_at DATETIME(3),
+    revoked_at DATETIME(3),
+    created_at DATETIME(3) DEFAULT CURRENT_TIMESTAMP(3),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    KEY idx_api_keys_user_id (user_id)
+) DEFAULT CHARSET=utf8mb4;
+
+-- API密钥每日调用次数（UTC 日期）
+CREATE TABLE IF NOT EXISTS api_key_usages (
+    api_key_id BIGINT UNSIGNED NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE
+) DEFAULT CHARSET=utf8mb4;
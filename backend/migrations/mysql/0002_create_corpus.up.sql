@@ -0,0 +1,187 @@
+-- 语料表由 manage etl 填充；索引写在建表语句中
+
+-- 分类
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    created_at DATETIME(3)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 朝代
+CREATE TABLE IF NOT EXISTS dynasties (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    name_en VARCHAR(50),
+    description TEXT,
+    period VARCHAR(50),
+    start_year BIGINT,
+    end_year BIGINT,
+    sort_order BIGINT,
+    created_at DATETIME(3),
+    KEY idx_dynasties_sort_order (sort_order)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 作者
+CREATE TABLE IF NOT EXISTS authors (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    dynasty_id BIGINT UNSIGNED,
+    dynasty VARCHAR(50),
+    biography TEXT,
+    birth_year BIGINT,
+    birth_approx BOOLEAN,
+    death_year BIGINT,
+    death_approx BOOLEAN,
+    courtesy_name VARCHAR(50),
+    art_name VARCHAR(100),
+    native_place VARCHAR(100),
+    era_names TEXT,
+    profile_source VARCHAR(20),
+    source_key VARCHAR(255),
+    created_at DATETIME(3),
+    FOREIGN KEY (dynasty_id) REFERENCES dynasties(id),
+    UNIQUE KEY idx_author_dynasty (name, dynasty_id),
+    KEY idx_authors_dynasty (dynasty),
+    KEY idx_authors_birth_year (birth_year),
+    KEY idx_authors_death_year (death_year),
+    KEY idx_authors_source_key (source_key)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 作者别名（字、号、异写）
+CREATE TABLE IF NOT EXISTS author_aliases (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    author_id BIGINT UNSIGNED,
+    name VARCHAR(255) NOT NULL,
+    dynasty VARCHAR(50),
+    type VARCHAR(20) DEFAULT 'alias',
+    created_at DATETIME(3),
+    FOREIGN KEY (author_id) REFERENCES authors(id),
+    UNIQUE KEY idx_alias_name_dynasty (name, dynasty),
+    KEY idx_author_aliases_author_id (author_id)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 作品
+CREATE TABLE IF NOT EXISTS works (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    category_id BIGINT UNSIGNED,
+    author_id BIGINT UNSIGNED,
+    title VARCHAR(255),
+    rhythmic VARCHAR(255),
+    volume VARCHAR(100),
+    section VARCHAR(100),
+    content MEDIUMTEXT NOT NULL,            -- JSON数组，每段一项；个别长篇超过 TEXT 的 64KB
+    prologue TEXT,
+    original_id VARCHAR(100),
+    source_key VARCHAR(255),
+    created_at DATETIME(3),
+    FOREIGN KEY (category_id) REFERENCES categories(id),
+    FOREIGN KEY (author_id) REFERENCES authors(id),
+    KEY idx_works_category_id (category_id),
+    KEY idx_works_author_id (author_id),
+    KEY idx_works_title (title),
+    KEY idx_works_rhythmic (rhythmic),
+    KEY idx_works_source_key (source_key)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 选集
+CREATE TABLE IF NOT EXISTS anthologies (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    created_at DATETIME(3)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 选集与作品的关联
+CREATE TABLE IF NOT EXISTS anthology_works (
+    anthology_id BIGINT UNSIGNED NOT NULL,
+    work_id BIGINT UNSIGNED NOT NULL,
+    sort_order BIGINT,
+    PRIMARY KEY (anthology_id, work_id),
+    FOREIGN KEY (anthology_id) REFERENCES anthologies(id),
+    FOREIGN KEY (work_id) REFERENCES works(id),
+    KEY idx_anthology_works_work_id (work_id)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 注释、评析
+CREATE TABLE IF NOT EXISTS comments (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    work_id BIGINT UNSIGNED,
+    content MEDIUMTEXT NOT NULL,
+    type VARCHAR(50) DEFAULT 'note',
+    commenter VARCHAR(100),
+    paragraph_index BIGINT,
+    created_at DATETIME(3),
+    FOREIGN KEY (work_id) REFERENCES works(id),
+    KEY idx_comments_work_id (work_id)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 管理后台对语料的修改，ETL 重建语料后重新应用
+CREATE TABLE IF NOT EXISTS corpus_overrides (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    source_key VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    data MEDIUMTEXT,
+    updated_by BIGINT UNSIGNED,
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    UNIQUE KEY idx_override_entity (entity_type, source_key)
+) DEFAULT CHARSET=utf8mb4;
+
+-- ETL 导入记录
+CREATE TABLE IF NOT EXISTS import_runs (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    started_at DATETIME(3),
+    finished_at DATETIME(3),
+    works BIGINT,
+    authors BIGINT,
+    author_aliases BIGINT,
+    comments BIGINT,
+    anthology_works BIGINT,
+    KEY idx_import_runs_finished_at (finished_at)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 作品、作者、注释的修订历史（BEFORE 是保留字，需加反引号）
+CREATE TABLE IF NOT EXISTS work_revisions (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id BIGINT UNSIGNED NOT NULL,
+    work_id BIGINT UNSIGNED,
+    source_key VARCHAR(255),
+    action VARCHAR(20) NOT NULL,
+    `before` MEDIUMTEXT,
+    `after` MEDIUMTEXT,
+    editor_id BIGINT UNSIGNED,
+    reason VARCHAR(255),
+    rollback_of BIGINT UNSIGNED,
+    created_at DATETIME(3),
+    KEY idx_revision_entity (entity_type, entity_id),
+    KEY idx_work_revisions_work_id (work_id),
+    KEY idx_work_revisions_source_key (source_key),
+    KEY idx_work_revisions_editor_id (editor_id),
+    KEY idx_work_revisions_created_at (created_at)
+) DEFAULT CHARSET=utf8mb4;
+
+-- 读者提交的纠错
+CREATE TABLE IF NOT EXISTS corrections (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    work_id BIGINT UNSIGNED NOT NULL,
+    user_id BIGINT UNSIGNED NOT NULL,
+    title VARCHAR(255),
+    content MEDIUMTEXT,
+    author_id BIGINT UNSIGNED,
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewer_id BIGINT UNSIGNED,
+    review_note VARCHAR(500),
+    reviewed_at DATETIME(3),
+    created_at DATETIME(3),
+    updated_at DATETIME(3),
+    KEY idx_corrections_work_id (work_id),
+    KEY idx_corrections_user_id (user_id),
+    KEY idx_corrections_status (status)
+) DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,8 @@
+-- 数据版本，语料变化时递增，服务端据此清空缓存
+CREATE TABLE IF NOT EXISTS data_versions (
+    name VARCHAR(50) PRIMARY KEY,
+    version BIGINT NOT NULL DEFAULT 0,
+    updated_at DATETIME(3)
+) DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO data_versions (name, version, updated_at) VALUES ('corpus', 1, CURRENT_TIMESTAMP(3));
@@ -0,0 +1,4 @@
+ALTER TABLE corrections
+    DROP KEY idx_corrections_work_key,
+    DROP COLUMN author_key,
+    DROP COLUMN work_key;
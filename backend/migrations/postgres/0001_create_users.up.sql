@@ -0,0 +1,158 @@
+-- 用户表
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    open_id VARCHAR(100),
+    union_id VARCHAR(100),
+    username VARCHAR(50) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    nickname VARCHAR(100),
+    avatar_url VARCHAR(500),
+    email VARCHAR(100),
+    phone VARCHAR(20),
+    gender BIGINT DEFAULT 0 CHECK(gender IN (0, 1, 2)),
+    birth_date TIMESTAMPTZ,
+    province VARCHAR(50),
+    city VARCHAR(50),
+    level BIGINT DEFAULT 1,
+    experience BIGINT DEFAULT 0,
+    coins BIGINT DEFAULT 0,
+    vip_level BIGINT DEFAULT 0,
+    vip_expire_at TIMESTAMPTZ,
+    status BIGINT DEFAULT 1 CHECK(status IN (0, 1)),
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK(role IN ('user', 'editor', 'admin')),
+    last_login_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 选填的联系方式只在非空时要求唯一
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_open_id ON users(open_id) WHERE open_id <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE email <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone ON users(phone) WHERE phone <> '';
+
+-- 用户收藏表
+CREATE TABLE IF NOT EXISTS user_favorites (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_id BIGINT NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK(target_type IN ('poem', 'author')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(user_id, target_id, target_type)
+);
+
+CREATE INDEX IF NOT EXISTS idx_favorites_user ON user_favorites(user_id);
+CREATE INDEX IF NOT EXISTS idx_favorites_target ON user_favorites(target_id, target_type);
+
+-- 用户浏览历史表
+CREATE TABLE IF NOT EXISTS user_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    target_id BIGINT NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK(target_type IN ('poem', 'author')),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_history_user ON user_history(user_id);
+CREATE INDEX IF NOT EXISTS idx_history_created ON user_history(created_at DESC);
+
+-- 用户会话表（刷新令牌，只保存哈希）
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id VARCHAR(36) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    access_jti VARCHAR(36),
+    access_exp TIMESTAMPTZ,
+    device VARCHAR(100),
+    ip VARCHAR(45),
+    user_agent VARCHAR(500),
+    expires_at TIMESTAMPTZ,
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_family_id ON user_sessions(family_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_access_jti ON user_sessions(access_jti);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_access_exp ON user_sessions(access_exp);
+
+-- 已吊销的access token（jti 吊销名单）
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(36) PRIMARY KEY,
+    user_id BIGINT,
+    reason VARCHAR(50),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- 找回密码令牌（只保存哈希，一次性使用）
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    ip VARCHAR(45),
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at);
+
+-- 登录失败计数（按用户名和IP），登录成功后删除
+CREATE TABLE IF NOT EXISTS login_throttles (
+    subject VARCHAR(120) PRIMARY KEY,
+    failures BIGINT NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_locked_until ON login_throttles(locked_until);
+
+-- 认证审计日志
+CREATE TABLE IF NOT EXISTS auth_audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    event VARCHAR(30) NOT NULL,
+    user_id BIGINT,
+    username VARCHAR(50),
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    detail VARCHAR(255),
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_event ON auth_audit_logs(event);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_user_id ON auth_audit_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_username ON auth_audit_logs(username);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_ip ON auth_audit_logs(ip);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_created_at ON auth_audit_logs(created_at);
+
+-- 第三方开发者API密钥（只保存哈希）
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,                   -- JSON数组，如 ["poetry:read"]
+    daily_quota BIGINT NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- API密钥每日调用次数（UTC 日期）
+CREATE TABLE IF NOT EXISTS api_key_usages (
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    day VARCHAR(10) NOT NULL,
+    count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day)
+);
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS corrections;
+DROP TABLE IF EXISTS work_revisions;
+DROP TABLE IF EXISTS import_runs;
+DROP TABLE IF EXISTS corpus_overrides;
+DROP TABLE IF EXISTS anthology_works;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS works;
+DROP TABLE IF EXISTS anthologies;
+DROP TABLE IF EXISTS author_aliases;
+DROP TABLE IF EXISTS authors;
+DROP TABLE IF EXISTS dynasties;
+DROP TABLE IF EXISTS categories;
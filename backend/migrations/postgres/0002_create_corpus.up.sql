@@ -0,0 +1,190 @@
+-- 语料表由 manage etl 填充
+
+-- 分类
+CREATE TABLE IF NOT EXISTS categories (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    created_at TIMESTAMPTZ
+);
+
+-- 朝代
+CREATE TABLE IF NOT EXISTS dynasties (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    name_en VARCHAR(50),
+    description TEXT,
+    period VARCHAR(50),
+    start_year BIGINT,
+    end_year BIGINT,
+    sort_order BIGINT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_dynasties_sort_order ON dynasties(sort_order);
+
+-- 作者
+CREATE TABLE IF NOT EXISTS authors (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    dynasty_id BIGINT REFERENCES dynasties(id),
+    dynasty VARCHAR(50),
+    biography TEXT,
+    birth_year BIGINT,
+    birth_approx BOOLEAN,
+    death_year BIGINT,
+    death_approx BOOLEAN,
+    courtesy_name VARCHAR(50),
+    art_name VARCHAR(100),
+    native_place VARCHAR(100),
+    era_names TEXT,
+    profile_source VARCHAR(20),
+    source_key VARCHAR(255),
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_author_dynasty ON authors(name, dynasty_id);
+CREATE INDEX IF NOT EXISTS idx_authors_dynasty ON authors(dynasty);
+CREATE INDEX IF NOT EXISTS idx_authors_birth_year ON authors(birth_year);
+CREATE INDEX IF NOT EXISTS idx_authors_death_year ON authors(death_year);
+CREATE INDEX IF NOT EXISTS idx_authors_source_key ON authors(source_key);
+
+-- 作者别名（字、号、异写）
+CREATE TABLE IF NOT EXISTS author_aliases (
+    id BIGSERIAL PRIMARY KEY,
+    author_id BIGINT REFERENCES authors(id),
+    name VARCHAR(255) NOT NULL,
+    dynasty VARCHAR(50),
+    type VARCHAR(20) DEFAULT 'alias',
+    created_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alias_name_dynasty ON author_aliases(name, dynasty);
+CREATE INDEX IF NOT EXISTS idx_author_aliases_author_id ON author_aliases(author_id);
+
+-- 作品
+CREATE TABLE IF NOT EXISTS works (
+    id BIGSERIAL PRIMARY KEY,
+    category_id BIGINT REFERENCES categories(id),
+    author_id BIGINT REFERENCES authors(id),
+    title VARCHAR(255),
+    rhythmic VARCHAR(255),
+    volume VARCHAR(100),
+    section VARCHAR(100),
+    content TEXT NOT NULL,                  -- JSON数组，每段一项
+    prologue TEXT,
+    original_id VARCHAR(100),
+    source_key VARCHAR(255),
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_works_category_id ON works(category_id);
+CREATE INDEX IF NOT EXISTS idx_works_author_id ON works(author_id);
+CREATE INDEX IF NOT EXISTS idx_works_title ON works(title);
+CREATE INDEX IF NOT EXISTS idx_works_rhythmic ON works(rhythmic);
+CREATE INDEX IF NOT EXISTS idx_works_source_key ON works(source_key);
+
+-- 选集
+CREATE TABLE IF NOT EXISTS anthologies (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    created_at TIMESTAMPTZ
+);
+
+-- 选集与作品的关联
+CREATE TABLE IF NOT EXISTS anthology_works (
+    anthology_id BIGINT REFERENCES anthologies(id),
+    work_id BIGINT REFERENCES works(id),
+    sort_order BIGINT,
+    PRIMARY KEY (anthology_id, work_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anthology_works_work_id ON anthology_works(work_id);
+
+-- 注释、评析
+CREATE TABLE IF NOT EXISTS comments (
+    id BIGSERIAL PRIMARY KEY,
+    work_id BIGINT REFERENCES works(id),
+    content TEXT NOT NULL,
+    type VARCHAR(50) DEFAULT 'note',
+    commenter VARCHAR(100),
+    paragraph_index BIGINT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_work_id ON comments(work_id);
+
+-- 管理后台对语料的修改，ETL 重建语料后重新应用
+CREATE TABLE IF NOT EXISTS corpus_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    source_key VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    data TEXT,
+    updated_by BIGINT,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_override_entity ON corpus_overrides(entity_type, source_key);
+
+-- ETL 导入记录
+CREATE TABLE IF NOT EXISTS import_runs (
+    id BIGSERIAL PRIMARY KEY,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    works BIGINT,
+    authors BIGINT,
+    author_aliases BIGINT,
+    comments BIGINT,
+    anthology_works BIGINT
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_runs_finished_at ON import_runs(finished_at);
+
+-- 作品、作者、注释的修订历史
+CREATE TABLE IF NOT EXISTS work_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    work_id BIGINT,
+    source_key VARCHAR(255),
+    action VARCHAR(20) NOT NULL,
+    "before" TEXT,
+    "after" TEXT,
+    editor_id BIGINT,
+    reason VARCHAR(255),
+    rollback_of BIGINT,
+    created_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_revision_entity ON work_revisions(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_work_id ON work_revisions(work_id);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_source_key ON work_revisions(source_key);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_editor_id ON work_revisions(editor_id);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_created_at ON work_revisions(created_at);
+
+-- 读者提交的纠错
+CREATE TABLE IF NOT EXISTS corrections (
+    id BIGSERIAL PRIMARY KEY,
+    work_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    title VARCHAR(255),
+    content TEXT,
+    author_id BIGINT,
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewer_id BIGINT,
+    review_note VARCHAR(500),
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_corrections_work_id ON corrections(work_id);
+CREATE INDEX IF NOT EXISTS idx_corrections_user_id ON corrections(user_id);
+CREATE INDEX IF NOT EXISTS idx_corrections_status ON corrections(status);
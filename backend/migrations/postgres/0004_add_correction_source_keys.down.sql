@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_corrections_work_key;
+ALTER TABLE corrections DROP COLUMN author_key;
+ALTER TABLE corrections DROP COLUMN work_key;
@@ -0,0 +1,167 @@
+-- 用户表
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    open_id VARCHAR(100),
+    union_id VARCHAR(100),
+    username VARCHAR(50) NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    nickname VARCHAR(100),
+    avatar_url VARCHAR(500),
+    email VARCHAR(100),
+    phone VARCHAR(20),
+    gender INTEGER DEFAULT 0 CHECK(gender IN (0, 1, 2)),
+    birth_date DATE,
+    province VARCHAR(50),
+    city VARCHAR(50),
+    level INTEGER DEFAULT 1,
+    experience INTEGER DEFAULT 0,
+    coins INTEGER DEFAULT 0,
+    vip_level INTEGER DEFAULT 0,
+    vip_expire_at DATETIME,
+    status INTEGER DEFAULT 1 CHECK(status IN (0, 1)),
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK(role IN ('user', 'editor', 'admin')),
+    last_login_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 选填的联系方式只在非空时要求唯一
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_open_id ON users(open_id) WHERE open_id <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE email <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_phone ON users(phone) WHERE phone <> '';
+
+-- 用户收藏表
+CREATE TABLE IF NOT EXISTS user_favorites (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK(target_type IN ('poem', 'author')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    UNIQUE(user_id, target_id, target_type)
+);
+
+-- 创建收藏表索引
+CREATE INDEX IF NOT EXISTS idx_favorites_user ON user_favorites(user_id);
+CREATE INDEX IF NOT EXISTS idx_favorites_target ON user_favorites(target_id, target_type);
+
+-- 用户浏览历史表
+CREATE TABLE IF NOT EXISTS user_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    target_id INTEGER NOT NULL,
+    target_type VARCHAR(20) NOT NULL CHECK(target_type IN ('poem', 'author')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 创建历史表索引
+CREATE INDEX IF NOT EXISTS idx_history_user ON user_history(user_id);
+CREATE INDEX IF NOT EXISTS idx_history_created ON user_history(created_at DESC);
+
+-- 用户会话表（刷新令牌，只保存哈希）
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    family_id VARCHAR(36) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    access_jti VARCHAR(36),
+    access_exp DATETIME,
+    device VARCHAR(100),
+    ip VARCHAR(45),
+    user_agent VARCHAR(500),
+    expires_at DATETIME,
+    rotated_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- 创建会话表索引
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_family_id ON user_sessions(family_id);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_expires_at ON user_sessions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_access_jti ON user_sessions(access_jti);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_access_exp ON user_sessions(access_exp);
+
+-- 已吊销的access token（jti 吊销名单）
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(36) PRIMARY KEY,
+    user_id INTEGER,
+    reason VARCHAR(50),
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_user_id ON revoked_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
+
+-- 找回密码令牌（只保存哈希，一次性使用）
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    ip VARCHAR(45),
+    expires_at DATETIME NOT NULL,
+    used_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user_id ON password_reset_tokens(user_id);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at);
+
+-- 登录失败计数（按用户名和IP），登录成功后删除
+CREATE TABLE IF NOT EXISTS login_throttles (
+    subject VARCHAR(120) PRIMARY KEY,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failed_at DATETIME NOT NULL,
+    locked_until DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_locked_until ON login_throttles(locked_until);
+
+-- 认证审计日志
+CREATE TABLE IF NOT EXISTS auth_audit_logs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event VARCHAR(30) NOT NULL,
+    user_id INTEGER,
+    username VARCHAR(50),
+    ip VARCHAR(45),
+    user_agent VARCHAR(255),
+    detail VARCHAR(255),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_event ON auth_audit_logs(event);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_user_id ON auth_audit_logs(user_id);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_username ON auth_audit_logs(username);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_ip ON auth_audit_logs(ip);
+CREATE INDEX IF NOT EXISTS idx_auth_audit_logs_created_at ON auth_audit_logs(created_at);
+
+-- 第三方开发者API密钥（只保存哈希）
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT NOT NULL,                   -- JSON数组，如 ["poetry:read"]
+    daily_quota INTEGER NOT NULL,
+    last_used_at DATETIME,
+    expires_at DATETIME,
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- API密钥每日调用次数（UTC 日期）
+CREATE TABLE IF NOT EXISTS api_key_usages (
+    api_key_id INTEGER NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, day),
+    FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE
+);
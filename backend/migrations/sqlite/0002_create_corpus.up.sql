@@ -0,0 +1,190 @@
+-- 语料表由 manage etl 填充；表名和索引名与此前 ETL 自动建表时一致，已有的库可直接执行
+
+-- 分类
+CREATE TABLE IF NOT EXISTS categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    created_at DATETIME
+);
+
+-- 朝代
+CREATE TABLE IF NOT EXISTS dynasties (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    name_en VARCHAR(50),
+    description TEXT,
+    period VARCHAR(50),
+    start_year INTEGER,
+    end_year INTEGER,
+    sort_order INTEGER,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_dynasties_sort_order ON dynasties(sort_order);
+
+-- 作者
+CREATE TABLE IF NOT EXISTS authors (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(255) NOT NULL,
+    dynasty_id INTEGER REFERENCES dynasties(id),
+    dynasty VARCHAR(50),
+    biography TEXT,
+    birth_year INTEGER,
+    birth_approx NUMERIC,
+    death_year INTEGER,
+    death_approx NUMERIC,
+    courtesy_name VARCHAR(50),
+    art_name VARCHAR(100),
+    native_place VARCHAR(100),
+    era_names TEXT,
+    profile_source VARCHAR(20),
+    source_key VARCHAR(255),
+    created_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_author_dynasty ON authors(name, dynasty_id);
+CREATE INDEX IF NOT EXISTS idx_authors_dynasty ON authors(dynasty);
+CREATE INDEX IF NOT EXISTS idx_authors_birth_year ON authors(birth_year);
+CREATE INDEX IF NOT EXISTS idx_authors_death_year ON authors(death_year);
+CREATE INDEX IF NOT EXISTS idx_authors_source_key ON authors(source_key);
+
+-- 作者别名（字、号、异写）
+CREATE TABLE IF NOT EXISTS author_aliases (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    author_id INTEGER REFERENCES authors(id),
+    name VARCHAR(255) NOT NULL,
+    dynasty VARCHAR(50),
+    type VARCHAR(20) DEFAULT 'alias',
+    created_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_alias_name_dynasty ON author_aliases(name, dynasty);
+CREATE INDEX IF NOT EXISTS idx_author_aliases_author_id ON author_aliases(author_id);
+
+-- 作品
+CREATE TABLE IF NOT EXISTS works (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    category_id INTEGER REFERENCES categories(id),
+    author_id INTEGER REFERENCES authors(id),
+    title VARCHAR(255),
+    rhythmic VARCHAR(255),
+    volume VARCHAR(100),
+    section VARCHAR(100),
+    content TEXT NOT NULL,                  -- JSON数组，每段一项
+    prologue TEXT,
+    original_id VARCHAR(100),
+    source_key VARCHAR(255),
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_works_category_id ON works(category_id);
+CREATE INDEX IF NOT EXISTS idx_works_author_id ON works(author_id);
+CREATE INDEX IF NOT EXISTS idx_works_title ON works(title);
+CREATE INDEX IF NOT EXISTS idx_works_rhythmic ON works(rhythmic);
+CREATE INDEX IF NOT EXISTS idx_works_source_key ON works(source_key);
+
+-- 选集
+CREATE TABLE IF NOT EXISTS anthologies (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    display_name VARCHAR(100),
+    description TEXT,
+    created_at DATETIME
+);
+
+-- 选集与作品的关联
+CREATE TABLE IF NOT EXISTS anthology_works (
+    anthology_id INTEGER REFERENCES anthologies(id),
+    work_id INTEGER REFERENCES works(id),
+    sort_order INTEGER,
+    PRIMARY KEY (anthology_id, work_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_anthology_works_work_id ON anthology_works(work_id);
+
+-- 注释、评析
+CREATE TABLE IF NOT EXISTS comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    work_id INTEGER REFERENCES works(id),
+    content TEXT NOT NULL,
+    type VARCHAR(50) DEFAULT 'note',
+    commenter VARCHAR(100),
+    paragraph_index INTEGER,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_work_id ON comments(work_id);
+
+-- 管理后台对语料的修改，ETL 重建语料后重新应用
+CREATE TABLE IF NOT EXISTS corpus_overrides (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type VARCHAR(20) NOT NULL,
+    source_key VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    data TEXT,
+    updated_by INTEGER,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_override_entity ON corpus_overrides(entity_type, source_key);
+
+-- ETL 导入记录
+CREATE TABLE IF NOT EXISTS import_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    started_at DATETIME,
+    finished_at DATETIME,
+    works INTEGER,
+    authors INTEGER,
+    author_aliases INTEGER,
+    comments INTEGER,
+    anthology_works INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_import_runs_finished_at ON import_runs(finished_at);
+
+-- 作品、作者、注释的修订历史
+CREATE TABLE IF NOT EXISTS work_revisions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    work_id INTEGER,
+    source_key VARCHAR(255),
+    action VARCHAR(20) NOT NULL,
+    "before" TEXT,
+    "after" TEXT,
+    editor_id INTEGER,
+    reason VARCHAR(255),
+    rollback_of INTEGER,
+    created_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_revision_entity ON work_revisions(entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_work_id ON work_revisions(work_id);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_source_key ON work_revisions(source_key);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_editor_id ON work_revisions(editor_id);
+CREATE INDEX IF NOT EXISTS idx_work_revisions_created_at ON work_revisions(created_at);
+
+-- 读者提交的纠错
+CREATE TABLE IF NOT EXISTS corrections (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    work_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    title VARCHAR(255),
+    content TEXT,
+    author_id INTEGER,
+    note TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    reviewer_id INTEGER,
+    review_note VARCHAR(500),
+    reviewed_at DATETIME,
+    created_at DATETIME,
+    updated_at DATETIME
+);
+
+CREATE INDEX IF NOT EXISTS idx_corrections_work_id ON corrections(work_id);
+CREATE INDEX IF NOT EXISTS idx_corrections_user_id ON corrections(user_id);
+CREATE INDEX IF NOT EXISTS idx_corrections_status ON corrections(status);
@@ -0,0 +1,10 @@
+-- 纠错引用的作品和作者改为同时记录 SourceKey，manage etl 重建语料后ID会变化，据此重新关联
+ALTER TABLE corrections ADD COLUMN work_key VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE corrections ADD COLUMN author_key VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_corrections_work_key ON corrections(work_key);
+
+-- 已有的纠错按当前ID回填
+UPDATE corrections SET work_key = COALESCE((SELECT source_key FROM works WHERE works.id = corrections.work_id), '');
+UPDATE corrections SET author_key = COALESCE((SELECT source_key FROM authors WHERE authors.id = corrections.author_id), '')
+    WHERE author_id IS NOT NULL AND author_id <> 0;
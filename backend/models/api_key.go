@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// APIKey 第三方开发者的API密钥，只保存哈希，Prefix 用于在列表中辨认
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:20;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     JSONArr    `gorm:"type:text;not null" json:"scopes"`
+	DailyQuota int        `gorm:"not null" json:"daily_quota"` // 每日调用上限（按 UTC 日期计）
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	UsageToday int64 `gorm:"-" json:"usage_today"`
+}
+
+// TableName 指定表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// HasScope 密钥是否拥有权限范围
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyUsage API密钥每日调用次数
+type APIKeyUsage struct {
+	APIKeyID uint   `gorm:"primaryKey" json:"-"`
+	Day      string `gorm:"primaryKey;size:10" json:"day"` // UTC 日期，如 2024-01-15
+	Count    int64  `gorm:"not null;default:0" json:"count"`
+}
+
+// TableName 指定表名
+func (APIKeyUsage) TableName() string {
+	return "api_key_usages"
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// 纠错状态
+const (
+	CorrectionPending  = "pending"
+	CorrectionApproved = "approved"
+	CorrectionRejected = "rejected"
+)
+
+// Correction 读者提交的作品纠错。Title、Content、AuthorID 为空表示不修改该项。
+// manage etl 重建语料后ID会变化，WorkKey、AuthorKey 记录对应的 SourceKey，采纳时据此找回作品和作者
+type Correction struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	WorkID     uint       `gorm:"not null;index" json:"work_id"`
+	WorkKey    string     `gorm:"size:255;not null;default:'';index" json:"-"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Title      string     `gorm:"size:255" json:"title,omitempty"`
+	Content    JSONArr    `gorm:"type:text" json:"content,omitempty"`
+	AuthorID   uint       `json:"author_id,omitempty"`
+	AuthorKey  string     `gorm:"size:255;not null;default:''" json:"-"`
+	Note       string     `gorm:"type:text" json:"note"`
+	Status     string     `gorm:"size:20;not null;default:pending;index" json:"status"`
+	ReviewerID uint       `json:"reviewer_id,omitempty"`
+	ReviewNote string     `gorm:"size:500" json:"review_note,omitempty"` // 驳回原因或采纳说明
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (Correction) TableName() string {
+	return "corrections"
+}
+
+// CorrectionFilter 纠错列表筛选条件，零值表示不限
+type CorrectionFilter struct {
+	Status string
+	UserID uint
+	WorkID uint
+}
+
+// CorrectionCollection 纠错集合（分页）
+type CorrectionCollection struct {
+	Corrections []Correction `json:"corrections"`
+	Total       int          `json:"total"`
+	Page        int          `json:"page"`
+	PageSize    int          `json:"page_size"`
+	TotalPages  int          `json:"total_pages"`
+	NextCursor  string       `json:"next_cursor,omitempty"`
+}
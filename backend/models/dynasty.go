@@ -0,0 +1,69 @@
+package models
+
+import "strings"
+
+// DefaultDynasties 朝代种子数据，按时间先后排列；Code 与前端使用的朝代代码一致
+var DefaultDynasties = []Dynasty{
+	{Code: "pre-qin", Name: "先秦", NameEn: "Pre-Qin", Period: "？－前221年", StartYear: -2070, EndYear: -221, Description: "秦统一之前的历史时期，诗经、楚辞诞生于此"},
+	{Code: "qin", Name: "秦", NameEn: "Qin", Period: "前221年－前207年", StartYear: -221, EndYear: -207, Description: "中国历史上第一个大一统王朝"},
+	{Code: "han", Name: "汉", NameEn: "Han", Period: "前202年－220年", StartYear: -202, EndYear: 220, Description: "西汉、东汉，乐府诗兴盛"},
+	{Code: "wei-jin", Name: "魏晋", NameEn: "Wei-Jin", Period: "220年－420年", StartYear: 220, EndYear: 420, Description: "三国至东晋，建安风骨与玄言、田园诗"},
+	{Code: "nanbei", Name: "南北朝", NameEn: "Northern and Southern", Period: "420年－589年", StartYear: 420, EndYear: 589, Description: "南北对峙时期，永明体与民歌"},
+	{Code: "sui", Name: "隋", NameEn: "Sui", Period: "581年－618年", StartYear: 581, EndYear: 618, Description: "结束南北分裂的短暂王朝"},
+	{Code: "tang", Name: "唐", NameEn: "Tang", Period: "618年－907年", StartYear: 618, EndYear: 907, Description: "诗歌的黄金时代"},
+	{Code: "wudai", Name: "五代", NameEn: "Five Dynasties", Period: "907年－979年", StartYear: 907, EndYear: 979, Description: "五代十国，花间词与南唐词"},
+	{Code: "song", Name: "宋", NameEn: "Song", Period: "960年－1279年", StartYear: 960, EndYear: 1279, Description: "北宋、南宋，词的鼎盛时期"},
+	{Code: "liao", Name: "辽", NameEn: "Liao", Period: "916年－1125年", StartYear: 916, EndYear: 1125, Description: "契丹建立的北方王朝"},
+	{Code: "jin", Name: "金", NameEn: "Jin", Period: "1115年－1234年", StartYear: 1115, EndYear: 1234, Description: "女真建立的北方王朝"},
+	{Code: "yuan", Name: "元", NameEn: "Yuan", Period: "1271年－1368年", StartYear: 1271, EndYear: 1368, Description: "元曲的时代"},
+	{Code: "ming", Name: "明", NameEn: "Ming", Period: "1368年－1644年", StartYear: 1368, EndYear: 1644, Description: "明代诗文与小说"},
+	{Code: "qing", Name: "清", NameEn: "Qing", Period: "1636年－1912年", StartYear: 1636, EndYear: 1912, Description: "清诗与清词中兴"},
+}
+
+// dynastyAliases 数据源与接口中出现的其他写法
+var dynastyAliases = map[string]string{
+	"preqin": "先秦",
+	"秦朝":     "秦",
+	"汉朝":     "汉",
+	"汉代":     "汉",
+	"漢":      "汉",
+	"汉/魏":    "汉",
+	"汉魏":     "汉",
+	"西汉":     "汉",
+	"东汉":     "汉",
+	"魏":      "魏晋",
+	"晋":      "魏晋",
+	"晉":      "魏晋",
+	"三国":     "魏晋",
+	"魏晋南北朝":  "南北朝",
+	"隋朝":     "隋",
+	"唐朝":     "唐",
+	"唐代":     "唐",
+	"五代十国":   "五代",
+	"南唐":     "五代",
+	"宋朝":     "宋",
+	"宋代":     "宋",
+	"北宋":     "宋",
+	"南宋":     "宋",
+	"元朝":     "元",
+	"元代":     "元",
+	"明朝":     "明",
+	"明代":     "明",
+	"清朝":     "清",
+	"清代":     "清",
+}
+
+// NormalizeDynasty 把朝代代码、英文名和各种写法统一为 dynasties 表中的中文名，无法识别时原样返回
+func NormalizeDynasty(dynasty string) string {
+	dynasty = strings.TrimSpace(dynasty)
+	key := strings.ToLower(dynasty)
+	for _, d := range DefaultDynasties {
+		if key == d.Code || dynasty == d.Name || key == strings.ToLower(d.NameEn) {
+			return d.Name
+		}
+	}
+	if name, ok := dynastyAliases[key]; ok {
+		return name
+	}
+	return dynasty
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// 修改记录针对的实体和动作
+const (
+	OverrideEntityWork   = "work"
+	OverrideEntityAuthor = "author"
+
+	OverrideActionCreate = "create"
+	OverrideActionUpdate = "update"
+	OverrideActionDelete = "delete"
+)
+
+// CorpusOverride 管理后台对语料的修改。ETL 每次都会重建作品和作者表，
+// 导入完成后按 SourceKey 找到对应记录并重新应用这些修改；该表本身不会被 ETL 删除
+type CorpusOverride struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EntityType string    `gorm:"size:20;not null;uniqueIndex:idx_override_entity" json:"entity_type"` // work, author
+	SourceKey  string    `gorm:"size:255;not null;uniqueIndex:idx_override_entity" json:"source_key"`
+	Action     string    `gorm:"size:20;not null" json:"action"` // create, update, delete
+	Data       string    `gorm:"type:text" json:"data"`          // WorkPatch 或 AuthorPatch 的 JSON
+	UpdatedBy  uint      `json:"updated_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名
+func (CorpusOverride) TableName() string {
+	return "corpus_overrides"
+}
+
+// CommentData 作品注释的内容，用于在修改记录中保存整份注释列表
+type CommentData struct {
+	Content        string `json:"content"`
+	Type           string `json:"type"`
+	Commenter      string `json:"commenter"`
+	ParagraphIndex int    `json:"paragraph_index"`
+}
+
+// WorkPatch 对作品的修改，nil 字段表示未修改。新建作品时保存全部字段
+type WorkPatch struct {
+	Title     *string        `json:"title,omitempty"`
+	Rhythmic  *string        `json:"rhythmic,omitempty"`
+	Volume    *string        `json:"volume,omitempty"`
+	Section   *string        `json:"section,omitempty"`
+	Prologue  *string        `json:"prologue,omitempty"`
+	Content   *[]string      `json:"content,omitempty"`
+	AuthorKey *string        `json:"author_key,omitempty"` // 作者的 SourceKey
+	Category  *string        `json:"category,omitempty"`   // 分类名，如 quantangshi
+	Comments  *[]CommentData `json:"comments,omitempty"`   // 修改过注释时保存完整列表
+}
+
+// Merge 用 other 中非 nil 的字段覆盖当前修改
+func (p WorkPatch) Merge(other WorkPatch) WorkPatch {
+	if other.Title != nil {
+		p.Title = other.Title
+	}
+	if other.Rhythmic != nil {
+		p.Rhythmic = other.Rhythmic
+	}
+	if other.Volume != nil {
+		p.Volume = other.Volume
+	}
+	if other.Section != nil {
+		p.Section = other.Section
+	}
+	if other.Prologue != nil {
+		p.Prologue = other.Prologue
+	}
+	if other.Content != nil {
+		p.Content = other.Content
+	}
+	if other.AuthorKey != nil {
+		p.AuthorKey = other.AuthorKey
+	}
+	if other.Category != nil {
+		p.Category = other.Category
+	}
+	if other.Comments != nil {
+		p.Comments = other.Comments
+	}
+	return p
+}
+
+// ApplyTo 把文本字段写入作品，作者、分类和注释需要查表，由调用方处理
+func (p WorkPatch) ApplyTo(w *Work) {
+	if p.Title != nil {
+		w.Title = *p.Title
+	}
+	if p.Rhythmic != nil {
+		w.Rhythmic = *p.Rhythmic
+	}
+	if p.Volume != nil {
+		w.Volume = *p.Volume
+	}
+	if p.Section != nil {
+		w.Section = *p.Section
+	}
+	if p.Prologue != nil {
+		w.Prologue = *p.Prologue
+	}
+	if p.Content != nil {
+		w.Content = JSONArr(*p.Content)
+	}
+}
+
+// AuthorPatch 对作者的修改，nil 字段表示未修改
+type AuthorPatch struct {
+	Name      *string `json:"name,omitempty"`
+	Dynasty   *string `json:"dynasty,omitempty"`
+	Biography *string `json:"biography,omitempty"`
+}
+
+// Merge 用 other 中非 nil 的字段覆盖当前修改
+func (p AuthorPatch) Merge(other AuthorPatch) AuthorPatch {
+	if other.Name != nil {
+		p.Name = other.Name
+	}
+	if other.Dynasty != nil {
+		p.Dynasty = other.Dynasty
+	}
+	if other.Biography != nil {
+		p.Biography = other.Biography
+	}
+	return p
+}
+
+// ApplyTo 把修改写入作者，朝代变化时调用方还需更新 DynastyID
+func (p AuthorPatch) ApplyTo(a *Author) {
+	if p.Name != nil {
+		a.Name = *p.Name
+	}
+	if p.Dynasty != nil {
+		a.Dynasty = *p.Dynasty
+	}
+	if p.Biography != nil {
+		a.Biography = *p.Biography
+	}
+}
+
+// AuthorSourceKey 作者在数据源中的标识（导入时的正名和朝代）
+func AuthorSourceKey(name, dynasty string) string {
+	return name + "|" + dynasty
+}
+
+// WorkSourceKey 作品在数据源中的标识：有原始ID时用分类+原始ID，
+// 否则用分类、作者、标题和正文摘要。同一数据源中重复的作品由 ETL 追加序号区分
+func WorkSourceKey(category, originalID, authorKey, title string, content []string) string {
+	if originalID != "" {
+		return category + "|id:" + originalID
+	}
+	sum := sha1.Sum([]byte(strings.Join(content, "\n")))
+	return category + "|" + authorKey + "|" + title + "|" + hex.EncodeToString(sum[:8])
+}
@@ -17,32 +17,92 @@ type Category struct {
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+// Dynasty 朝代表，SortOrder 按时间先后排列
+type Dynasty struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"size:20;not null;unique" json:"code"` // e.g., 'tang'
+	Name        string    `gorm:"size:50;not null;unique" json:"name"` // e.g., '唐'
+	NameEn      string    `gorm:"size:50" json:"name_en"`
+	Description string    `gorm:"type:text" json:"description"`
+	Period      string    `gorm:"size:50" json:"period"` // e.g., '618年－907年'
+	StartYear   int       `json:"start_year"`            // 公元前为负数
+	EndYear     int       `json:"end_year"`
+	SortOrder   int       `gorm:"index" json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
 // Author 作者表
 type Author struct {
+	ID          uint          `gorm:"primaryKey" json:"id"`
+	Name        string        `gorm:"size:255;not null;index:idx_author_dynasty,unique" json:"name"`
+	DynastyID   uint          `gorm:"index:idx_author_dynasty,unique" json:"dynasty_id"`
+	DynastyInfo *Dynasty      `gorm:"foreignKey:DynastyID" json:"dynasty_info,omitempty"`
+	Dynasty     string        `gorm:"size:50;index" json:"dynasty"` // 朝代名，与 DynastyID 同步，便于展示
+	Biography   string        `gorm:"type:text" json:"biography"`
+	Profile     AuthorProfile `gorm:"embedded" json:"profile"`
+	Works       []Work        `gorm:"foreignKey:AuthorID" json:"-"`
+	Aliases     []AuthorAlias `gorm:"foreignKey:AuthorID" json:"aliases,omitempty"` // 字、号、异写等别名
+	SourceKey   string        `gorm:"size:255;index" json:"-"`                      // 导入时的 name|dynasty，改名后不变
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// AuthorProfile 作者结构化资料，ETL 从简介中解析，管理员可手工修正
+type AuthorProfile struct {
+	BirthYear    *int    `gorm:"index" json:"birth_year"` // 公元前为负数
+	BirthApprox  bool    `json:"birth_approx"`            // 生年不确定（约、?）
+	DeathYear    *int    `gorm:"index" json:"death_year"`
+	DeathApprox  bool    `json:"death_approx"`
+	CourtesyName string  `gorm:"size:50" json:"courtesy_name"`                        // 字
+	ArtName      string  `gorm:"size:100" json:"art_name"`                            // 号
+	NativePlace  string  `gorm:"size:100" json:"native_place"`                        // 籍贯
+	EraNames     JSONArr `gorm:"type:text" json:"era_names"`                          // 生平涉及的年号
+	Source       string  `gorm:"column:profile_source;size:20" json:"profile_source"` // parsed / manual
+}
+
+// AuthorAlias 作者别名表，把同一作者的字、号及不同朝代写法归到同一条 Author 记录
+type AuthorAlias struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
-	Name      string    `gorm:"size:255;not null;index:idx_author_dynasty,unique" json:"name"`
-	Dynasty   string    `gorm:"size:50;index:idx_author_dynasty,unique" json:"dynasty"`
-	Biography string    `gorm:"type:text" json:"biography"`
-	Works     []Work    `gorm:"foreignKey:AuthorID" json:"-"`
+	AuthorID  uint      `gorm:"index" json:"author_id"`
+	Name      string    `gorm:"size:255;not null;index:idx_alias_name_dynasty,unique" json:"name"` // e.g., '东坡居士'
+	Dynasty   string    `gorm:"size:50;index:idx_alias_name_dynasty,unique" json:"dynasty"`        // 为空表示不限朝代
+	Type      string    `gorm:"size:20;default:'alias'" json:"type"`                               // courtesy(字), pseudonym(号), alias, merged
 	CreatedAt time.Time `json:"created_at"`
 }
 
 // Work 作品表
 type Work struct {
-	ID         uint      `gorm:"primaryKey" json:"id"`
-	CategoryID uint      `gorm:"index" json:"category_id"`
-	AuthorID   uint      `gorm:"index" json:"author_id"`
-	Category   Category  `gorm:"foreignKey:CategoryID" json:"category"`
-	Author     Author    `gorm:"foreignKey:AuthorID" json:"author"`
-	Title      string    `gorm:"size:255;index" json:"title"`
-	Rhythmic   string    `gorm:"size:255;index" json:"rhythmic"` // 词牌名/曲牌名
-	Volume     string    `gorm:"size:100" json:"volume"`         // 卷
-	Section    string    `gorm:"size:100" json:"section"`        // 篇/章
-	Content    JSONArr   `gorm:"type:text;not null" json:"content"`
-	Prologue   string    `gorm:"type:text" json:"prologue"`
-	OriginalID string    `gorm:"size:100" json:"original_id"`
-	Comments   []Comment `gorm:"foreignKey:WorkID" json:"comments"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	CategoryID  uint        `gorm:"index" json:"category_id"`
+	AuthorID    uint        `gorm:"index" json:"author_id"`
+	Category    Category    `gorm:"foreignKey:CategoryID" json:"category"`
+	Author      Author      `gorm:"foreignKey:AuthorID" json:"author"`
+	Title       string      `gorm:"size:255;index" json:"title"`
+	Rhythmic    string      `gorm:"size:255;index" json:"rhythmic"` // 词牌名/曲牌名
+	Volume      string      `gorm:"size:100" json:"volume"`         // 卷
+	Section     string      `gorm:"size:100" json:"section"`        // 篇/章
+	Content     JSONArr     `gorm:"type:text;not null" json:"content"`
+	Prologue    string      `gorm:"type:text" json:"prologue"`
+	OriginalID  string      `gorm:"size:100" json:"original_id"`
+	SourceKey   string      `gorm:"size:255;index" json:"-"` // 数据源中的稳定标识，见 WorkSourceKey
+	Comments    []Comment   `gorm:"foreignKey:WorkID" json:"comments"`
+	Anthologies []Anthology `gorm:"many2many:anthology_works" json:"anthologies,omitempty"` // 所属选集（唐诗三百首等）
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Anthology 选集表（如唐诗三百首、宋词三百首），作品仍归属原分类
+type Anthology struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"size:50;not null;unique" json:"name"` // e.g., 'tangshi300'
+	DisplayName string    `gorm:"size:100" json:"display_name"`        // e.g., '唐诗三百首'
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AnthologyWork 选集与作品的关联表，SortOrder 保留选集内的原始顺序
+type AnthologyWork struct {
+	AnthologyID uint `gorm:"primaryKey" json:"anthology_id"`
+	WorkID      uint `gorm:"primaryKey;index" json:"work_id"`
+	SortOrder   int  `json:"sort_order"`
 }
 
 // Comment 注释/评析表
@@ -56,15 +116,43 @@ type Comment struct {
 	CreatedAt      time.Time `json:"created_at"`
 }
 
-// JSONArr 用于在 SQLite 中存储 JSON 数组
+// ImportRun ETL 导入记录，manage etl 完成时写入各表的导入行数，不随语料重建清空
+type ImportRun struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `gorm:"index" json:"finished_at"`
+	Works          int64     `json:"works"`
+	Authors        int64     `json:"authors"`
+	AuthorAliases  int64     `json:"author_aliases"`
+	Comments       int64     `json:"comments"`
+	AnthologyWorks int64     `json:"anthology_works"`
+}
+
+// DataVersionCorpus 语料的数据版本名
+const DataVersionCorpus = "corpus"
+
+// DataVersion 数据版本，语料变化（manage etl、管理后台编辑、manage authors）时递增，
+// 服务端据此清空查询缓存并生成 ETag
+type DataVersion struct {
+	Name      string    `gorm:"primaryKey;size:50" json:"name"`
+	Version   int64     `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// JSONArr 以 JSON 文本存储的字符串数组，列类型为 text，三种数据库通用
 type JSONArr []string
 
-// Value 实现 driver.Valuer 接口
+// Value 实现 driver.Valuer 接口。返回 string 而非 []byte：[]byte 在 SQLite 中存为 BLOB，LIKE 匹配不到其中的中文；
+// PostgreSQL 会把 []byte 当作 bytea，与 text 列比较时报错
 func (j JSONArr) Value() (driver.Value, error) {
 	if len(j) == 0 {
 		return "[]", nil
 	}
-	return json.Marshal(j)
+	b, err := json.Marshal(j)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
 }
 
 // Scan 实现 sql.Scanner 接口
@@ -86,7 +174,12 @@ func (j *JSONArr) Scan(value interface{}) error {
 }
 
 // TableName overrides
-func (Category) TableName() string { return "categories" }
-func (Author) TableName() string   { return "authors" }
-func (Work) TableName() string     { return "works" }
-func (Comment) TableName() string  { return "comments" }
+func (Category) TableName() string      { return "categories" }
+func (Dynasty) TableName() string       { return "dynasties" }
+func (Author) TableName() string        { return "authors" }
+func (Work) TableName() string          { return "works" }
+func (Comment) TableName() string       { return "comments" }
+func (AuthorAlias) TableName() string   { return "author_aliases" }
+func (Anthology) TableName() string     { return "anthologies" }
+func (AnthologyWork) TableName() string { return "anthology_works" }
+func (ImportRun) TableName() string     { return "import_runs" }
@@ -1,13 +1,37 @@
 package models
 
-// Dynasty (Legacy support or for static config if needed, though now Author has Dynasty field)
-type Dynasty struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	NameEn      string `json:"name_en"`
-	Description string `json:"description"`
-	Period      string `json:"period"`
-	SortOrder   int    `json:"sort_order"`
+// DynastyStats 朝代及其作者、作品数量
+type DynastyStats struct {
+	Dynasty
+	AuthorCount int64 `json:"author_count"`
+	WorkCount   int64 `json:"work_count"`
+}
+
+// PageQuery 分页参数：提供 Cursor 时从游标处继续，忽略 Page
+type PageQuery struct {
+	Page      int
+	PageSize  int
+	Cursor    string
+	WithTotal bool // 为 false 时跳过 COUNT(*)，Total/TotalPages 返回 -1
+}
+
+// pageLimits 每页数量的默认值和上限，启动时由配置设置
+var pageLimits = struct{ defaultSize, maxSize int }{20, 100}
+
+// SetPageLimits 设置每页数量的默认值和上限
+func SetPageLimits(defaultSize, maxSize int) {
+	pageLimits.defaultSize, pageLimits.maxSize = defaultSize, maxSize
+}
+
+// Normalize 修正页码和每页数量，每页数量未指定或超出上限时使用默认值
+func (pq PageQuery) Normalize() PageQuery {
+	if pq.Page < 1 {
+		pq.Page = 1
+	}
+	if pq.PageSize < 1 || pq.PageSize > pageLimits.maxSize {
+		pq.PageSize = pageLimits.defaultSize
+	}
+	return pq
 }
 
 // PoemCollection 诗词集合（分页）
@@ -17,6 +41,7 @@ type PoemCollection struct {
 	Page       int    `json:"page"`
 	PageSize   int    `json:"page_size"`
 	TotalPages int    `json:"total_pages"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // AuthorCollection 作者集合（分页）
@@ -26,6 +51,33 @@ type AuthorCollection struct {
 	Page       int      `json:"page"`
 	PageSize   int      `json:"page_size"`
 	TotalPages int      `json:"total_pages"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// CommentFilter 注释列表筛选条件，零值表示不限
+type CommentFilter struct {
+	WorkID uint
+	Type   string
+}
+
+// CommentCollection 注释集合（分页）
+type CommentCollection struct {
+	Comments   []Comment `json:"comments"`
+	Total      int       `json:"total"`
+	Page       int       `json:"page"`
+	PageSize   int       `json:"page_size"`
+	TotalPages int       `json:"total_pages"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// AuthorFilter 作者列表筛选与排序条件
+type AuthorFilter struct {
+	Dynasty    string
+	AliveIn    *int   // 该年在世
+	BornAfter  *int   // 生年 >= BornAfter
+	BornBefore *int   // 生年 <= BornBefore
+	SortBy     string // id, name, birth_year, death_year
+	Desc       bool
 }
 
 // SearchResponse 搜索响应
@@ -38,6 +90,7 @@ type SearchResponse struct {
 	TotalPages int      `json:"total_pages"`
 	Query      string   `json:"query"`
 	DurationMs int64    `json:"duration_ms"`
+	NextCursor string   `json:"next_cursor,omitempty"`
 }
 
 // APIResponse 统一API响应
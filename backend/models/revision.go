@@ -0,0 +1,108 @@
+package models
+
+import "time"
+
+// RevisionEntityComment 注释修订；作品和作者沿用 OverrideEntityWork、OverrideEntityAuthor
+const RevisionEntityComment = "comment"
+
+// WorkRevision 管理后台对作品、作者或注释的一次修改。
+// Before/After 为修改前后的完整快照（JSON），新建时 Before 为空，删除时 After 为空
+type WorkRevision struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	EntityType string    `gorm:"size:20;not null;index:idx_revision_entity" json:"entity_type"` // work, author, comment
+	EntityID   uint      `gorm:"not null;index:idx_revision_entity" json:"entity_id"`
+	WorkID     uint      `gorm:"index" json:"work_id,omitempty"`   // 作品或注释所属作品，作者修订为 0
+	SourceKey  string    `gorm:"size:255;index" json:"source_key"` // 作品或作者的 SourceKey，ETL 重建后据此更新ID
+	Action     string    `gorm:"size:20;not null" json:"action"`   // create, update, delete
+	Before     string    `gorm:"type:text" json:"before,omitempty"`
+	After      string    `gorm:"type:text" json:"after,omitempty"`
+	EditorID   uint      `gorm:"index" json:"editor_id"`
+	Reason     string    `gorm:"size:255" json:"reason"`
+	RollbackOf uint      `json:"rollback_of,omitempty"` // 由回滚产生时，指向回滚到的修订
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (WorkRevision) TableName() string {
+	return "work_revisions"
+}
+
+// RevisionFilter 修订列表筛选条件，零值表示不限
+type RevisionFilter struct {
+	EntityType string
+	EntityID   uint
+	WorkID     uint
+	EditorID   uint
+}
+
+// RevisionCollection 修订集合（分页）
+type RevisionCollection struct {
+	Revisions  []WorkRevision `json:"revisions"`
+	Total      int            `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	TotalPages int            `json:"total_pages"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// WorkSnapshot 作品快照，不含注释。ETL 重建后作者ID会变化，回滚时优先按 AuthorKey 找回作者
+type WorkSnapshot struct {
+	Title     string   `json:"title"`
+	Rhythmic  string   `json:"rhythmic"`
+	Volume    string   `json:"volume"`
+	Section   string   `json:"section"`
+	Prologue  string   `json:"prologue"`
+	Content   []string `json:"content"`
+	AuthorID  uint     `json:"author_id"`
+	AuthorKey string   `json:"author_key,omitempty"`
+	Category  string   `json:"category"`
+}
+
+// NewWorkSnapshot 生成作品快照，要求已加载 Author 和 Category
+func NewWorkSnapshot(w *Work) WorkSnapshot {
+	return WorkSnapshot{
+		Title:     w.Title,
+		Rhythmic:  w.Rhythmic,
+		Volume:    w.Volume,
+		Section:   w.Section,
+		Prologue:  w.Prologue,
+		Content:   append([]string(nil), w.Content...),
+		AuthorID:  w.AuthorID,
+		AuthorKey: w.Author.SourceKey,
+		Category:  w.Category.Name,
+	}
+}
+
+// AuthorSnapshot 作者快照
+type AuthorSnapshot struct {
+	Name      string `json:"name"`
+	Dynasty   string `json:"dynasty"`
+	Biography string `json:"biography"`
+}
+
+// NewAuthorSnapshot 生成作者快照
+func NewAuthorSnapshot(a *Author) AuthorSnapshot {
+	return AuthorSnapshot{Name: a.Name, Dynasty: a.Dynasty, Biography: a.Biography}
+}
+
+// CommentSnapshot 注释快照，WorkKey 为所属作品的 SourceKey
+type CommentSnapshot struct {
+	WorkID         uint   `json:"work_id"`
+	WorkKey        string `json:"work_key,omitempty"`
+	Content        string `json:"content"`
+	Type           string `json:"type"`
+	Commenter      string `json:"commenter"`
+	ParagraphIndex int    `json:"paragraph_index"`
+}
+
+// NewCommentSnapshot 生成注释快照
+func NewCommentSnapshot(c *Comment, workKey string) CommentSnapshot {
+	return CommentSnapshot{
+		WorkID:         c.WorkID,
+		WorkKey:        workKey,
+		Content:        c.Content,
+		Type:           c.Type,
+		Commenter:      c.Commenter,
+		ParagraphIndex: c.ParagraphIndex,
+	}
+}
@@ -7,14 +7,14 @@ import (
 // User 用户表
 type User struct {
 	ID           uint       `gorm:"primaryKey" json:"id"`
-	OpenID       string     `gorm:"size:100;unique" json:"open_id,omitempty"`           // 微信OpenID（预留）
+	OpenID       string     `gorm:"size:100;uniqueIndex:idx_users_open_id,where:open_id <> ''" json:"open_id,omitempty"` // 微信OpenID（预留）
 	UnionID      string     `gorm:"size:100" json:"union_id,omitempty"`                 // 微信UnionID（预留）
 	Username     string     `gorm:"size:50;not null;unique" json:"username"`            // 用户名
 	PasswordHash string     `gorm:"size:255;not null" json:"-"`                         // 密码哈希（不返回给前端）
 	Nickname     string     `gorm:"size:100" json:"nickname"`                            // 昵称
 	AvatarURL    string     `gorm:"size:500" json:"avatar_url"`                          // 头像URL
-	Email        string     `gorm:"size:100;unique" json:"email,omitempty"`             // 邮箱
-	Phone        string     `gorm:"size:20;unique" json:"phone,omitempty"`              // 手机号
+	Email        string     `gorm:"size:100;uniqueIndex:idx_users_email,where:email <> ''" json:"email,omitempty"` // 邮箱
+	Phone        string     `gorm:"size:20;uniqueIndex:idx_users_phone,where:phone <> ''" json:"phone,omitempty"` // 手机号
 	Gender       int        `gorm:"default:0;comment:0:未知 1:男 2:女" json:"gender"`  // 性别
 	BirthDate    *time.Time `json:"birth_date,omitempty"`                               // 生日
 	Province     string     `gorm:"size:50" json:"province,omitempty"`                  // 省份
@@ -22,9 +22,10 @@ type User struct {
 	Level        int        `gorm:"default:1;comment:用户等级" json:"level"`            // 等级
 	Experience   int        `gorm:"default:0;comment:经验值" json:"experience"`          // 经验值
 	Coins        int        `gorm:"default:0;comment:金币" json:"coins"`                // 金币
-	VIPLevel     int        `gorm:"default:0;comment:VIP等级" json:"vip_level"`        // VIP等级
-	VIPExpireAt  *time.Time `json:"vip_expire_at,omitempty"`                            // VIP过期时间
+	VIPLevel     int        `gorm:"column:vip_level;default:0;comment:VIP等级" json:"vip_level"` // VIP等级
+	VIPExpireAt  *time.Time `gorm:"column:vip_expire_at" json:"vip_expire_at,omitempty"` // VIP过期时间
 	Status       int        `gorm:"default:1;comment:0:禁用 1:正常" json:"status"`      // 状态
+	Role         string     `gorm:"size:20;default:user" json:"role"`                  // 角色：user、editor、admin
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`                            // 最后登录时间
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
@@ -62,3 +63,94 @@ type UserHistory struct {
 func (UserHistory) TableName() string {
 	return "user_history"
 }
+
+// UserSession 登录会话的刷新令牌。每次刷新都会轮换出新令牌（新行），
+// 同一次登录产生的令牌共享 FamilyID；已轮换的令牌再次出现视为泄露，整个会话作废
+type UserSession struct {
+	ID        uint       `gorm:"primaryKey" json:"-"`
+	UserID    uint       `gorm:"not null;index" json:"-"`
+	FamilyID  string     `gorm:"size:36;not null;index" json:"session_id"` // 对外暴露的会话ID
+	TokenHash string     `gorm:"size:64;not null;unique" json:"-"`         // 刷新令牌的 SHA-256
+	AccessJTI string     `gorm:"size:36;index" json:"-"`                   // 与该刷新令牌一同签发的access token
+	AccessExp time.Time  `gorm:"index" json:"-"`                           // access token过期时间
+	Device    string     `gorm:"size:100" json:"device"`
+	IP        string     `gorm:"size:45" json:"ip"`
+	UserAgent string     `gorm:"size:500" json:"user_agent"`
+	ExpiresAt time.Time  `gorm:"index" json:"expires_at"`
+	RotatedAt *time.Time `json:"-"`                // 已被新令牌替换的时间
+	RevokedAt *time.Time `json:"-"`                // 登出或检测到重用时作废
+	CreatedAt time.Time  `json:"created_at"`       // 本次令牌签发时间，即最近一次活跃时间
+	Current   bool       `gorm:"-" json:"current"` // 是否为发起请求的会话
+}
+
+// TableName 指定表名
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
+
+// RevokedToken 已吊销的access token（按 jti 记录），过期后自动清理
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;size:36" json:"jti"`
+	UserID    uint      `gorm:"index" json:"user_id"`
+	Reason    string    `gorm:"size:50" json:"reason"` // logout, password_changed, disabled, session_revoked
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 指定表名
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// PasswordResetToken 找回密码令牌，只保存哈希。一次性使用，过期或再次申请后失效
+type PasswordResetToken struct {
+	ID        uint       `gorm:"primaryKey" json:"-"`
+	UserID    uint       `gorm:"not null;index" json:"-"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	IP        string     `gorm:"size:45" json:"-"` // 申请找回的IP
+	ExpiresAt time.Time  `gorm:"not null;index" json:"-"`
+	UsedAt    *time.Time `json:"-"`
+	CreatedAt time.Time  `json:"-"`
+}
+
+// TableName 指定表名
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
+
+// LoginThrottle 登录失败计数，Subject 为 "user:<用户名>" 或 "ip:<IP>"。登录成功后删除
+type LoginThrottle struct {
+	Subject      string     `gorm:"primaryKey;size:120" json:"subject"`
+	Failures     int        `gorm:"not null;default:0" json:"failures"`
+	LastFailedAt time.Time  `gorm:"not null" json:"last_failed_at"`
+	LockedUntil  *time.Time `gorm:"index" json:"locked_until,omitempty"`
+}
+
+// TableName 指定表名
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
+
+// 认证审计事件
+const (
+	AuditLoginFailed  = "login_failed"  // 用户名不存在或密码错误
+	AuditLoginBlocked = "login_blocked" // 锁定期间的登录请求被拒绝
+	AuditLockout      = "lockout"       // 失败次数达到阈值，开始锁定
+)
+
+// AuthAuditLog 认证审计日志
+type AuthAuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Event     string    `gorm:"size:30;not null;index" json:"event"`
+	UserID    uint      `gorm:"index" json:"user_id,omitempty"`
+	Username  string    `gorm:"size:50;index" json:"username"`
+	IP        string    `gorm:"size:45;index" json:"ip"`
+	UserAgent string    `gorm:"size:255" json:"user_agent"`
+	Detail    string    `gorm:"size:255" json:"detail"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// TableName 指定表名
+func (AuthAuditLog) TableName() string {
+	return "auth_audit_logs"
+}
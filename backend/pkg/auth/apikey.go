@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// APIKeyPrefix 第三方开发者API密钥的前缀，便于识别和密钥扫描
+const APIKeyPrefix = "pk_"
+
+// API密钥的权限范围
+const (
+	ScopePoetryRead   = "poetry:read"   // 目录、诗词、作者和随机诗词
+	ScopePoetrySearch = "poetry:search" // 全文搜索
+)
+
+// apiKeyScopes 已定义的权限范围
+var apiKeyScopes = []string{ScopePoetryRead, ScopePoetrySearch}
+
+var (
+	ErrInvalidAPIKey       = errors.New("API密钥无效或已吊销")
+	ErrAPIKeyQuotaExceeded = errors.New("API密钥今日调用次数已用完")
+)
+
+// ValidScope 是否为已定义的权限范围
+func ValidScope(scope string) bool {
+	for _, s := range apiKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyScopes 返回全部权限范围
+func APIKeyScopes() []string {
+	return append([]string(nil), apiKeyScopes...)
+}
+
+// GenerateAPIKey 生成新的API密钥，返回明文、用于展示的前缀和用于存储的哈希
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(APIKeyPrefix)+8], HashToken(key), nil
+}
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DenylistStore 吊销记录的持久化存储
+type DenylistStore interface {
+	// Add 记录被吊销的 jti
+	Add(ctx context.Context, jti string, userID uint, reason string, expiresAt time.Time) error
+	// IsRevoked 查询 jti 是否已被吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// DeleteExpired 删除 before 之前已过期的记录
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// negativeTTL "未吊销"结果的缓存时间，限制其他进程（如 manage 命令）吊销后的生效延迟
+const negativeTTL = time.Minute
+
+// Denylist access token吊销名单。查询结果缓存在内存LRU中，吊销操作同时写入缓存和存储；
+// "未吊销"的结果只缓存 negativeTTL，以便感知其他进程写入的吊销记录
+type Denylist struct {
+	store    DenylistStore
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // 最近使用的在前
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type denylistEntry struct {
+	jti       string
+	revoked   bool
+	expiresAt time.Time
+}
+
+// NewDenylist 创建吊销名单，capacity 为内存缓存的最大条目数
+func NewDenylist(store DenylistStore, capacity int) *Denylist {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &Denylist{
+		store:    store,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Revoke 吊销 jti，expiresAt 为token本身的过期时间，之后记录即可清理
+func (d *Denylist) Revoke(ctx context.Context, jti string, userID uint, reason string, expiresAt time.Time) error {
+	if jti == "" || !expiresAt.After(time.Now()) {
+		return nil
+	}
+	if err := d.store.Add(ctx, jti, userID, reason, expiresAt); err != nil {
+		return err
+	}
+	d.put(jti, true, expiresAt)
+	return nil
+}
+
+// IsRevoked 检查 jti 是否已被吊销；存储出错时按已吊销处理
+func (d *Denylist) IsRevoked(ctx context.Context, jti string, expiresAt time.Time) bool {
+	if jti == "" {
+		return false
+	}
+	if revoked, ok := d.get(jti); ok {
+		d.hits.Add(1)
+		return revoked
+	}
+	d.misses.Add(1)
+
+	revoked, err := d.store.IsRevoked(ctx, jti)
+	if err != nil {
+		slog.ErrorContext(ctx, "denylist lookup failed", "error", err)
+		return true
+	}
+	if !revoked {
+		if limit := time.Now().Add(negativeTTL); expiresAt.After(limit) {
+			expiresAt = limit
+		}
+	}
+	d.put(jti, revoked, expiresAt)
+	return revoked
+}
+
+// CacheStats 内存缓存的累计命中和未命中次数
+func (d *Denylist) CacheStats() (hits, misses uint64) {
+	return d.hits.Load(), d.misses.Load()
+}
+
+// Prune 清理已过期的吊销记录和缓存条目
+func (d *Denylist) Prune(ctx context.Context) (int64, error) {
+	now := time.Now()
+
+	d.mu.Lock()
+	for e := d.order.Front(); e != nil; {
+		next := e.Next()
+		if entry := e.Value.(*denylistEntry); !entry.expiresAt.After(now) {
+			d.order.Remove(e)
+			delete(d.entries, entry.jti)
+		}
+		e = next
+	}
+	d.mu.Unlock()
+
+	return d.store.DeleteExpired(ctx, now)
+}
+
+// StartPruner 每隔 interval 清理一次过期记录，直到 ctx 结束
+func (d *Denylist) StartPruner(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := d.Prune(ctx); err != nil && ctx.Err() == nil {
+					slog.ErrorContext(ctx, "denylist prune failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+func (d *Denylist) get(jti string) (bool, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.entries[jti]
+	if !ok {
+		return false, false
+	}
+	entry := e.Value.(*denylistEntry)
+	if !entry.expiresAt.After(time.Now()) {
+		d.order.Remove(e)
+		delete(d.entries, jti)
+		return false, false
+	}
+	d.order.MoveToFront(e)
+	return entry.revoked, true
+}
+
+func (d *Denylist) put(jti string, revoked bool, expiresAt time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if e, ok := d.entries[jti]; ok {
+		entry := e.Value.(*denylistEntry)
+		entry.revoked = revoked
+		entry.expiresAt = expiresAt
+		d.order.MoveToFront(e)
+		return
+	}
+
+	d.entries[jti] = d.order.PushFront(&denylistEntry{jti: jti, revoked: revoked, expiresAt: expiresAt})
+	for d.order.Len() > d.capacity {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*denylistEntry).jti)
+	}
+}
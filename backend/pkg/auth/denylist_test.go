@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryDenylistStore 内存中的吊销记录，err 不为空时所有操作失败
+type memoryDenylistStore struct {
+	mu      sync.Mutex
+	revoked map[string]time.Time
+	lookups int
+	err     error
+}
+
+func newMemoryDenylistStore() *memoryDenylistStore {
+	return &memoryDenylistStore{revoked: make(map[string]time.Time)}
+}
+
+func (s *memoryDenylistStore) Add(ctx context.Context, jti string, userID uint, reason string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *memoryDenylistStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	if s.err != nil {
+		return false, s.err
+	}
+	_, ok := s.revoked[jti]
+	return ok, nil
+}
+
+func (s *memoryDenylistStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	var n int64
+	for jti, expiresAt := range s.revoked {
+		if expiresAt.Before(before) {
+			delete(s.revoked, jti)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestDenylistRevoke(t *testing.T) {
+	store := newMemoryDenylistStore()
+	d := NewDenylist(store, 10)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	if d.IsRevoked(ctx, "a", expiresAt) {
+		t.Fatal("unknown jti reported as revoked")
+	}
+	if err := d.Revoke(ctx, "a", 1, "logout", expiresAt); err != nil {
+		t.Fatal(err)
+	}
+	// 吊销同时更新缓存中"未吊销"的结果
+	if !d.IsRevoked(ctx, "a", expiresAt) {
+		t.Fatal("revoked jti accepted")
+	}
+	if store.lookups != 1 {
+		t.Fatalf("%d store lookups, want 1", store.lookups)
+	}
+
+	// 其他进程写入的吊销记录在新的 Denylist 中可见
+	other := NewDenylist(store, 10)
+	if !other.IsRevoked(ctx, "a", expiresAt) {
+		t.Fatal("revocation by another process not seen")
+	}
+}
+
+func TestDenylistFailsClosed(t *testing.T) {
+	store := newMemoryDenylistStore()
+	d := NewDenylist(store, 10)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	store.err = errors.New("database is locked")
+	if !d.IsRevoked(ctx, "a", expiresAt) {
+		t.Fatal("store error treated as not revoked")
+	}
+	if err := d.Revoke(ctx, "b", 1, "logout", expiresAt); err == nil {
+		t.Fatal("revoke succeeded while the store fails")
+	}
+
+	// 出错的结果不缓存，存储恢复后正常放行
+	store.err = nil
+	if d.IsRevoked(ctx, "a", expiresAt) {
+		t.Fatal("jti still rejected after the store recovered")
+	}
+}
@@ -2,35 +2,146 @@ package auth
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-// JWTManager JWT管理器
+// Config JWT签名配置
+type Config struct {
+	Algorithm       string            // HS256、RS256 或 EdDSA，决定使用哪类密钥签名
+	Secret          string            // HS256 当前密钥，仅在 Algorithm 为 HS256 时使用
+	SecretID        string            // HS256 当前密钥的 kid
+	PreviousSecrets map[string]string // 轮换下来、只用于验证的 HS256 密钥（kid -> secret）；从 HS256 切换到非对称算法时旧密钥放在这里
+	KeysDir         string            // RS256/EdDSA 的 PEM 密钥目录，公钥文件只用于验证
+	ActiveKeyID     string            // 用于签名的 kid，为空时取目录中 kid 排序最大的私钥
+	TokenDuration   time.Duration
+	Issuer          string
+}
+
+// legacyKeyID 未配置 kid 时 HS256 密钥的 kid
+const legacyKeyID = "default"
+
+// defaultTokenDuration 未配置有效期时 access token 的有效期，与配置默认值一致；
+// 过期后用刷新令牌续期，不宜过长
+const defaultTokenDuration = 15 * time.Minute
+
+// JWTManager JWT管理器。签名只用当前密钥，验证时按token头部的 kid 选择密钥，
+// 因此轮换密钥后旧token在过期前仍然有效
 type JWTManager struct {
-	secretKey     string
+	signingKey    *Key
+	keys          map[string]*Key
 	tokenDuration time.Duration
+	issuer        string
 }
 
 // Claims JWT声明
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid,omitempty"` // 签发该token的登录会话
 	jwt.RegisteredClaims
 }
 
-// NewJWTManager 创建JWT管理器
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
-	return &JWTManager{
-		secretKey:     secretKey,
-		tokenDuration: tokenDuration,
+// NewJWTManager 根据配置加载密钥并创建JWT管理器
+func NewJWTManager(cfg Config) (*JWTManager, error) {
+	m := &JWTManager{
+		keys:          make(map[string]*Key),
+		tokenDuration: cfg.TokenDuration,
+		issuer:        cfg.Issuer,
+	}
+	if m.tokenDuration <= 0 {
+		m.tokenDuration = defaultTokenDuration
+	}
+
+	secretID := cfg.SecretID
+	if secretID == "" {
+		secretID = legacyKeyID
+	}
+	// 使用非对称算法时不注册 Secret，否则任何知道该密钥的人都能伪造 HS256 token；
+	// 切换算法期间仍需验证的旧 HS256 token 由 PreviousSecrets 显式提供
+	symmetric := cfg.Algorithm == "" || cfg.Algorithm == AlgHS256
+	if symmetric && cfg.Secret != "" {
+		m.addKey(NewHMACKey(secretID, cfg.Secret))
+	}
+	for id, secret := range cfg.PreviousSecrets {
+		if _, exists := m.keys[id]; !exists {
+			m.addKey(NewHMACKey(id, secret))
+		}
 	}
+
+	var dirKeys []*Key
+	if cfg.KeysDir != "" {
+		var err error
+		if dirKeys, err = LoadKeyDir(cfg.KeysDir); err != nil {
+			return nil, err
+		}
+		for _, key := range dirKeys {
+			m.addKey(key)
+		}
+	}
+
+	switch cfg.Algorithm {
+	case "", AlgHS256:
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("%s requires a secret: %w", AlgHS256, errNoSigningKey)
+		}
+		m.signingKey = m.keys[secretID]
+	case AlgRS256, AlgEdDSA:
+		if cfg.ActiveKeyID != "" {
+			m.signingKey = m.keys[cfg.ActiveKeyID]
+		} else {
+			for _, key := range dirKeys {
+				if key.Algorithm == cfg.Algorithm && key.CanSign() {
+					m.signingKey = key
+				}
+			}
+		}
+		if m.signingKey == nil || !m.signingKey.CanSign() {
+			return nil, fmt.Errorf("%s key %q: %w", cfg.Algorithm, cfg.ActiveKeyID, errNoSigningKey)
+		}
+		if m.signingKey.Algorithm != cfg.Algorithm {
+			return nil, fmt.Errorf("key %s is %s, not %s", m.signingKey.ID, m.signingKey.Algorithm, cfg.Algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", cfg.Algorithm)
+	}
+
+	return m, nil
+}
+
+func (m *JWTManager) addKey(key *Key) {
+	m.keys[key.ID] = key
+}
+
+// TokenDuration 返回access token有效期
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
+}
+
+// SigningKeyID 返回当前签名密钥的 kid
+func (m *JWTManager) SigningKeyID() string {
+	return m.signingKey.ID
+}
+
+// JWKS 返回所有非对称验证密钥的公钥，HMAC密钥不会出现在其中
+func (m *JWTManager) JWKS() []JWK {
+	jwks := make([]JWK, 0, len(m.keys))
+	for _, key := range m.keys {
+		if jwk, ok := key.JWK(); ok {
+			jwks = append(jwks, jwk)
+		}
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].KeyID < jwks[j].KeyID })
+	return jwks
 }
 
-// GenerateToken 生成JWT token
-func (m *JWTManager) GenerateToken(userID uint, username string) (string, error) {
+// GenerateToken 生成JWT token，同时返回其声明以便记录 jti 和过期时间
+func (m *JWTManager) GenerateToken(userID uint, username, role, sessionID string) (string, *Claims, error) {
 	// 生成唯一的JTI
 	jti := uuid.New().String()
 
@@ -38,34 +149,37 @@ func (m *JWTManager) GenerateToken(userID uint, username string) (string, error)
 	expiresAt := now.Add(m.tokenDuration)
 
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        jti,
+			Issuer:    m.issuer,
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(m.secretKey))
+	token := jwt.NewWithClaims(m.signingKey.method(), claims)
+	token.Header["kid"] = m.signingKey.ID
+	tokenString, err := token.SignedString(m.signingKey.signKey)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
 // ValidateToken 验证JWT token
 func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(m.secretKey), nil
-	})
+	opts := []jwt.ParserOption{jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA})}
+	if m.issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.issuer))
+	}
 
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, m.keyFunc, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -78,18 +192,18 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-// RefreshToken 刷新token
-func (m *JWTManager) RefreshToken(tokenString string) (string, error) {
-	claims, err := m.ValidateToken(tokenString)
-	if err != nil {
-		return "", err
+// keyFunc 按 kid 选择验证密钥，没有 kid 时只接受当前签名密钥，
+// 并要求token声明的算法与密钥一致，防止算法混淆
+func (m *JWTManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	key := m.signingKey
+	if kid, _ := token.Header["kid"].(string); kid != "" {
+		key = m.keys[kid]
 	}
-
-	// 检查token是否即将过期（30分钟内）
-	if time.Until(claims.ExpiresAt.Time) > 30*time.Minute {
-		return "", errors.New("token is still valid")
+	if key == nil {
+		return nil, errors.New("unknown key id")
 	}
-
-	// 生成新token
-	return m.GenerateToken(claims.UserID, claims.Username)
+	if token.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
 }
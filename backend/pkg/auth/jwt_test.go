@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// publicDevSecret 与 config 中的开发密钥相同，任何人都能拿到
+const publicDevSecret = "your-secret-key-change-in-production"
+
+func newEdDSAManager(t *testing.T, cfg Config) *JWTManager {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalPrivateKeyPEM(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "k1.pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Algorithm = AlgEdDSA
+	cfg.KeysDir = dir
+	cfg.TokenDuration = time.Minute
+	m, err := NewJWTManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// forgeHS256 用给定的 HMAC 密钥签一个管理员token，kid 为空时不写入头部
+func forgeHS256(t *testing.T, secret, kid string) string {
+	t.Helper()
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		UserID:   1,
+		Username: "attacker",
+		Role:     "admin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestAsymmetricManagerRejectsHS256(t *testing.T) {
+	// 非对称算法下即使配置里残留了 secret，也不能用它伪造token
+	m := newEdDSAManager(t, Config{Secret: publicDevSecret})
+
+	tests := []struct {
+		name string
+		kid  string
+	}{
+		{"no kid", ""},
+		{"legacy kid", legacyKeyID},
+		{"signing kid", "k1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.ValidateToken(forgeHS256(t, publicDevSecret, tt.kid)); err == nil {
+				t.Fatal("forged HS256 token was accepted")
+			}
+		})
+	}
+
+	token, _, err := m.GenerateToken(2, "alice", "user", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.ValidateToken(token); err != nil {
+		t.Fatalf("token signed by the manager was rejected: %v", err)
+	}
+}
+
+func TestAsymmetricManagerPreviousSecrets(t *testing.T) {
+	const old = "previous-hs256-secret-for-migration"
+	m := newEdDSAManager(t, Config{PreviousSecrets: map[string]string{"old": old}})
+
+	if _, err := m.ValidateToken(forgeHS256(t, old, "old")); err != nil {
+		t.Fatalf("token signed with an explicit previous secret was rejected: %v", err)
+	}
+	if _, err := m.ValidateToken(forgeHS256(t, old, "")); err == nil {
+		t.Fatal("HS256 token without kid was accepted by an EdDSA manager")
+	}
+	if _, err := m.ValidateToken(forgeHS256(t, old, "k1")); err == nil {
+		t.Fatal("HS256 token claiming an EdDSA kid was accepted")
+	}
+}
+
+func TestHS256Manager(t *testing.T) {
+	const secret = "current-hs256-secret-at-least-32-chars"
+	m, err := NewJWTManager(Config{Algorithm: AlgHS256, Secret: secret, TokenDuration: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.ValidateToken(forgeHS256(t, secret, "")); err != nil {
+		t.Fatalf("token without kid signed with the current secret was rejected: %v", err)
+	}
+	if _, err := m.ValidateToken(forgeHS256(t, publicDevSecret, "")); err == nil {
+		t.Fatal("token signed with another secret was accepted")
+	}
+	if _, err := m.ValidateToken(forgeHS256(t, secret, "unknown")); err == nil {
+		t.Fatal("token with an unknown kid was accepted")
+	}
+}
+
+func TestDefaultTokenDuration(t *testing.T) {
+	m, err := NewJWTManager(Config{Algorithm: AlgHS256, Secret: "current-hs256-secret-at-least-32-chars"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.TokenDuration(); got != 15*time.Minute {
+		t.Fatalf("default token duration = %v, want 15m", got)
+	}
+}
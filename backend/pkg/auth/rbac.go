@@ -0,0 +1,56 @@
+package auth
+
+// 用户角色
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// 权限
+const (
+	PermEditWorks         = "works:edit"
+	PermEditAuthors       = "authors:edit"
+	PermModerateComments  = "comments:moderate"
+	PermReviewCorrections = "corrections:review"
+	PermManageUsers       = "users:manage"
+)
+
+// rolePermissions 各角色拥有的权限，admin 拥有全部权限
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleEditor: {
+		PermEditWorks,
+		PermEditAuthors,
+		PermModerateComments,
+		PermReviewCorrections,
+	},
+	RoleAdmin: {
+		PermEditWorks,
+		PermEditAuthors,
+		PermModerateComments,
+		PermReviewCorrections,
+		PermManageUsers,
+	},
+}
+
+// ValidRole 是否为已定义的角色
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission 角色是否拥有指定权限
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions 返回角色拥有的权限列表
+func Permissions(role string) []string {
+	return append([]string{}, rolePermissions[role]...)
+}
@@ -0,0 +1,127 @@
+package biography
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Profile 从作者简介中提取出的结构化信息，字段缺失表示未能识别
+type Profile struct {
+	BirthYear    *int // 公元前为负数
+	BirthApprox  bool // 约、?、？ 等不确定标记
+	DeathYear    *int
+	DeathApprox  bool
+	CourtesyName string   // 字
+	ArtName      string   // 号
+	NativePlace  string   // 籍贯
+	EraNames     []string // 简介中出现的年号
+}
+
+var (
+	// 李白（701年－762年）、屈原（约公元前340年—公元前278年）、苏轼(1037-1101?)
+	lifespanRe = regexp.MustCompile(`[（(]\s*(约|約)?\s*(公元)?(前)?\s*(\d{1,4})\s*年?\s*([?？])?\s*[-－—–~～至]\s*(约|約)?\s*(公元)?(前)?\s*(\d{1,4})\s*年?\s*([?？])?\s*[)）]`)
+	// 生于701年 / 卒于762年
+	bornRe = regexp.MustCompile(`生于(约|約)?(公元)?(前)?(\d{1,4})年`)
+	diedRe = regexp.MustCompile(`(?:卒|死|逝世)于(约|約)?(公元)?(前)?(\d{1,4})年`)
+
+	courtesyRe = regexp.MustCompile(`(?:^|[，,。；;\s])字\s*[“"]?(\p{Han}{1,4})`)
+	artNameRe  = regexp.MustCompile(`(?:^|[，,。；;\s])(?:自号|号|號)\s*[“"]?(\p{Han}{1,6})`)
+	nativeRe   = regexp.MustCompile(`(?:祖籍|籍贯|籍貫)\s*[:：]?\s*(\p{Han}{2,10})`)
+	placeRe    = regexp.MustCompile(`(?:^|[，,。；;])\s*(\p{Han}{2,8}(?:[（(][^）)]{1,20}[）)])?)人[，,。；;]`)
+	eraRe      = regexp.MustCompile(`(\p{Han}{2})(?:元|[一二三四五六七八九十]{1,3})年`)
+)
+
+// 以这些字结尾的"X人"多半是身份描述而不是地名，例如"唐代诗人"
+var nonPlaceSuffixes = []string{"诗", "詩", "词", "詞", "文", "曲", "家", "名", "后", "後", "夫", "主", "世", "代", "朝", "国", "國", "之", "的", "等"}
+
+// 年号前两个字落在这些字上通常是"晚年""少年"之类的普通词
+var nonEraChars = "一二三四五六七八九十百千少晚早中末同去今明当當其是此某数數多每后後前"
+
+// Parse 从简介文本中尽力提取生卒年、字、号、籍贯和年号，无法识别的字段保持零值
+func Parse(text string) Profile {
+	var p Profile
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return p
+	}
+
+	if m := lifespanRe.FindStringSubmatch(text); m != nil {
+		p.BirthYear = parseYear(m[3], m[4])
+		p.BirthApprox = m[1] != "" || m[5] != ""
+		p.DeathYear = parseYear(m[8], m[9])
+		p.DeathApprox = m[6] != "" || m[10] != ""
+	} else {
+		if m := bornRe.FindStringSubmatch(text); m != nil {
+			p.BirthYear = parseYear(m[3], m[4])
+			p.BirthApprox = m[1] != ""
+		}
+		if m := diedRe.FindStringSubmatch(text); m != nil {
+			p.DeathYear = parseYear(m[3], m[4])
+			p.DeathApprox = m[1] != ""
+		}
+	}
+
+	if m := courtesyRe.FindStringSubmatch(text); m != nil {
+		p.CourtesyName = m[1]
+	}
+	if m := artNameRe.FindStringSubmatch(text); m != nil {
+		p.ArtName = m[1]
+	}
+
+	if m := nativeRe.FindStringSubmatch(text); m != nil {
+		p.NativePlace = m[1]
+	} else {
+		for _, m := range placeRe.FindAllStringSubmatch(text, -1) {
+			if looksLikePlace(m[1]) {
+				p.NativePlace = m[1]
+				break
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, m := range eraRe.FindAllStringSubmatch(text, -1) {
+		era := m[1]
+		if seen[era] || !looksLikeEra(era) {
+			continue
+		}
+		seen[era] = true
+		p.EraNames = append(p.EraNames, era)
+	}
+
+	return p
+}
+
+func parseYear(bc, digits string) *int {
+	year, err := strconv.Atoi(digits)
+	if err != nil || year == 0 {
+		return nil
+	}
+	if bc != "" {
+		year = -year
+	}
+	return &year
+}
+
+func looksLikePlace(s string) bool {
+	name := s
+	if i := strings.IndexAny(name, "（("); i >= 0 {
+		name = name[:i]
+	}
+	for _, suffix := range nonPlaceSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+func looksLikeEra(s string) bool {
+	for _, r := range s {
+		if strings.ContainsRune(nonEraChars, r) {
+			return false
+		}
+	}
+	return true
+}
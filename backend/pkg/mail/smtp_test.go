@@ -0,0 +1,184 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP 只实现发送一封邮件所需命令的 SMTP 服务器，记录收到的命令和 DATA 内容
+type fakeSMTP struct {
+	ln       net.Listener
+	commands []string
+	data     string
+	done     chan struct{}
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeSMTP) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve() {
+	defer close(s.done)
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.commands = append(s.commands, line)
+
+		switch verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); verb {
+		case "EHLO":
+			// 不声明 STARTTLS 和 AUTH，客户端应直接发送
+			reply("250-fake")
+			reply("250 8BITMIME")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	server := startFakeSMTP(t)
+	mailer := SMTPMailer{
+		Host:    "127.0.0.1",
+		Port:    server.port(),
+		From:    "noreply@example.com",
+		Timeout: 5 * time.Second,
+	}
+
+	body := "你好：\n\n请打开以下链接重置密码：\nhttps://example.com/reset?token=" + strings.Repeat("x", 80) + "\n"
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "重置密码", Body: body})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	<-server.done
+
+	want := []string{"MAIL FROM:<noreply@example.com>", "RCPT TO:<alice@example.com>", "DATA", "QUIT"}
+	var got []string
+	for _, cmd := range server.commands {
+		if !strings.HasPrefix(cmd, "EHLO") && !strings.HasPrefix(cmd, "HELO") {
+			// net/smtp 可能在 MAIL FROM 后附加 BODY=8BITMIME
+			got = append(got, strings.TrimSuffix(cmd, " BODY=8BITMIME"))
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+
+	header, encoded, ok := strings.Cut(server.data, "\r\n\r\n")
+	if !ok {
+		t.Fatalf("message has no header/body separator: %q", server.data)
+	}
+	for _, h := range []string{
+		"From: noreply@example.com",
+		"To: alice@example.com",
+		"Subject: =?utf-8?q?",
+		"Content-Transfer-Encoding: base64",
+	} {
+		if !strings.Contains(header, h) {
+			t.Errorf("header missing %q:\n%s", h, header)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(encoded, "\r\n"), "\r\n")
+	for i, line := range lines {
+		if len(line) > 76 {
+			t.Errorf("body line %d is %d characters, want at most 76", i, len(line))
+		}
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(lines, ""))
+	if err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if string(decoded) != body {
+		t.Fatalf("body = %q, want %q", decoded, body)
+	}
+}
+
+func TestSMTPMailerRejectedRecipient(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		conn.Write([]byte("220 fake ESMTP\r\n"))
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				conn.Write([]byte("250 fake\r\n"))
+			case strings.HasPrefix(line, "RCPT"):
+				conn.Write([]byte("550 no such user\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				conn.Write([]byte("221 bye\r\n"))
+				return
+			default:
+				conn.Write([]byte("250 OK\r\n"))
+			}
+		}
+	}()
+
+	mailer := SMTPMailer{Host: "127.0.0.1", Port: ln.Addr().(*net.TCPAddr).Port, From: "noreply@example.com", Timeout: 5 * time.Second}
+	err = mailer.Send(context.Background(), Message{To: "nobody@example.com", Subject: "s", Body: "b"})
+	if err == nil || !strings.Contains(err.Error(), "550") {
+		t.Fatalf("Send error = %v, want the 550 reply", err)
+	}
+}
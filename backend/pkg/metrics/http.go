@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// ContentType Prometheus 文本格式
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler 输出注册表中的全部指标。token 非空时要求请求携带 "Authorization: Bearer <token>"
+func (r *Registry) Handler(token string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token != "" {
+			got := req.Header.Get("Authorization")
+			if subtle.ConstantTimeCompare([]byte(got), []byte("Bearer "+token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		io.WriteString(w, r.Text())
+	})
+}
+
+// RegisterRuntime 注册进程级指标：goroutine 数、堆内存和启动时间
+func (r *Registry) RegisterRuntime() {
+	start := float64(time.Now().Unix())
+	r.NewGaugeFunc("go_goroutines", "Number of goroutines that currently exist.", nil, func() []Sample {
+		return []Sample{{Value: float64(runtime.NumGoroutine())}}
+	})
+	r.NewGaugeFunc("go_memstats_heap_alloc_bytes", "Number of heap bytes allocated and still in use.", nil, func() []Sample {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+		return []Sample{{Value: float64(m.HeapAlloc)}}
+	})
+	r.NewGaugeFunc("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", nil, func() []Sample {
+		return []Sample{{Value: start}}
+	})
+}
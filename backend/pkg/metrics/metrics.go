@@ -0,0 +1,397 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets 默认的耗时分桶（秒）
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Sample 采集函数返回的一个样本，LabelValues 与注册时的标签一一对应
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// collector 一个指标族，负责按 Prometheus 文本格式输出自身
+type collector interface {
+	describe() *desc
+	write(b *strings.Builder)
+}
+
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) describe() *desc { return d }
+
+// Registry 指标注册表。各组件在启动时注册指标，/metrics 请求时统一输出
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建空的注册表
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	name := c.describe().name
+	if _, ok := r.collectors[name]; ok {
+		panic(fmt.Sprintf("metrics: %s 重复注册", name))
+	}
+	r.collectors[name] = c
+}
+
+// NewCounter 注册只增不减的计数器
+func (r *Registry) NewCounter(name, help string, labels ...string) *CounterVec {
+	v := &CounterVec{desc: desc{name: name, help: help, typ: "counter", labels: labels}}
+	r.register(v)
+	return v
+}
+
+// NewGauge 注册可增可减的仪表
+func (r *Registry) NewGauge(name, help string, labels ...string) *GaugeVec {
+	v := &GaugeVec{desc: desc{name: name, help: help, typ: "gauge", labels: labels}}
+	r.register(v)
+	return v
+}
+
+// NewHistogram 注册直方图，buckets 为升序的上界，为空时使用 DefBuckets
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	if !sort.Float64sAreSorted(buckets) {
+		panic(fmt.Sprintf("metrics: %s 的分桶必须升序", name))
+	}
+	v := &HistogramVec{desc: desc{name: name, help: help, typ: "histogram", labels: labels}, buckets: buckets}
+	r.register(v)
+	return v
+}
+
+// NewCounterFunc 注册采集时才取值的计数器，用于已经自行计数的组件
+func (r *Registry) NewCounterFunc(name, help string, labels []string, fn func() []Sample) {
+	r.register(&funcCollector{desc: desc{name: name, help: help, typ: "counter", labels: labels}, fn: fn})
+}
+
+// NewGaugeFunc 注册采集时才取值的仪表，如连接数、数据量
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, fn func() []Sample) {
+	r.register(&funcCollector{desc: desc{name: name, help: help, typ: "gauge", labels: labels}, fn: fn})
+}
+
+// Text 按 Prometheus 文本格式（0.0.4）输出全部指标，指标和序列均按名称排序，输出稳定
+func (r *Registry) Text() string {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, c := range collectors {
+		d := c.describe()
+		fmt.Fprintf(&b, "# HELP %s %s\n", d.name, escapeHelp(d.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", d.name, d.typ)
+		c.write(&b)
+	}
+	return b.String()
+}
+
+// key 标签值组合对应的序列键，标签值个数不符时 panic
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s 需要 %d 个标签值，实际 %d 个", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// sortedKeys 按标签值排序的序列键
+func sortedKeys[T any](series map[string]*T) []string {
+	keys := make([]string, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec 计数器
+type CounterVec struct {
+	desc
+	mu      sync.Mutex
+	series  map[string]*Counter
+	lvalues map[string][]string
+}
+
+// Counter 一条计数器序列
+type Counter struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// With 按标签值取得序列，不存在时创建
+func (v *CounterVec) With(labelValues ...string) *Counter {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.series == nil {
+		v.series = make(map[string]*Counter)
+		v.lvalues = make(map[string][]string)
+	}
+	c, ok := v.series[key]
+	if !ok {
+		c = &Counter{}
+		v.series[key] = c
+		v.lvalues[key] = append([]string(nil), labelValues...)
+	}
+	return c
+}
+
+// Inc 加一
+func (c *Counter) Inc() { c.Add(1) }
+
+// Add 增加 delta，负数会被忽略
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mu.Lock()
+	c.value += delta
+	c.mu.Unlock()
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+func (v *CounterVec) write(b *strings.Builder) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range sortedKeys(v.series) {
+		writeSample(b, v.name, v.labels, v.lvalues[key], "", "", v.series[key].Value())
+	}
+}
+
+// GaugeVec 仪表
+type GaugeVec struct {
+	desc
+	mu      sync.Mutex
+	series  map[string]*Gauge
+	lvalues map[string][]string
+}
+
+// Gauge 一条仪表序列
+type Gauge struct {
+	mu    sync.Mutex
+	value float64
+}
+
+// With 按标签值取得序列，不存在时创建
+func (v *GaugeVec) With(labelValues ...string) *Gauge {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.series == nil {
+		v.series = make(map[string]*Gauge)
+		v.lvalues = make(map[string][]string)
+	}
+	g, ok := v.series[key]
+	if !ok {
+		g = &Gauge{}
+		v.series[key] = g
+		v.lvalues[key] = append([]string(nil), labelValues...)
+	}
+	return g
+}
+
+// Set 设置当前值
+func (g *Gauge) Set(value float64) {
+	g.mu.Lock()
+	g.value = value
+	g.mu.Unlock()
+}
+
+// Add 增加 delta，可为负数
+func (g *Gauge) Add(delta float64) {
+	g.mu.Lock()
+	g.value += delta
+	g.mu.Unlock()
+}
+
+// Inc 加一
+func (g *Gauge) Inc() { g.Add(1) }
+
+// Dec 减一
+func (g *Gauge) Dec() { g.Add(-1) }
+
+// Value 当前值
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.value
+}
+
+func (v *GaugeVec) write(b *strings.Builder) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range sortedKeys(v.series) {
+		writeSample(b, v.name, v.labels, v.lvalues[key], "", "", v.series[key].Value())
+	}
+}
+
+// HistogramVec 直方图
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*Histogram
+	lvalues map[string][]string
+}
+
+// Histogram 一条直方图序列，counts[i] 为落入第 i 个桶（不累计）的观测数
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+// With 按标签值取得序列，不存在时创建
+func (v *HistogramVec) With(labelValues ...string) *Histogram {
+	key := v.key(labelValues)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.series == nil {
+		v.series = make(map[string]*Histogram)
+		v.lvalues = make(map[string][]string)
+	}
+	h, ok := v.series[key]
+	if !ok {
+		h = &Histogram{buckets: v.buckets, counts: make([]uint64, len(v.buckets))}
+		v.series[key] = h
+		v.lvalues[key] = append([]string(nil), labelValues...)
+	}
+	return h
+}
+
+// Observe 记录一次观测，耗时类指标以秒为单位
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += value
+}
+
+// Count 观测总数
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (v *HistogramVec) write(b *strings.Builder) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, key := range sortedKeys(v.series) {
+		h := v.series[key]
+		values := v.lvalues[key]
+
+		h.mu.Lock()
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += h.counts[i]
+			writeSample(b, v.name+"_bucket", v.labels, values, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(b, v.name+"_bucket", v.labels, values, "le", "+Inf", float64(h.count))
+		writeSample(b, v.name+"_sum", v.labels, values, "", "", h.sum)
+		writeSample(b, v.name+"_count", v.labels, values, "", "", float64(h.count))
+		h.mu.Unlock()
+	}
+}
+
+// funcCollector 采集时调用 fn 取值
+type funcCollector struct {
+	desc
+	fn func() []Sample
+}
+
+func (f *funcCollector) write(b *strings.Builder) {
+	samples := f.fn()
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].LabelValues, "\xff") < strings.Join(samples[j].LabelValues, "\xff")
+	})
+	for _, s := range samples {
+		f.key(s.LabelValues)
+		writeSample(b, f.name, f.labels, s.LabelValues, "", "", s.Value)
+	}
+}
+
+// writeSample 输出一行样本，extraName 非空时追加一个标签（直方图的 le）
+func writeSample(b *strings.Builder, name string, labels, values []string, extraName, extraValue string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		b.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, `%s="%s"`, label, escapeLabel(values[i]))
+		}
+		if extraName != "" {
+			if len(labels) > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, `%s="%s"`, extraName, extraValue)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpReplacer.Replace(s) }
+func escapeLabel(s string) string { return labelReplacer.Replace(s) }
@@ -0,0 +1,193 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryText(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(r *Registry)
+		want  string
+	}{
+		{
+			name: "counter without labels",
+			setup: func(r *Registry) {
+				r.NewCounter("jobs_total", "Total jobs.").With().Add(3)
+			},
+			want: `# HELP jobs_total Total jobs.
+# TYPE jobs_total counter
+jobs_total 3
+`,
+		},
+		{
+			name: "negative counter delta is ignored",
+			setup: func(r *Registry) {
+				c := r.NewCounter("jobs_total", "Total jobs.").With()
+				c.Inc()
+				c.Add(-5)
+			},
+			want: `# HELP jobs_total Total jobs.
+# TYPE jobs_total counter
+jobs_total 1
+`,
+		},
+		{
+			name: "series sorted by label values",
+			setup: func(r *Registry) {
+				v := r.NewCounter("http_requests_total", "Requests.", "method", "route")
+				v.With("POST", "/b").Inc()
+				v.With("GET", "/b").Add(2)
+				v.With("GET", "/a").Inc()
+			},
+			want: `# HELP http_requests_total Requests.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="/a"} 1
+http_requests_total{method="GET",route="/b"} 2
+http_requests_total{method="POST",route="/b"} 1
+`,
+		},
+		{
+			name: "help and label escaping",
+			setup: func(r *Registry) {
+				r.NewGauge("odd", "Line one\nback\\slash \"quoted\"", "v").With("a\"b\\c\nd").Set(1.5)
+			},
+			want: `# HELP odd Line one\nback\\slash "quoted"
+# TYPE odd gauge
+odd{v="a\"b\\c\nd"} 1.5
+`,
+		},
+		{
+			name: "gauge add and dec",
+			setup: func(r *Registry) {
+				g := r.NewGauge("in_flight", "In flight.").With()
+				g.Inc()
+				g.Inc()
+				g.Dec()
+				g.Add(-0.5)
+			},
+			want: `# HELP in_flight In flight.
+# TYPE in_flight gauge
+in_flight 0.5
+`,
+		},
+		{
+			name: "histogram buckets are cumulative",
+			setup: func(r *Registry) {
+				h := r.NewHistogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route").With("/a")
+				h.Observe(0.05)
+				h.Observe(0.1) // 等于上界的观测计入该桶
+				h.Observe(0.5)
+				h.Observe(3)
+			},
+			want: `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 2
+latency_seconds_bucket{route="/a",le="1"} 3
+latency_seconds_bucket{route="/a",le="+Inf"} 4
+latency_seconds_sum{route="/a"} 3.65
+latency_seconds_count{route="/a"} 4
+`,
+		},
+		{
+			name: "histogram without labels",
+			setup: func(r *Registry) {
+				r.NewHistogram("wait_seconds", "Wait.", []float64{1}).With().Observe(2)
+			},
+			want: `# HELP wait_seconds Wait.
+# TYPE wait_seconds histogram
+wait_seconds_bucket{le="1"} 0
+wait_seconds_bucket{le="+Inf"} 1
+wait_seconds_sum 2
+wait_seconds_count 1
+`,
+		},
+		{
+			name: "func collector samples sorted",
+			setup: func(r *Registry) {
+				r.NewGaugeFunc("rows", "Rows.", []string{"table"}, func() []Sample {
+					return []Sample{{LabelValues: []string{"works"}, Value: 10}, {LabelValues: []string{"authors"}, Value: 2}}
+				})
+			},
+			want: `# HELP rows Rows.
+# TYPE rows gauge
+rows{table="authors"} 2
+rows{table="works"} 10
+`,
+		},
+		{
+			name: "families sorted by name",
+			setup: func(r *Registry) {
+				r.NewCounter("b_total", "B.").With().Inc()
+				r.NewCounter("a_total", "A.").With().Inc()
+			},
+			want: `# HELP a_total A.
+# TYPE a_total counter
+a_total 1
+# HELP b_total B.
+# TYPE b_total counter
+b_total 1
+`,
+		},
+		{
+			name: "family without series only has metadata",
+			setup: func(r *Registry) {
+				r.NewCounter("idle_total", "Idle.", "kind")
+			},
+			want: `# HELP idle_total Idle.
+# TYPE idle_total counter
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			tt.setup(r)
+			if got := r.Text(); got != tt.want {
+				t.Errorf("Text() mismatch\ngot:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r *Registry)
+	}{
+		{"duplicate name", func(r *Registry) {
+			r.NewCounter("x_total", "X.")
+			r.NewGauge("x_total", "X.")
+		}},
+		{"unsorted buckets", func(r *Registry) {
+			r.NewHistogram("h", "H.", []float64{1, 0.5})
+		}},
+		{"wrong label count", func(r *Registry) {
+			r.NewCounter("y_total", "Y.", "a", "b").With("only-one")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			tt.fn(NewRegistry())
+		})
+	}
+}
+
+func TestDefaultBuckets(t *testing.T) {
+	r := NewRegistry()
+	r.NewHistogram("d_seconds", "D.", nil).With().Observe(0.2)
+	text := r.Text()
+	if got := strings.Count(text, "d_seconds_bucket{"); got != len(DefBuckets)+1 {
+		t.Fatalf("%d bucket lines, want %d:\n%s", got, len(DefBuckets)+1, text)
+	}
+	if !strings.Contains(text, `d_seconds_bucket{le="0.25"} 1`) || !strings.Contains(text, `d_seconds_bucket{le="0.1"} 0`) {
+		t.Fatalf("unexpected bucket counts:\n%s", text)
+	}
+}
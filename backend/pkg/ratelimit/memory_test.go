@@ -0,0 +1,90 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestMemoryStoreRefill(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	s := NewMemoryStore()
+	s.now = clock.now
+	ctx := context.Background()
+	limit := Per(3, 3*time.Second) // 每秒补充 1 个，容量 3
+
+	take := func() Result {
+		t.Helper()
+		result, err := s.Take(ctx, "k", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	for want := 2; want >= 0; want-- {
+		r := take()
+		if !r.Allowed || r.Remaining != want || r.Limit != 3 {
+			t.Fatalf("take with %d left = %+v", want, r)
+		}
+	}
+	r := take()
+	if r.Allowed || r.Remaining != 0 || r.RetryAfter != time.Second || r.Reset != 3*time.Second {
+		t.Fatalf("take from empty bucket = %+v, want rejected with retry after 1s and reset 3s", r)
+	}
+
+	// 半秒只补充半个令牌，仍被拒绝
+	clock.advance(500 * time.Millisecond)
+	if r := take(); r.Allowed || r.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("take after 0.5s = %+v, want rejected with retry after 0.5s", r)
+	}
+	clock.advance(500 * time.Millisecond)
+	if r := take(); !r.Allowed || r.Remaining != 0 {
+		t.Fatalf("take after 1s = %+v, want allowed", r)
+	}
+
+	// 长时间空闲后最多补满到容量
+	clock.advance(time.Hour)
+	if r := take(); !r.Allowed || r.Remaining != 2 {
+		t.Fatalf("take after idling = %+v, want allowed with 2 left", r)
+	}
+
+	// 不同的 key 使用各自的桶
+	other, err := s.Take(ctx, "other", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !other.Allowed || other.Remaining != 2 {
+		t.Fatalf("take on another key = %+v", other)
+	}
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Limit
+		enabled bool
+		err     bool
+	}{
+		{"60/m", Limit{Rate: 1, Burst: 60}, true, false},
+		{"10/s", Limit{Rate: 10, Burst: 10}, true, false},
+		{"", Limit{}, false, false},
+		{"off", Limit{}, false, false},
+		{"0", Limit{}, false, false},
+		{"60", Limit{}, false, true},
+		{"-1/m", Limit{}, false, true},
+		{"5/d", Limit{}, false, true},
+	}
+	for _, tt := range tests {
+		got, enabled, err := ParseLimit(tt.in)
+		if (err != nil) != tt.err || enabled != tt.enabled || got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, %v, %v", tt.in, got, enabled, err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit 令牌桶参数：桶容量 Burst，每秒补充 Rate 个令牌
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Per 每 period 允许 n 次请求，突发上限也为 n
+func Per(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// ParseLimit 解析 "60/m" 形式的限额，单位为 s、m、h；空字符串、"0" 或 "off" 表示不限流
+func ParseLimit(s string) (Limit, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" || s == "off" {
+		return Limit{}, false, nil
+	}
+
+	count, unit, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, false, fmt.Errorf("无效的限额 %q，格式应为 次数/s|m|h", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, false, fmt.Errorf("无效的限额 %q", s)
+	}
+
+	var period time.Duration
+	switch unit {
+	case "s":
+		period = time.Second
+	case "m":
+		period = time.Minute
+	case "h":
+		period = time.Hour
+	default:
+		return Limit{}, false, fmt.Errorf("无效的限额单位 %q", unit)
+	}
+	return Per(n, period), true, nil
+}
+
+// Result 一次取令牌的结果
+type Result struct {
+	Allowed    bool
+	Limit      int           // 桶容量
+	Remaining  int           // 剩余令牌数
+	Reset      time.Duration // 令牌补满所需时间
+	RetryAfter time.Duration // 被拒绝时，下一个令牌可用前的等待时间
+}
+
+// Store 令牌桶存储。内存实现只在单进程内生效，多实例部署时可换成共享存储（如 Redis）
+type Store interface {
+	// Take 从 key 对应的桶中取一个令牌
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
@@ -52,6 +52,16 @@ func Unauthorized(c *gin.Context, message string) {
 	Error(c, http.StatusUnauthorized, message)
 }
 
+// Forbidden 无权限响应
+func Forbidden(c *gin.Context, message string) {
+	Error(c, http.StatusForbidden, message)
+}
+
+// NotFound 资源不存在响应
+func NotFound(c *gin.Context, message string) {
+	Error(c, http.StatusNotFound, message)
+}
+
 // BadRequest 错误请求响应
 func BadRequest(c *gin.Context, message string) {
 	Error(c, http.StatusBadRequest, message)
@@ -0,0 +1,65 @@
+package textdiff
+
+// Op 差异行的类型
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Line 一行差异。OldLine/NewLine 为该行在修改前后文本中的行号（从 1 开始），不存在时为 0
+type Line struct {
+	Op      Op     `json:"op"`
+	Text    string `json:"text"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+}
+
+// Lines 按行比较 a 和 b，基于最长公共子序列，同一位置先输出删除再输出新增。
+// 诗词正文行数不多，直接使用 O(n*m) 的动态规划
+func Lines(a, b []string) []Line {
+	n, m := len(a), len(b)
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]Line, 0, n+m)
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, Line{Op: OpEqual, Text: a[i], OldLine: i + 1, NewLine: j + 1})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, Line{Op: OpDelete, Text: a[i], OldLine: i + 1})
+			i++
+		default:
+			lines = append(lines, Line{Op: OpInsert, Text: b[j], NewLine: j + 1})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		lines = append(lines, Line{Op: OpDelete, Text: a[i], OldLine: i + 1})
+	}
+	for ; j < m; j++ {
+		lines = append(lines, Line{Op: OpInsert, Text: b[j], NewLine: j + 1})
+	}
+	return lines
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"poem/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// APIKeyRepository API密钥数据访问接口
+type APIKeyRepository interface {
+	// Create 保存新密钥
+	Create(ctx context.Context, key *models.APIKey) error
+	// GetByID 获取密钥
+	GetByID(ctx context.Context, id uint) (*models.APIKey, error)
+	// GetActiveByHash 根据哈希获取未吊销、未过期且所属用户未被禁用的密钥
+	GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	// ListByUser 列出用户的全部密钥，最新的在前
+	ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error)
+	// CountActive 统计用户未吊销的密钥数
+	CountActive(ctx context.Context, userID uint) (int64, error)
+	// Revoke 吊销密钥
+	Revoke(ctx context.Context, id uint) error
+	// IncrementUsage 当日调用次数未达到 quota 时加一并更新最后使用时间，返回累计次数；
+	// 已达到上限时不计数并返回 false。quota 为 0 表示不限
+	IncrementUsage(ctx context.Context, id uint, day string, quota int) (int64, bool, error)
+	// GetUsage 获取密钥 since（含）之后每天的调用次数，按日期升序
+	GetUsage(ctx context.Context, id uint, since string) ([]models.APIKeyUsage, error)
+	// GetUsageOn 获取多个密钥某天的调用次数
+	GetUsageOn(ctx context.Context, ids []uint, day string) (map[uint]int64, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建API密钥Repository
+func NewAPIKeyRepository(db *gorm.DB) (APIKeyRepository, error) {
+	return &apiKeyRepository{db: db}, nil
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = api_keys.user_id AND users.status = 1").
+		Where("api_keys.key_hash = ? AND api_keys.revoked_at IS NULL", keyHash).
+		Where("api_keys.expires_at IS NULL OR api_keys.expires_at > ?", time.Now()).
+		First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id desc").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) CountActive(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *apiKeyRepository) IncrementUsage(ctx context.Context, id uint, day string, quota int) (int64, bool, error) {
+	var (
+		count   int64
+		allowed bool
+	)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var usage models.APIKeyUsage
+		err := tx.Where("api_key_id = ? AND day = ?", id, day).Limit(1).Find(&usage).Error
+		if err != nil {
+			return err
+		}
+		count = usage.Count
+		if quota > 0 && count >= int64(quota) {
+			return nil
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "api_key_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("api_key_usages.count + 1")}),
+		}).Create(&models.APIKeyUsage{APIKeyID: id, Day: day, Count: 1}).Error
+		if err != nil {
+			return err
+		}
+		count++
+		allowed = true
+		return tx.Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", time.Now()).Error
+	})
+	return count, allowed, err
+}
+
+func (r *apiKeyRepository) GetUsage(ctx context.Context, id uint, since string) ([]models.APIKeyUsage, error) {
+	var usage []models.APIKeyUsage
+	err := r.db.WithContext(ctx).
+		Where("api_key_id = ? AND day >= ?", id, since).
+		Order("day asc").
+		Find(&usage).Error
+	return usage, err
+}
+
+func (r *apiKeyRepository) GetUsageOn(ctx context.Context, ids []uint, day string) (map[uint]int64, error) {
+	var usage []models.APIKeyUsage
+	if err := r.db.WithContext(ctx).Where("api_key_id IN ? AND day = ?", ids, day).Find(&usage).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint]int64, len(usage))
+	for _, u := range usage {
+		result[u.APIKeyID] = u.Count
+	}
+	return result, nil
+}
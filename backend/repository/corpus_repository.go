@@ -0,0 +1,346 @@
+package repository
+
+import (
+	"context"
+	"poem/backend/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CorpusRepository 管理后台编辑语料的数据访问接口。
+// 每次修改都和对应的 CorpusOverride、WorkRevision 在同一事务中写入，
+// 前者保证 ETL 重建后能重新应用，后者记录修订历史
+type CorpusRepository interface {
+	// GetWork 获取作品及作者、分类、注释
+	GetWork(ctx context.Context, id uint) (*models.Work, error)
+	// SaveWork 新建或更新作品
+	SaveWork(ctx context.Context, work *models.Work, override *models.CorpusOverride, revision *models.WorkRevision) error
+	// GetWorkByKey 按 SourceKey 获取作品（不含关联），ETL 重建后用它找回ID
+	GetWorkByKey(ctx context.Context, key string) (*models.Work, error)
+	// DeleteWork 删除作品及其注释和选集关联
+	DeleteWork(ctx context.Context, work *models.Work, override *models.CorpusOverride, revision *models.WorkRevision) error
+	// GetAuthor 获取作者
+	GetAuthor(ctx context.Context, id uint) (*models.Author, error)
+	// GetAuthorByKey 按 SourceKey 获取作者
+	GetAuthorByKey(ctx context.Context, key string) (*models.Author, error)
+	// SaveAuthor 新建或更新作者
+	SaveAuthor(ctx context.Context, author *models.Author, override *models.CorpusOverride, revision *models.WorkRevision) error
+	// DeleteAuthor 删除作者及其别名
+	DeleteAuthor(ctx context.Context, author *models.Author, override *models.CorpusOverride, revision *models.WorkRevision) error
+	// CountWorksByAuthor 统计作者的作品数
+	CountWorksByAuthor(ctx context.Context, authorID uint) (int64, error)
+	// GetComment 获取注释
+	GetComment(ctx context.Context, id uint) (*models.Comment, error)
+	// ListComments 按ID升序列出注释
+	ListComments(ctx context.Context, filter models.CommentFilter, pq models.PageQuery) (models.CommentCollection, error)
+	// SaveComment 新建或更新注释
+	SaveComment(ctx context.Context, comment *models.Comment, override *models.CorpusOverride, revision *models.WorkRevision) error
+	// DeleteComment 删除注释
+	DeleteComment(ctx context.Context, comment *models.Comment, override *models.CorpusOverride, revision *models.WorkRevision) error
+	// GetCategoryByName 根据分类名获取分类
+	GetCategoryByName(ctx context.Context, name string) (*models.Category, error)
+	// GetDynastyByName 根据朝代名获取朝代
+	GetDynastyByName(ctx context.Context, name string) (*models.Dynasty, error)
+	// GetOverride 获取实体的修改记录
+	GetOverride(ctx context.Context, entityType, sourceKey string) (*models.CorpusOverride, error)
+	// ListOverrides 列出修改记录，entityType 为空时返回全部
+	ListOverrides(ctx context.Context, entityType string) ([]models.CorpusOverride, error)
+	// GetRevision 获取修订
+	GetRevision(ctx context.Context, id uint) (*models.WorkRevision, error)
+	// ListRevisions 按时间倒序列出修订
+	ListRevisions(ctx context.Context, filter models.RevisionFilter, pq models.PageQuery) (models.RevisionCollection, error)
+	// LatestImportRun 获取最近一次 ETL 导入记录，尚未导入过时返回 nil
+	LatestImportRun(ctx context.Context) (*models.ImportRun, error)
+}
+
+type corpusRepository struct {
+	db *gorm.DB
+}
+
+// NewCorpusRepository 创建语料编辑Repository
+func NewCorpusRepository(db *gorm.DB) (CorpusRepository, error) {
+	return &corpusRepository{db: db}, nil
+}
+
+func (r *corpusRepository) GetWork(ctx context.Context, id uint) (*models.Work, error) {
+	var work models.Work
+	err := r.db.WithContext(ctx).Preload("Author").Preload("Category").Preload("Comments").First(&work, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+func (r *corpusRepository) GetWorkByKey(ctx context.Context, key string) (*models.Work, error) {
+	var work models.Work
+	if err := r.db.WithContext(ctx).Where("source_key = ?", key).First(&work).Error; err != nil {
+		return nil, err
+	}
+	return &work, nil
+}
+
+func (r *corpusRepository) SaveWork(ctx context.Context, work *models.Work, override *models.CorpusOverride, revision *models.WorkRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(work).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, revision, work.ID, work.ID); err != nil {
+			return err
+		}
+		return saveOverride(tx, override)
+	})
+}
+
+func (r *corpusRepository) DeleteWork(ctx context.Context, work *models.Work, override *models.CorpusOverride, revision *models.WorkRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("work_id = ?", work.ID).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("work_id = ?", work.ID).Delete(&models.AnthologyWork{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Work{}, work.ID).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, revision, work.ID, work.ID); err != nil {
+			return err
+		}
+		return saveOverride(tx, override)
+	})
+}
+
+func (r *corpusRepository) GetAuthor(ctx context.Context, id uint) (*models.Author, error) {
+	var author models.Author
+	if err := r.db.WithContext(ctx).First(&author, id).Error; err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+func (r *corpusRepository) GetAuthorByKey(ctx context.Context, key string) (*models.Author, error) {
+	var author models.Author
+	if err := r.db.WithContext(ctx).Where("source_key = ?", key).First(&author).Error; err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+func (r *corpusRepository) SaveAuthor(ctx context.Context, author *models.Author, override *models.CorpusOverride, revision *models.WorkRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(author).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, revision, author.ID, 0); err != nil {
+			return err
+		}
+		return saveOverride(tx, override)
+	})
+}
+
+func (r *corpusRepository) DeleteAuthor(ctx context.Context, author *models.Author, override *models.CorpusOverride, revision *models.WorkRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("author_id = ?", author.ID).Delete(&models.AuthorAlias{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Author{}, author.ID).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, revision, author.ID, 0); err != nil {
+			return err
+		}
+		return saveOverride(tx, override)
+	})
+}
+
+func (r *corpusRepository) CountWorksByAuthor(ctx context.Context, authorID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Work{}).Where("author_id = ?", authorID).Count(&count).Error
+	return count, err
+}
+
+func (r *corpusRepository) GetComment(ctx context.Context, id uint) (*models.Comment, error) {
+	var comment models.Comment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *corpusRepository) ListComments(ctx context.Context, filter models.CommentFilter, pq models.PageQuery) (models.CommentCollection, error) {
+	query := r.db.WithContext(ctx).Model(&models.Comment{})
+	if filter.WorkID != 0 {
+		query = query.Where("work_id = ?", filter.WorkID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+
+	total, totalPages, err := countTotal(query, pq)
+	if err != nil {
+		return models.CommentCollection{}, err
+	}
+
+	scope := cursorScope("comments", filter.WorkID, filter.Type)
+	query, offset, err := applyPage(query.Order("id asc"), pq, scope, "id", true)
+	if err != nil {
+		return models.CommentCollection{}, err
+	}
+	var comments []models.Comment
+	if err := query.Find(&comments).Error; err != nil {
+		return models.CommentCollection{}, err
+	}
+
+	next := ""
+	if len(comments) > pq.PageSize {
+		comments = comments[:pq.PageSize]
+		next = nextCursor(pq, scope, comments[len(comments)-1].ID, true, offset)
+	}
+
+	return models.CommentCollection{
+		Comments:   comments,
+		Total:      total,
+		Page:       pq.Page,
+		PageSize:   pq.PageSize,
+		TotalPages: totalPages,
+		NextCursor: next,
+	}, nil
+}
+
+func (r *corpusRepository) SaveComment(ctx context.Context, comment *models.Comment, override *models.CorpusOverride, revision *models.WorkRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(comment).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, revision, comment.ID, comment.WorkID); err != nil {
+			return err
+		}
+		return saveOverride(tx, override)
+	})
+}
+
+func (r *corpusRepository) DeleteComment(ctx context.Context, comment *models.Comment, override *models.CorpusOverride, revision *models.WorkRevision) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Comment{}, comment.ID).Error; err != nil {
+			return err
+		}
+		if err := saveRevision(tx, revision, comment.ID, comment.WorkID); err != nil {
+			return err
+		}
+		return saveOverride(tx, override)
+	})
+}
+
+func (r *corpusRepository) GetCategoryByName(ctx context.Context, name string) (*models.Category, error) {
+	var category models.Category
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *corpusRepository) GetDynastyByName(ctx context.Context, name string) (*models.Dynasty, error) {
+	var dynasty models.Dynasty
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&dynasty).Error; err != nil {
+		return nil, err
+	}
+	return &dynasty, nil
+}
+
+func (r *corpusRepository) GetOverride(ctx context.Context, entityType, sourceKey string) (*models.CorpusOverride, error) {
+	var override models.CorpusOverride
+	err := r.db.WithContext(ctx).Where("entity_type = ? AND source_key = ?", entityType, sourceKey).First(&override).Error
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *corpusRepository) ListOverrides(ctx context.Context, entityType string) ([]models.CorpusOverride, error) {
+	var overrides []models.CorpusOverride
+	query := r.db.WithContext(ctx).Order("updated_at DESC")
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	err := query.Find(&overrides).Error
+	return overrides, err
+}
+
+func (r *corpusRepository) GetRevision(ctx context.Context, id uint) (*models.WorkRevision, error) {
+	var revision models.WorkRevision
+	if err := r.db.WithContext(ctx).First(&revision, id).Error; err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+func (r *corpusRepository) ListRevisions(ctx context.Context, filter models.RevisionFilter, pq models.PageQuery) (models.RevisionCollection, error) {
+	query := r.db.WithContext(ctx).Model(&models.WorkRevision{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.WorkID != 0 {
+		query = query.Where("work_id = ?", filter.WorkID)
+	}
+	if filter.EditorID != 0 {
+		query = query.Where("editor_id = ?", filter.EditorID)
+	}
+
+	total, totalPages, err := countTotal(query, pq)
+	if err != nil {
+		return models.RevisionCollection{}, err
+	}
+
+	// 按时间倒序，游标使用偏移量
+	scope := cursorScope("revisions", filter.EntityType, filter.EntityID, filter.WorkID, filter.EditorID)
+	query, offset, err := applyPage(query.Order("id desc"), pq, scope, "id", false)
+	if err != nil {
+		return models.RevisionCollection{}, err
+	}
+	var revisions []models.WorkRevision
+	if err := query.Find(&revisions).Error; err != nil {
+		return models.RevisionCollection{}, err
+	}
+
+	next := ""
+	if len(revisions) > pq.PageSize {
+		revisions = revisions[:pq.PageSize]
+		next = nextCursor(pq, scope, 0, false, offset)
+	}
+
+	return models.RevisionCollection{
+		Revisions:  revisions,
+		Total:      total,
+		Page:       pq.Page,
+		PageSize:   pq.PageSize,
+		TotalPages: totalPages,
+		NextCursor: next,
+	}, nil
+}
+
+// saveRevision 写入修订，新建的实体在保存后才有ID
+func saveRevision(tx *gorm.DB, revision *models.WorkRevision, entityID, workID uint) error {
+	revision.EntityID = entityID
+	revision.WorkID = workID
+	return tx.Create(revision).Error
+}
+
+// saveOverride 按 entity_type + source_key 写入修改记录，已存在时整体覆盖。
+// 每次编辑语料都经过这里，同时递增语料的数据版本
+func saveOverride(tx *gorm.DB, override *models.CorpusOverride) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}, {Name: "source_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"action", "data", "updated_by", "updated_at"}),
+	}).Create(override).Error
+	if err != nil {
+		return err
+	}
+	return BumpDataVersion(tx, models.DataVersionCorpus)
+}
+
+func (r *corpusRepository) LatestImportRun(ctx context.Context) (*models.ImportRun, error) {
+	return (&PoetryRepository{db: r.db}).LatestImportRun(ctx)
+}
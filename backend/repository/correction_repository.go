@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"poem/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CorrectionRepository 读者纠错数据访问接口
+type CorrectionRepository interface {
+	// Create 提交纠错
+	Create(ctx context.Context, correction *models.Correction) error
+	// GetByID 获取纠错
+	GetByID(ctx context.Context, id uint) (*models.Correction, error)
+	// List 列出纠错，待审核队列按提交顺序排列，其余按时间倒序
+	List(ctx context.Context, filter models.CorrectionFilter, pq models.PageQuery) (models.CorrectionCollection, error)
+	// CountPending 统计用户待审核的纠错数
+	CountPending(ctx context.Context, userID uint) (int64, error)
+	// Review 把待审核的纠错改为 status，已被审核时返回 false
+	Review(ctx context.Context, id uint, status string, reviewerID uint, note string) (bool, error)
+	// Reopen 审核后应用修改失败时退回待审核
+	Reopen(ctx context.Context, id uint) error
+}
+
+type correctionRepository struct {
+	db *gorm.DB
+}
+
+// NewCorrectionRepository 创建纠错Repository
+func NewCorrectionRepository(db *gorm.DB) (CorrectionRepository, error) {
+	return &correctionRepository{db: db}, nil
+}
+
+func (r *correctionRepository) Create(ctx context.Context, correction *models.Correction) error {
+	return r.db.WithContext(ctx).Create(correction).Error
+}
+
+func (r *correctionRepository) GetByID(ctx context.Context, id uint) (*models.Correction, error) {
+	var correction models.Correction
+	if err := r.db.WithContext(ctx).First(&correction, id).Error; err != nil {
+		return nil, err
+	}
+	return &correction, nil
+}
+
+func (r *correctionRepository) List(ctx context.Context, filter models.CorrectionFilter, pq models.PageQuery) (models.CorrectionCollection, error) {
+	query := r.db.WithContext(ctx).Model(&models.Correction{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.WorkID != 0 {
+		query = query.Where("work_id = ?", filter.WorkID)
+	}
+
+	total, totalPages, err := countTotal(query, pq)
+	if err != nil {
+		return models.CorrectionCollection{}, err
+	}
+
+	// 待审核队列先到先审，可以用 keyset；其他列表按时间倒序，游标退回到偏移量
+	order, keyset := "id desc", false
+	if filter.Status == models.CorrectionPending {
+		order, keyset = "id asc", true
+	}
+	scope := cursorScope("corrections", filter.Status, filter.UserID, filter.WorkID)
+	query, offset, err := applyPage(query.Order(order), pq, scope, "id", keyset)
+	if err != nil {
+		return models.CorrectionCollection{}, err
+	}
+	var corrections []models.Correction
+	if err := query.Find(&corrections).Error; err != nil {
+		return models.CorrectionCollection{}, err
+	}
+
+	next := ""
+	if len(corrections) > pq.PageSize {
+		corrections = corrections[:pq.PageSize]
+		next = nextCursor(pq, scope, corrections[len(corrections)-1].ID, keyset, offset)
+	}
+
+	return models.CorrectionCollection{
+		Corrections: corrections,
+		Total:       total,
+		Page:        pq.Page,
+		PageSize:    pq.PageSize,
+		TotalPages:  totalPages,
+		NextCursor:  next,
+	}, nil
+}
+
+func (r *correctionRepository) CountPending(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Correction{}).
+		Where("user_id = ? AND status = ?", userID, models.CorrectionPending).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *correctionRepository) Review(ctx context.Context, id uint, status string, reviewerID uint, note string) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.Correction{}).
+		Where("id = ? AND status = ?", id, models.CorrectionPending).
+		Updates(map[string]interface{}{
+			"status":      status,
+			"reviewer_id": reviewerID,
+			"review_note": note,
+			"reviewed_at": &now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *correctionRepository) Reopen(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.Correction{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":      models.CorrectionPending,
+			"reviewer_id": 0,
+			"review_note": "",
+			"reviewed_at": nil,
+		}).Error
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"poem/backend/models"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// 支持的数据库，与 gorm Dialector.Name() 一致
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// 搜索实现：SQLite 和缺少索引时用 LIKE 全表扫描，PostgreSQL 用 pg_trgm 索引加速 LIKE，MySQL 用 ngram 全文索引
+const (
+	SearchLike     = "like"
+	SearchTrigram  = "trigram"
+	SearchFulltext = "fulltext"
+)
+
+// 全文搜索索引名，由 EnsureSearchIndexes 创建
+const (
+	trigramTitleIndex   = "idx_works_title_trgm"
+	trigramContentIndex = "idx_works_content_trgm"
+	fulltextIndex       = "idx_works_fulltext"
+)
+
+// OpenDB 按驱动打开数据库。SQLite 的 dsn 为文件路径；MySQL 的 dsn 会强制 parseTime=true，
+// 否则 DATETIME 列无法扫描到 time.Time
+func OpenDB(driver, dsn string) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch driver {
+	case DriverSQLite, "":
+		dialector = sqlite.Open(dsn)
+	case DriverPostgres:
+		dialector = postgres.Open(dsn)
+	case DriverMySQL:
+		cfg, err := mysqldriver.ParseDSN(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("解析 MySQL DSN 失败: %w", err)
+		}
+		cfg.ParseTime = true
+		dialector = mysql.Open(cfg.FormatDSN())
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+	return gorm.Open(dialector, &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+}
+
+// randomOrder 随机排序表达式
+func randomOrder(db *gorm.DB) string {
+	if db.Dialector.Name() == DriverMySQL {
+		return "RAND()"
+	}
+	return "RANDOM()"
+}
+
+// EnsureSearchIndexes 创建作品标题和正文的全文搜索索引，已存在时跳过。
+// SQLite 没有可用于中文子串匹配的索引，什么也不做
+func EnsureSearchIndexes(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		// 需要 pg_trgm 扩展，没有建扩展权限时请由 DBA 预先执行 CREATE EXTENSION pg_trgm
+		statements := []string{
+			"CREATE EXTENSION IF NOT EXISTS pg_trgm",
+			"CREATE INDEX IF NOT EXISTS " + trigramTitleIndex + " ON works USING gin (title gin_trgm_ops)",
+			"CREATE INDEX IF NOT EXISTS " + trigramContentIndex + " ON works USING gin (content gin_trgm_ops)",
+		}
+		for _, stmt := range statements {
+			if err := db.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+	case DriverMySQL:
+		if db.Migrator().HasIndex(&models.Work{}, fulltextIndex) {
+			return nil
+		}
+		return db.Exec("ALTER TABLE works ADD FULLTEXT INDEX " + fulltextIndex + " (title, content) WITH PARSER ngram").Error
+	}
+	return nil
+}
+
+// SearchEngine 当前数据库实际使用的搜索实现，全文索引不存在时退回 LIKE
+func SearchEngine(ctx context.Context, db *gorm.DB) string {
+	m := db.WithContext(ctx).Migrator()
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		if m.HasIndex(&models.Work{}, trigramContentIndex) {
+			return SearchTrigram
+		}
+	case DriverMySQL:
+		if m.HasIndex(&models.Work{}, fulltextIndex) {
+			return SearchFulltext
+		}
+	}
+	return SearchLike
+}
+
+// corpusTables ETL 每次重建的语料表，子表在前
+var corpusTables = []string{"anthology_works", "comments", "author_aliases", "works", "anthologies", "authors", "dynasties", "categories"}
+
+// TruncateCorpus 清空语料表并重置自增 ID，供 ETL 重新导入。
+// corpus_overrides、work_revisions、corrections 等引用语料的表不清空：修改记录本身以 source_key 为键，
+// 修订和纠错中的作品、作者ID由 ETL 导入后按 source_key 更新；注释修订的ID无法更新，回滚时会被拒绝
+func TruncateCorpus(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		return db.Exec("TRUNCATE TABLE " + strings.Join(corpusTables, ", ") + " RESTART IDENTITY").Error
+	case DriverMySQL:
+		// 有外键引用的表不能 TRUNCATE，需在同一连接上临时关闭外键检查
+		return db.Connection(func(conn *gorm.DB) error {
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+			for _, table := range corpusTables {
+				if err := conn.Exec("TRUNCATE TABLE " + table).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	for _, table := range corpusTables {
+		if err := db.Exec("DELETE FROM " + table).Error; err != nil {
+			return err
+		}
+	}
+	return db.Exec("DELETE FROM sqlite_sequence WHERE name IN ?", corpusTables).Error
+}
+
+// BumpDataVersion 递增数据版本，服务端在下次检查版本时清空缓存
+func BumpDataVersion(db *gorm.DB, name string) error {
+	return db.Model(&models.DataVersion{}).Where("name = ?", name).Updates(map[string]interface{}{
+		"version":    gorm.Expr("version + 1"),
+		"updated_at": time.Now(),
+	}).Error
+}
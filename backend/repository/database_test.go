@@ -0,0 +1,156 @@
+package repository_test
+
+import (
+	"context"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"sort"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openTestDB 打开 DB_DRIVER、DB_DSN 指定的数据库（默认临时 SQLite）并清空语料表。
+// 外部数据库的语料会被清空，请勿指向有数据的库
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := testdb.FromEnv(t)
+	if err := repository.TruncateCorpus(db); err != nil {
+		t.Fatalf("truncate corpus: %v", err)
+	}
+	return db
+}
+
+// seedCorpus 写入一个分类、一个朝代、两位作者和三首作品
+func seedCorpus(t *testing.T, db *gorm.DB) []models.Work {
+	t.Helper()
+	category := models.Category{Name: "tangshi", DisplayName: "唐诗"}
+	dynasty := models.Dynasty{Code: "tang", Name: "唐", SortOrder: 1}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&dynasty).Error; err != nil {
+		t.Fatal(err)
+	}
+	authors := []models.Author{
+		{Name: "李白", DynastyID: dynasty.ID, Dynasty: dynasty.Name},
+		{Name: "王维", DynastyID: dynasty.ID, Dynasty: dynasty.Name},
+	}
+	if err := db.Create(&authors).Error; err != nil {
+		t.Fatal(err)
+	}
+	works := []models.Work{
+		{CategoryID: category.ID, AuthorID: authors[0].ID, Title: "静夜思", Content: models.JSONArr{"床前明月光，疑是地上霜。", "举头望明月，低头思故乡。"}},
+		{CategoryID: category.ID, AuthorID: authors[0].ID, Title: "月下独酌", Content: models.JSONArr{"花间一壶酒，独酌无相亲。"}},
+		{CategoryID: category.ID, AuthorID: authors[1].ID, Title: "山居秋暝", Content: models.JSONArr{"空山新雨后，天气晚来秋。", "明月松间照，清泉石上流。"}},
+	}
+	if err := db.Omit("Author", "Category").Create(&works).Error; err != nil {
+		t.Fatal(err)
+	}
+	return works
+}
+
+func workTitles(works []models.Work) []string {
+	titles := make([]string, len(works))
+	for i, w := range works {
+		titles[i] = w.Title
+	}
+	sort.Strings(titles)
+	return titles
+}
+
+func TestRandomOrder(t *testing.T) {
+	db := openTestDB(t)
+	seeded := seedCorpus(t, db)
+
+	var works []models.Work
+	if err := db.Order(repository.RandomOrder(db)).Find(&works).Error; err != nil {
+		t.Fatalf("order by %s: %v", repository.RandomOrder(db), err)
+	}
+	if got, want := strings.Join(workTitles(works), ","), strings.Join(workTitles(seeded), ","); got != want {
+		t.Fatalf("random order returned %v, want %v", got, want)
+	}
+
+	repo := repository.NewPoetryRepositoryWithDB(db)
+	random, err := repo.GetRandomPoems(context.Background(), 2, "tangshi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(random) != 2 || random[0].ID == random[1].ID {
+		t.Fatalf("GetRandomPoems returned %v, want 2 distinct works", workTitles(random))
+	}
+}
+
+func TestSearch(t *testing.T) {
+	db := openTestDB(t)
+	seedCorpus(t, db)
+	if err := repository.EnsureSearchIndexes(db); err != nil {
+		t.Fatalf("ensure search indexes: %v", err)
+	}
+	// 重复执行应跳过已存在的索引
+	if err := repository.EnsureSearchIndexes(db); err != nil {
+		t.Fatalf("ensure search indexes again: %v", err)
+	}
+
+	ctx := context.Background()
+	wantEngine := map[string]string{
+		repository.DriverSQLite:   repository.SearchLike,
+		repository.DriverPostgres: repository.SearchTrigram,
+		repository.DriverMySQL:    repository.SearchFulltext,
+	}[db.Dialector.Name()]
+	repo := repository.NewPoetryRepositoryWithDB(db)
+	if got := repo.SearchEngine(ctx); got != wantEngine {
+		t.Fatalf("SearchEngine = %q, want %q", got, wantEngine)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"title", "静夜", []string{"静夜思"}},
+		{"content phrase", "明月", []string{"山居秋暝", "静夜思"}},
+		{"single character", "月", []string{"山居秋暝", "月下独酌", "静夜思"}}, // 全文搜索至少两个字，单字退回 LIKE
+		{"author name", "王维", []string{"山居秋暝"}},
+		{"no match", "大江东去", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := repo.Search(ctx, tt.query, models.PageQuery{Page: 1, PageSize: 10, WithTotal: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := strings.Join(workTitles(resp.Works), ",")
+			if want := strings.Join(tt.want, ","); got != want || resp.Total != len(tt.want) {
+				t.Fatalf("Search(%q) = [%s] (total %d), want [%s]", tt.query, got, resp.Total, want)
+			}
+		})
+	}
+}
+
+func TestTruncateCorpus(t *testing.T) {
+	db := openTestDB(t)
+	seedCorpus(t, db)
+
+	if err := repository.TruncateCorpus(db); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range repository.CorpusTables {
+		var count int64
+		if err := db.Table(table).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s has %d rows after truncate", table, count)
+		}
+	}
+
+	// 自增 ID 应从 1 重新开始，ETL 重新导入后作品ID与首次导入一致
+	works := seedCorpus(t, db)
+	if works[0].ID != 1 || works[0].AuthorID != 1 || works[0].CategoryID != 1 {
+		t.Fatalf("after truncate work id = %d, author id = %d, category id = %d, want all 1",
+			works[0].ID, works[0].AuthorID, works[0].CategoryID)
+	}
+}
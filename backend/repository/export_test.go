@@ -0,0 +1,15 @@
+package repository
+
+import "gorm.io/gorm"
+
+// 供 repository_test 包使用的内部函数
+
+var (
+	RandomOrder  = randomOrder
+	CorpusTables = corpusTables
+)
+
+// NewPoetryRepositoryWithDB 在已打开的数据库上创建诗词仓库
+func NewPoetryRepositoryWithDB(db *gorm.DB) *PoetryRepository {
+	return &PoetryRepository{db: db}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"poem/backend/migrations"
+	"poem/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// HealthRepository 就绪检查用到的数据库探测
+type HealthRepository interface {
+	// Ping 检查数据库连接是否可用
+	Ping(ctx context.Context) error
+	// CheckMigrations 数据库是否已迁移到当前程序要求的版本，返回 migrations.ErrSchemaMismatch 说明差异
+	CheckMigrations(ctx context.Context) error
+	// MissingSchema 返回模型对应的表或列中数据库里缺少的部分，形如 "works" 或 "works.source_key"
+	MissingSchema(ctx context.Context, list ...interface{}) ([]string, error)
+	// HasWorks 作品表是否有数据
+	HasWorks(ctx context.Context) (bool, error)
+	// MissingIndexes 返回搜索依赖的索引中缺少的部分，PostgreSQL/MySQL 包括全文搜索索引
+	MissingIndexes(ctx context.Context) []string
+	// SearchEngine 当前使用的搜索实现：like、trigram 或 fulltext
+	SearchEngine(ctx context.Context) string
+	// LatestImportRun 获取最近一次 ETL 导入记录，尚未导入过时返回 nil
+	LatestImportRun(ctx context.Context) (*models.ImportRun, error)
+}
+
+type healthRepository struct {
+	db *gorm.DB
+}
+
+// NewHealthRepository 创建就绪检查Repository，只读，不迁移表结构
+func NewHealthRepository(db *gorm.DB) HealthRepository {
+	return &healthRepository{db: db}
+}
+
+func (r *healthRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func (r *healthRepository) CheckMigrations(ctx context.Context) error {
+	return migrations.Check(ctx, r.db)
+}
+
+func (r *healthRepository) MissingSchema(ctx context.Context, list ...interface{}) ([]string, error) {
+	db := r.db.WithContext(ctx)
+	var missing []string
+	for _, model := range list {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) {
+			missing = append(missing, table)
+			continue
+		}
+		columns, err := db.Migrator().ColumnTypes(table)
+		if err != nil {
+			return nil, err
+		}
+		have := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			have[c.Name()] = true
+		}
+		for _, name := range stmt.Schema.DBNames {
+			if !have[name] {
+				missing = append(missing, fmt.Sprintf("%s.%s", table, name))
+			}
+		}
+	}
+	return missing, nil
+}
+
+func (r *healthRepository) HasWorks(ctx context.Context) (bool, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.Work{}).Limit(1).Pluck("id", &ids).Error
+	return len(ids) > 0, err
+}
+
+func (r *healthRepository) MissingIndexes(ctx context.Context) []string {
+	db := r.db.WithContext(ctx)
+	type index struct {
+		model interface{}
+		name  string
+	}
+	indexes := []index{
+		{&models.Work{}, "idx_works_title"},
+		{&models.Work{}, "idx_works_author_id"},
+		{&models.Author{}, "idx_author_dynasty"},
+	}
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		indexes = append(indexes, index{&models.Work{}, trigramTitleIndex}, index{&models.Work{}, trigramContentIndex})
+	case DriverMySQL:
+		indexes = append(indexes, index{&models.Work{}, fulltextIndex})
+	}
+
+	var missing []string
+	for _, idx := range indexes {
+		if !db.Migrator().HasIndex(idx.model, idx.name) {
+			missing = append(missing, idx.name)
+		}
+	}
+	return missing
+}
+
+func (r *healthRepository) SearchEngine(ctx context.Context) string {
+	return SearchEngine(ctx, r.db)
+}
+
+func (r *healthRepository) LatestImportRun(ctx context.Context) (*models.ImportRun, error) {
+	return (&PoetryRepository{db: r.db}).LatestImportRun(ctx)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"poem/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginAttemptRepository 登录失败计数与认证审计日志数据访问接口
+type LoginAttemptRepository interface {
+	// GetThrottles 获取多个对象的失败计数，没有记录的不返回
+	GetThrottles(ctx context.Context, subjects []string) ([]models.LoginThrottle, error)
+	// RecordFailure 记录一次失败并返回累计次数。上次失败和锁定结束都早于 since 时从 1 重新计数
+	RecordFailure(ctx context.Context, subject string, since time.Time) (int, error)
+	// Lock 锁定对象直到 until
+	Lock(ctx context.Context, subject string, until time.Time) error
+	// Reset 清除失败计数
+	Reset(ctx context.Context, subjects []string) error
+	// AddAudit 写入审计日志
+	AddAudit(ctx context.Context, log *models.AuthAuditLog) error
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginAttemptRepository 创建登录尝试Repository
+func NewLoginAttemptRepository(db *gorm.DB) (LoginAttemptRepository, error) {
+	return &loginAttemptRepository{db: db}, nil
+}
+
+func (r *loginAttemptRepository) GetThrottles(ctx context.Context, subjects []string) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	err := r.db.WithContext(ctx).Where("subject IN ?", subjects).Find(&throttles).Error
+	return throttles, err
+}
+
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, subject string, since time.Time) (int, error) {
+	var failures int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var throttle models.LoginThrottle
+		err := tx.Where("subject = ?", subject).First(&throttle).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			throttle = models.LoginThrottle{Subject: subject}
+		} else if err != nil {
+			return err
+		}
+
+		expired := throttle.LastFailedAt.Before(since) &&
+			(throttle.LockedUntil == nil || throttle.LockedUntil.Before(since))
+		if expired {
+			throttle.Failures = 0
+			throttle.LockedUntil = nil
+		}
+		throttle.Failures++
+		throttle.LastFailedAt = time.Now()
+		failures = throttle.Failures
+		return tx.Save(&throttle).Error
+	})
+	return failures, err
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, subject string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.LoginThrottle{}).
+		Where("subject = ?", subject).
+		Update("locked_until", until).Error
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, subjects []string) error {
+	return r.db.WithContext(ctx).Where("subject IN ?", subjects).Delete(&models.LoginThrottle{}).Error
+}
+
+func (r *loginAttemptRepository) AddAudit(ctx context.Context, log *models.AuthAuditLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
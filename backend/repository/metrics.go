@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"poem/backend/pkg/metrics"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dbBuckets 数据库查询耗时分桶（秒），比 HTTP 请求更细
+var dbBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+// metricsPlugin 通过 GORM 回调记录每条语句的耗时
+type metricsPlugin struct {
+	duration *metrics.HistogramVec
+	errors   *metrics.CounterVec
+}
+
+const metricsStartKey = "metrics:start"
+
+// InstrumentDB 为数据库连接注册查询耗时指标，按操作类型（create/query/update/delete/row/raw）和表名区分
+func InstrumentDB(db *gorm.DB, registry *metrics.Registry) error {
+	return db.Use(&metricsPlugin{
+		duration: registry.NewHistogram("db_query_duration_seconds",
+			"Database statement latency in seconds by operation and table.", dbBuckets, "operation", "table"),
+		errors: registry.NewCounter("db_query_errors_total",
+			"Database statements that returned an error (not counting record not found).", "operation", "table"),
+	})
+}
+
+func (p *metricsPlugin) Name() string { return "metrics" }
+
+func (p *metricsPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		{"query", cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		{"update", cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		{"delete", cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		{"row", cb.Row().Before("*").Register, cb.Row().After("*").Register},
+		{"raw", cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+	for _, proc := range processors {
+		if err := proc.before("metrics:before_"+proc.operation, p.before); err != nil {
+			return err
+		}
+		if err := proc.after("metrics:after_"+proc.operation, p.after(proc.operation)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *metricsPlugin) before(db *gorm.DB) {
+	db.InstanceSet(metricsStartKey, time.Now())
+}
+
+func (p *metricsPlugin) after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		v, ok := db.InstanceGet(metricsStartKey)
+		if !ok {
+			return
+		}
+		start, ok := v.(time.Time)
+		if !ok {
+			return
+		}
+		table := db.Statement.Table
+		if table == "" {
+			table = "unknown"
+		}
+		p.duration.With(operation, table).Observe(time.Since(start).Seconds())
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			p.errors.With(operation, table).Inc()
+		}
+	}
+}
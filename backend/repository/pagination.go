@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"poem/backend/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor 游标无法解析或不属于当前列表
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// pageCursor 游标内容，对外编码为不透明字符串。
+// 按主键排序的列表使用 keyset（LastID），其他排序退回到偏移量；
+// Scope 是生成游标时列表及其筛选、排序条件的摘要，换了条件的请求不能沿用旧游标
+type pageCursor struct {
+	LastID uint   `json:"id,omitempty"`
+	Offset int    `json:"o,omitempty"`
+	Scope  uint32 `json:"s"`
+}
+
+// cursorScope 计算列表范围的摘要，parts 依次为列表名和影响结果集或顺序的条件
+func cursorScope(parts ...interface{}) uint32 {
+	h := fnv.New32a()
+	for _, p := range parts {
+		fmt.Fprintf(h, "%v\x00", p)
+	}
+	return h.Sum32()
+}
+
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*pageCursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// applyPage 为查询加上分页条件并多取一条，用于判断是否还有下一页。
+// keyset 为 true 时要求查询已按 idColumn 升序排列；scope 与游标生成时不一致的视为无效游标
+func applyPage(query *gorm.DB, pq models.PageQuery, scope uint32, idColumn string, keyset bool) (*gorm.DB, int, error) {
+	cur, err := decodeCursor(pq.Cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cur != nil && cur.Scope != scope {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	offset := (pq.Page - 1) * pq.PageSize
+	if cur != nil {
+		if keyset {
+			if cur.LastID == 0 {
+				return nil, 0, ErrInvalidCursor
+			}
+			return query.Where(idColumn+" > ?", cur.LastID).Limit(pq.PageSize + 1), 0, nil
+		}
+		if cur.LastID != 0 || cur.Offset < 0 {
+			return nil, 0, ErrInvalidCursor
+		}
+		offset = cur.Offset
+	}
+	return query.Offset(offset).Limit(pq.PageSize + 1), offset, nil
+}
+
+// nextCursor 生成下一页游标，调用方在多取到一条记录时使用
+func nextCursor(pq models.PageQuery, scope uint32, lastID uint, keyset bool, offset int) string {
+	if keyset {
+		return encodeCursor(pageCursor{LastID: lastID, Scope: scope})
+	}
+	return encodeCursor(pageCursor{Offset: offset + pq.PageSize, Scope: scope})
+}
+
+// countTotal 按需统计总数和总页数，跳过统计时返回 -1
+func countTotal(query *gorm.DB, pq models.PageQuery) (int, int, error) {
+	if !pq.WithTotal {
+		return -1, -1, nil
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	return int(total), int((total + int64(pq.PageSize) - 1) / int64(pq.PageSize)), nil
+}
+
+// optionalInt 把可选的整数条件转为参与 cursorScope 的值，未设置时为空串
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(*v)
+}
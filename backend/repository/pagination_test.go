@@ -0,0 +1,225 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// seedAnthology 建立包含全部作品的选集，选集内顺序与ID相反
+func seedAnthology(t *testing.T, db *gorm.DB, works []models.Work) {
+	t.Helper()
+	anthology := models.Anthology{Name: "tangshi300", DisplayName: "唐诗三百首"}
+	if err := db.Create(&anthology).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range works {
+		link := models.AnthologyWork{AnthologyID: anthology.ID, WorkID: w.ID, SortOrder: len(works) - i}
+		if err := db.Create(&link).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func workIDs(works []models.Work) []uint {
+	ids := make([]uint, len(works))
+	for i, w := range works {
+		ids[i] = w.ID
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeCursor 解出游标内容，用于构造篡改过的游标
+func decodeCursor(t *testing.T, cursor string) map[string]interface{} {
+	t.Helper()
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c map[string]interface{}
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func encodeCursor(c interface{}) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestKeysetPagination(t *testing.T) {
+	db := openTestDB(t)
+	works := seedCorpus(t, db)
+	repo := repository.NewPoetryRepositoryWithDB(db)
+	ctx := context.Background()
+
+	first, err := repo.GetPoems(ctx, models.PageQuery{Page: 1, PageSize: 2, WithTotal: true}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIDs(workIDs(first.Works), []uint{works[0].ID, works[1].ID}) || first.NextCursor == "" {
+		t.Fatalf("first page = %v, next %q", workIDs(first.Works), first.NextCursor)
+	}
+	if first.Total != 3 || first.TotalPages != 2 {
+		t.Fatalf("total = %d, pages = %d, want 3 and 2", first.Total, first.TotalPages)
+	}
+
+	// keyset 从上一页最后一条之后继续，不受前面插入的影响
+	late := models.Work{CategoryID: works[0].CategoryID, AuthorID: works[0].AuthorID, Title: "将进酒", Content: models.JSONArr{"君不见黄河之水天上来"}}
+	if err := db.Omit("Author", "Category").Create(&late).Error; err != nil {
+		t.Fatal(err)
+	}
+	second, err := repo.GetPoems(ctx, models.PageQuery{PageSize: 2, Cursor: first.NextCursor}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIDs(workIDs(second.Works), []uint{works[2].ID, late.ID}) || second.NextCursor != "" {
+		t.Fatalf("second page = %v, next %q", workIDs(second.Works), second.NextCursor)
+	}
+	if second.Total != -1 {
+		t.Fatalf("total without with_total = %d, want -1", second.Total)
+	}
+	if c := decodeCursor(t, first.NextCursor); c["id"] == nil || c["o"] != nil {
+		t.Fatalf("keyset cursor = %v, want last id and no offset", c)
+	}
+}
+
+func TestOffsetPagination(t *testing.T) {
+	db := openTestDB(t)
+	works := seedCorpus(t, db)
+	seedAnthology(t, db, works)
+	repo := repository.NewPoetryRepositoryWithDB(db)
+	ctx := context.Background()
+
+	// 选集按原书顺序排列，不能用 keyset，游标退回到偏移量
+	first, err := repo.GetPoems(ctx, models.PageQuery{Page: 1, PageSize: 2}, "", "tangshi300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIDs(workIDs(first.Works), []uint{works[2].ID, works[1].ID}) || first.NextCursor == "" {
+		t.Fatalf("first page = %v, next %q", workIDs(first.Works), first.NextCursor)
+	}
+	if c := decodeCursor(t, first.NextCursor); c["o"] != float64(2) || c["id"] != nil {
+		t.Fatalf("offset cursor = %v, want offset 2", c)
+	}
+
+	second, err := repo.GetPoems(ctx, models.PageQuery{PageSize: 2, Cursor: first.NextCursor}, "", "tangshi300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalIDs(workIDs(second.Works), []uint{works[0].ID}) || second.NextCursor != "" {
+		t.Fatalf("second page = %v, next %q", workIDs(second.Works), second.NextCursor)
+	}
+}
+
+func TestCursorReplayedWithOtherFilters(t *testing.T) {
+	db := openTestDB(t)
+	works := seedCorpus(t, db)
+	seedAnthology(t, db, works)
+	repo := repository.NewPoetryRepositoryWithDB(db)
+	ctx := context.Background()
+
+	all, err := repo.GetPoems(ctx, models.PageQuery{Page: 1, PageSize: 1}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anthology, err := repo.GetPoems(ctx, models.PageQuery{Page: 1, PageSize: 1}, "", "tangshi300")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		call func(cursor string) error
+		use  string
+	}{
+		{"poems cursor with category", func(cur string) error {
+			_, err := repo.GetPoems(ctx, models.PageQuery{PageSize: 1, Cursor: cur}, "tangshi", "")
+			return err
+		}, all.NextCursor},
+		{"poems cursor with anthology", func(cur string) error {
+			_, err := repo.GetPoems(ctx, models.PageQuery{PageSize: 1, Cursor: cur}, "", "tangshi300")
+			return err
+		}, all.NextCursor},
+		{"anthology cursor without anthology", func(cur string) error {
+			_, err := repo.GetPoems(ctx, models.PageQuery{PageSize: 1, Cursor: cur}, "", "")
+			return err
+		}, anthology.NextCursor},
+		{"poems cursor in search", func(cur string) error {
+			_, err := repo.Search(ctx, "明月", models.PageQuery{PageSize: 1, Cursor: cur})
+			return err
+		}, all.NextCursor},
+		{"poems cursor in authors", func(cur string) error {
+			_, err := repo.GetAuthors(ctx, models.PageQuery{PageSize: 1, Cursor: cur}, models.AuthorFilter{})
+			return err
+		}, all.NextCursor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(tt.use); !errors.Is(err, repository.ErrInvalidCursor) {
+				t.Fatalf("error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestMalformedCursor(t *testing.T) {
+	db := openTestDB(t)
+	works := seedCorpus(t, db)
+	seedAnthology(t, db, works)
+	repo := repository.NewPoetryRepositoryWithDB(db)
+	ctx := context.Background()
+
+	page, err := repo.GetPoems(ctx, models.PageQuery{Page: 1, PageSize: 1}, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scope := decodeCursor(t, page.NextCursor)["s"]
+	anthologyPage, err := repo.GetPoems(ctx, models.PageQuery{Page: 1, PageSize: 1}, "", "tangshi300")
+	if err != nil {
+		t.Fatal(err)
+	}
+	anthologyScope := decodeCursor(t, anthologyPage.NextCursor)["s"]
+
+	tests := []struct {
+		name      string
+		cursor    string
+		anthology string
+	}{
+		{"not base64", "!!!not-a-cursor", ""},
+		{"base64 garbage", base64.RawURLEncoding.EncodeToString([]byte{0xff, 0x00, 0x12}), ""},
+		{"json of wrong shape", encodeCursor([]int{1, 2}), ""},
+		{"forged scope", encodeCursor(map[string]interface{}{"id": 1, "s": 12345}), ""},
+		{"keyset cursor without id", encodeCursor(map[string]interface{}{"s": scope}), ""},
+		{"keyset cursor with offset only", encodeCursor(map[string]interface{}{"o": 1, "s": scope}), ""},
+		{"offset cursor with id", encodeCursor(map[string]interface{}{"id": 1, "s": anthologyScope}), "tangshi300"},
+		{"negative offset", encodeCursor(map[string]interface{}{"o": -1, "s": anthologyScope}), "tangshi300"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.GetPoems(ctx, models.PageQuery{PageSize: 1, Cursor: tt.cursor}, "", tt.anthology)
+			if !errors.Is(err, repository.ErrInvalidCursor) {
+				t.Fatalf("GetPoems(cursor %q) error = %v, want ErrInvalidCursor", tt.cursor, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"poem/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetRepository 找回密码令牌数据访问接口
+type PasswordResetRepository interface {
+	// Create 保存新令牌
+	Create(ctx context.Context, token *models.PasswordResetToken) error
+	// GetByTokenHash 根据令牌哈希获取记录
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error)
+	// MarkUsed 标记令牌已使用，令牌已被使用过时返回 false
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	// InvalidateForUser 作废用户所有未使用的令牌
+	InvalidateForUser(ctx context.Context, userID uint) error
+	// CountSince 统计用户 since 之后申请的令牌数
+	CountSince(ctx context.Context, userID uint, since time.Time) (int64, error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetRepository 创建找回密码令牌Repository
+func NewPasswordResetRepository(db *gorm.DB) (PasswordResetRepository, error) {
+	return &passwordResetRepository{db: db}, nil
+}
+
+func (r *passwordResetRepository) Create(ctx context.Context, token *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *passwordResetRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *passwordResetRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *passwordResetRepository) InvalidateForUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
+
+func (r *passwordResetRepository) CountSince(ctx context.Context, userID uint, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND created_at > ?", userID, since).
+		Count(&count).Error
+	return count, err
+}
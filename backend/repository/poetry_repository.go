@@ -1,16 +1,25 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"poem/backend/models"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
-	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // PoetryRepository 诗词数据仓库
 type PoetryRepository struct {
 	db *gorm.DB
+
+	searchOnce   sync.Once
+	searchEngine string
 }
 
 // DB 获取数据库连接
@@ -18,23 +27,35 @@ func (r *PoetryRepository) DB() *gorm.DB {
 	return r.db
 }
 
-// NewPoetryRepository 创建诗词仓库
-func NewPoetryRepository(dbPath string) (*PoetryRepository, *gorm.DB, error) {
-	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Silent),
-	})
+// NewPoetryRepository 创建诗词仓库，driver 为 sqlite、postgres 或 mysql，SQLite 的 dsn 为文件路径
+func NewPoetryRepository(driver, dsn string) (*PoetryRepository, *gorm.DB, error) {
+	db, err := OpenDB(driver, dsn)
 	if err != nil {
 		return nil, nil, err
 	}
 	return &PoetryRepository{db: db}, db, nil
 }
 
+// ConfigurePool 设置数据库连接池，0 表示不限制（lifetime 为 0 表示连接不过期）
+func ConfigurePool(db *gorm.DB, maxOpen, maxIdle int, lifetime time.Duration) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(maxOpen)
+	sqlDB.SetMaxIdleConns(maxIdle)
+	sqlDB.SetConnMaxLifetime(lifetime)
+	return nil
+}
+
 // GetPoems 获取诗词列表（分页）
-func (r *PoetryRepository) GetPoems(page, pageSize int, categoryName string) (models.PoemCollection, error) {
+func (r *PoetryRepository) GetPoems(ctx context.Context, pq models.PageQuery, categoryName, anthologyName string) (models.PoemCollection, error) {
+	db := r.db.WithContext(ctx)
 	var works []models.Work
-	var total int64
 
-	query := r.db.Model(&models.Work{}).Preload("Author").Preload("Category")
+	query := db.Model(&models.Work{}).Preload("Author").Preload("Category")
+	order := "works.id asc"
+	keyset := true
 
 	if categoryName != "" {
 		// Join categories table to filter by category name
@@ -42,33 +63,56 @@ func (r *PoetryRepository) GetPoems(page, pageSize int, categoryName string) (mo
 			Where("categories.name = ? OR categories.display_name = ?", categoryName, categoryName)
 	}
 
-	query.Count(&total)
+	if anthologyName != "" {
+		// 选集内按原书顺序排列，选集规模小，游标退回到偏移量
+		query = query.Joins("JOIN anthology_works ON anthology_works.work_id = works.id").
+			Joins("JOIN anthologies ON anthologies.id = anthology_works.anthology_id").
+			Where("anthologies.name = ? OR anthologies.display_name = ?", anthologyName, anthologyName)
+		order = "anthology_works.sort_order asc, works.id asc"
+		keyset = false
+	}
 
-	offset := (page - 1) * pageSize
-	err := query.Order("works.id asc").Offset(offset).Limit(pageSize).Find(&works).Error
+	total, totalPages, err := countTotal(query, pq)
 	if err != nil {
 		return models.PoemCollection{}, err
 	}
 
+	scope := cursorScope("poems", categoryName, anthologyName)
+	query, offset, err := applyPage(query.Order(order), pq, scope, "works.id", keyset)
+	if err != nil {
+		return models.PoemCollection{}, err
+	}
+	if err := query.Find(&works).Error; err != nil {
+		return models.PoemCollection{}, err
+	}
+
+	next := ""
+	if len(works) > pq.PageSize {
+		works = works[:pq.PageSize]
+		next = nextCursor(pq, scope, works[len(works)-1].ID, keyset, offset)
+	}
+
 	return models.PoemCollection{
 		Works:      works,
-		Total:      int(total),
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Total:      total,
+		Page:       pq.Page,
+		PageSize:   pq.PageSize,
+		TotalPages: totalPages,
+		NextCursor: next,
 	}, nil
 }
 
 // GetPoemByID 根据ID获取诗词
-func (r *PoetryRepository) GetPoemByID(id string) (*models.Work, error) {
+func (r *PoetryRepository) GetPoemByID(ctx context.Context, id string) (*models.Work, error) {
+	db := r.db.WithContext(ctx)
 	var work models.Work
 	// Try searching by OriginalID first, then Primary Key ID if it's numeric
-	err := r.db.Preload("Author").Preload("Category").Preload("Comments").
+	err := db.Preload("Author").Preload("Category").Preload("Comments").Preload("Anthologies").
 		Where("original_id = ?", id).First(&work).Error
 
 	if err != nil {
 		// If not found by original_id, try by primary key
-		err = r.db.Preload("Author").Preload("Category").Preload("Comments").
+		err = db.Preload("Author").Preload("Category").Preload("Comments").Preload("Anthologies").
 			First(&work, "id = ?", id).Error
 		if err != nil {
 			return nil, err
@@ -78,18 +122,18 @@ func (r *PoetryRepository) GetPoemByID(id string) (*models.Work, error) {
 }
 
 // GetRandomPoems 获取随机诗词
-func (r *PoetryRepository) GetRandomPoems(count int, categoryName string) ([]models.Work, error) {
+func (r *PoetryRepository) GetRandomPoems(ctx context.Context, count int, categoryName string) ([]models.Work, error) {
+	db := r.db.WithContext(ctx)
 	var works []models.Work
 
-	query := r.db.Model(&models.Work{}).Preload("Author").Preload("Category")
+	query := db.Model(&models.Work{}).Preload("Author").Preload("Category")
 
 	if categoryName != "" {
 		query = query.Joins("JOIN categories ON categories.id = works.category_id").
 			Where("categories.name = ? OR categories.display_name = ?", categoryName, categoryName)
 	}
 
-	// SQLite 随机排序
-	err := query.Order("RANDOM()").Limit(count).Find(&works).Error
+	err := query.Order(randomOrder(db)).Limit(count).Find(&works).Error
 	if err != nil {
 		return nil, err
 	}
@@ -98,105 +142,353 @@ func (r *PoetryRepository) GetRandomPoems(count int, categoryName string) ([]mod
 }
 
 // GetPoemsByAuthor 根据作者获取诗词
-func (r *PoetryRepository) GetPoemsByAuthor(authorName string, page, pageSize int) (models.PoemCollection, error) {
+func (r *PoetryRepository) GetPoemsByAuthor(ctx context.Context, authorName, dynasty string, pq models.PageQuery) (models.PoemCollection, error) {
+	db := r.db.WithContext(ctx)
 	var works []models.Work
-	var total int64
 
 	// Find Author first
-	var author models.Author
-	if err := r.db.Where("name = ?", authorName).First(&author).Error; err != nil {
+	author, err := r.resolveAuthor(ctx, authorName, dynasty)
+	if err != nil {
 		return models.PoemCollection{}, err
 	}
 
-	query := r.db.Model(&models.Work{}).Preload("Author").Preload("Category").Where("author_id = ?", author.ID)
-	query.Count(&total)
+	query := db.Model(&models.Work{}).Preload("Author").Preload("Category").Where("author_id = ?", author.ID)
+	total, totalPages, err := countTotal(query, pq)
+	if err != nil {
+		return models.PoemCollection{}, err
+	}
 
-	offset := (page - 1) * pageSize
-	err := query.Offset(offset).Limit(pageSize).Find(&works).Error
+	scope := cursorScope("author_poems", author.ID)
+	query, _, err = applyPage(query.Order("works.id asc"), pq, scope, "works.id", true)
 	if err != nil {
 		return models.PoemCollection{}, err
 	}
+	if err := query.Find(&works).Error; err != nil {
+		return models.PoemCollection{}, err
+	}
+
+	next := ""
+	if len(works) > pq.PageSize {
+		works = works[:pq.PageSize]
+		next = nextCursor(pq, scope, works[len(works)-1].ID, true, 0)
+	}
 
 	return models.PoemCollection{
 		Works:      works,
-		Total:      int(total),
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Total:      total,
+		Page:       pq.Page,
+		PageSize:   pq.PageSize,
+		TotalPages: totalPages,
+		NextCursor: next,
 	}, nil
 }
 
+// authorSortColumns 允许排序的作者字段，生卒年未知的排在最后
+var authorSortColumns = map[string]string{
+	"id":         "id %s",
+	"name":       "name %s, id asc",
+	"birth_year": "birth_year IS NULL, birth_year %s, id asc",
+	"death_year": "death_year IS NULL, death_year %s, id asc",
+}
+
 // GetAuthors 获取作者列表
-func (r *PoetryRepository) GetAuthors(page, pageSize int, dynasty string) (models.AuthorCollection, error) {
+func (r *PoetryRepository) GetAuthors(ctx context.Context, pq models.PageQuery, filter models.AuthorFilter) (models.AuthorCollection, error) {
+	db := r.db.WithContext(ctx)
 	var authors []models.Author
-	var total int64
 
-	query := r.db.Model(&models.Author{})
-	if dynasty != "" {
-		query = query.Where("dynasty = ?", dynasty)
+	query := db.Model(&models.Author{})
+	if filter.Dynasty != "" {
+		query = query.Where("dynasty_id IN (?)", db.Model(&models.Dynasty{}).Select("id").Where("name = ?", filter.Dynasty))
+	}
+	if filter.AliveIn != nil {
+		query = query.Where("birth_year <= ? AND death_year >= ?", *filter.AliveIn, *filter.AliveIn)
+	}
+	if filter.BornAfter != nil {
+		query = query.Where("birth_year >= ?", *filter.BornAfter)
+	}
+	if filter.BornBefore != nil {
+		query = query.Where("birth_year <= ?", *filter.BornBefore)
 	}
 
-	query.Count(&total)
+	total, totalPages, err := countTotal(query, pq)
+	if err != nil {
+		return models.AuthorCollection{}, err
+	}
+
+	order, ok := authorSortColumns[filter.SortBy]
+	if !ok {
+		order = authorSortColumns["id"]
+	}
+	direction := "asc"
+	if filter.Desc {
+		direction = "desc"
+	}
+	// 只有按 ID 升序时才能用 keyset，其余排序使用偏移量游标
+	keyset := order == authorSortColumns["id"] && !filter.Desc
 
-	offset := (page - 1) * pageSize
-	err := query.Offset(offset).Limit(pageSize).Find(&authors).Error
+	scope := cursorScope("authors", filter.Dynasty, optionalInt(filter.AliveIn), optionalInt(filter.BornAfter), optionalInt(filter.BornBefore), filter.SortBy, filter.Desc)
+	query, offset, err := applyPage(query.Order(fmt.Sprintf(order, direction)), pq, scope, "id", keyset)
 	if err != nil {
 		return models.AuthorCollection{}, err
 	}
+	if err := query.Find(&authors).Error; err != nil {
+		return models.AuthorCollection{}, err
+	}
+
+	next := ""
+	if len(authors) > pq.PageSize {
+		authors = authors[:pq.PageSize]
+		next = nextCursor(pq, scope, authors[len(authors)-1].ID, keyset, offset)
+	}
 
 	return models.AuthorCollection{
 		Authors:    authors,
-		Total:      int(total),
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Total:      total,
+		Page:       pq.Page,
+		PageSize:   pq.PageSize,
+		TotalPages: totalPages,
+		NextCursor: next,
 	}, nil
 }
 
-// GetAuthorByName 根据名称获取作者
-func (r *PoetryRepository) GetAuthorByName(name string) (*models.Author, error) {
+// GetAuthorByName 根据名称获取作者，支持字、号等别名
+func (r *PoetryRepository) GetAuthorByName(ctx context.Context, name, dynasty string) (*models.Author, error) {
+	db := r.db.WithContext(ctx)
+	author, err := r.resolveAuthor(ctx, name, dynasty)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Model(author).Association("Aliases").Find(&author.Aliases); err != nil {
+		return nil, err
+	}
+	if author.DynastyID != 0 {
+		var dynastyInfo models.Dynasty
+		if err := db.First(&dynastyInfo, author.DynastyID).Error; err == nil {
+			author.DynastyInfo = &dynastyInfo
+		}
+	}
+	return author, nil
+}
+
+// resolveAuthor 按名称解析作者：先查正名，再查别名。
+// 同名作者分属多个朝代且未指定朝代时，取作品最多的一位，保证结果稳定
+func (r *PoetryRepository) resolveAuthor(ctx context.Context, name, dynasty string) (*models.Author, error) {
+	db := r.db.WithContext(ctx)
+	var authors []models.Author
+	query := db.Model(&models.Author{}).Where("authors.name = ?", name)
+	if dynasty != "" {
+		query = query.Where("authors.dynasty_id IN (?)", db.Model(&models.Dynasty{}).Select("id").Where("name = ?", dynasty))
+	}
+	err := query.Select("authors.*, (SELECT COUNT(*) FROM works WHERE works.author_id = authors.id) AS work_count").
+		Order("work_count desc, authors.id asc").Limit(1).Find(&authors).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(authors) > 0 {
+		return &authors[0], nil
+	}
+
+	var alias models.AuthorAlias
+	aliasQuery := db.Where("name = ?", name)
+	if dynasty != "" {
+		aliasQuery = aliasQuery.Where("dynasty = ? OR dynasty = ''", dynasty)
+	}
+	if err := aliasQuery.Order("id asc").First(&alias).Error; err != nil {
+		return nil, err
+	}
+
+	var author models.Author
+	if err := db.First(&author, alias.AuthorID).Error; err != nil {
+		return nil, err
+	}
+	return &author, nil
+}
+
+// AddAuthorAlias 为作者添加别名；别名已存在时改指向该作者
+func (r *PoetryRepository) AddAuthorAlias(ctx context.Context, authorID uint, name, dynasty, aliasType string) (*models.AuthorAlias, error) {
+	db := r.db.WithContext(ctx)
 	var author models.Author
-	err := r.db.Where("name = ?", name).First(&author).Error
+	if err := db.First(&author, authorID).Error; err != nil {
+		return nil, err
+	}
+
+	alias := models.AuthorAlias{Name: name, Dynasty: dynasty}
+	err := db.Where(models.AuthorAlias{Name: name, Dynasty: dynasty}).
+		Assign(models.AuthorAlias{AuthorID: authorID, Type: aliasType}).
+		FirstOrCreate(&alias).Error
 	if err != nil {
 		return nil, err
 	}
+	return &alias, nil
+}
+
+// UpdateAuthorProfile 手工修正作者资料，标记为 manual 后重新导入时会保留
+func (r *PoetryRepository) UpdateAuthorProfile(ctx context.Context, authorID uint, profile models.AuthorProfile) (*models.Author, error) {
+	db := r.db.WithContext(ctx)
+	var author models.Author
+	if err := db.First(&author, authorID).Error; err != nil {
+		return nil, err
+	}
+
+	profile.Source = "manual"
+	author.Profile = profile
+	if err := db.Save(&author).Error; err != nil {
+		return nil, err
+	}
 	return &author, nil
 }
 
+// MergeAuthors 把 fromID 的作品和别名并入 intoID，原名记为 merged 别名后删除 fromID
+func (r *PoetryRepository) MergeAuthors(ctx context.Context, fromID, intoID uint) error {
+	if fromID == intoID {
+		return errors.New("cannot merge an author into itself")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var from, into models.Author
+		if err := tx.First(&from, fromID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&into, intoID).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Work{}).Where("author_id = ?", from.ID).
+			Update("author_id", into.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AuthorAlias{}).Where("author_id = ?", from.ID).
+			Update("author_id", into.ID).Error; err != nil {
+			return err
+		}
+
+		merged := models.AuthorAlias{Name: from.Name, Dynasty: from.Dynasty}
+		if err := tx.Where(models.AuthorAlias{Name: from.Name, Dynasty: from.Dynasty}).
+			Assign(models.AuthorAlias{AuthorID: into.ID, Type: "merged"}).
+			FirstOrCreate(&merged).Error; err != nil {
+			return err
+		}
+
+		if into.Biography == "" && from.Biography != "" {
+			if err := tx.Model(&into).Update("biography", from.Biography).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&from).Error
+	})
+}
+
 // Search 搜索诗词
-func (r *PoetryRepository) Search(queryStr string, page, pageSize int) (models.SearchResponse, error) {
+func (r *PoetryRepository) Search(ctx context.Context, queryStr string, pq models.PageQuery) (models.SearchResponse, error) {
+	db := r.db.WithContext(ctx)
 	var works []models.Work
-	var total int64
 
-	// 使用 LIKE 进行模糊搜索
+	// 使用 LIKE 进行模糊搜索；MySQL 有全文索引时标题和正文改用 ngram 全文匹配（至少两个字）
 	likeStr := "%" + queryStr + "%"
 
 	// Join with Author to search by author name as well
-	query := r.db.Model(&models.Work{}).Preload("Author").Preload("Category").
-		Joins("LEFT JOIN authors ON authors.id = works.author_id").
-		Where("works.title LIKE ? OR works.content LIKE ? OR authors.name LIKE ?", likeStr, likeStr, likeStr)
+	query := db.Model(&models.Work{}).Preload("Author").Preload("Category").
+		Joins("LEFT JOIN authors ON authors.id = works.author_id")
+	if r.SearchEngine(ctx) == SearchFulltext && utf8.RuneCountInString(queryStr) >= 2 {
+		phrase := `"` + strings.ReplaceAll(queryStr, `"`, " ") + `"`
+		query = query.Where("MATCH(works.title, works.content) AGAINST (? IN BOOLEAN MODE) OR authors.name LIKE ?", phrase, likeStr)
+	} else {
+		query = query.Where("works.title LIKE ? OR works.content LIKE ? OR authors.name LIKE ?", likeStr, likeStr, likeStr)
+	}
 
-	query.Count(&total)
+	total, totalPages, err := countTotal(query, pq)
+	if err != nil {
+		return models.SearchResponse{}, err
+	}
 
-	offset := (page - 1) * pageSize
-	err := query.Offset(offset).Limit(pageSize).Find(&works).Error
+	scope := cursorScope("search", queryStr)
+	query, _, err = applyPage(query.Order("works.id asc"), pq, scope, "works.id", true)
 	if err != nil {
 		return models.SearchResponse{}, err
 	}
+	if err := query.Find(&works).Error; err != nil {
+		return models.SearchResponse{}, err
+	}
+
+	next := ""
+	if len(works) > pq.PageSize {
+		works = works[:pq.PageSize]
+		next = nextCursor(pq, scope, works[len(works)-1].ID, true, 0)
+	}
 
 	return models.SearchResponse{
 		Works:      works,
-		Total:      int(total),
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+		Total:      total,
+		Page:       pq.Page,
+		PageSize:   pq.PageSize,
+		TotalPages: totalPages,
 		Query:      queryStr,
+		NextCursor: next,
 	}, nil
 }
 
 // GetCategories 获取所有分类
-func (r *PoetryRepository) GetCategories() ([]models.Category, error) {
+func (r *PoetryRepository) GetCategories(ctx context.Context) ([]models.Category, error) {
+	db := r.db.WithContext(ctx)
 	var categories []models.Category
-	err := r.db.Find(&categories).Error
+	err := db.Find(&categories).Error
 	return categories, err
 }
+
+// GetAnthologies 获取所有选集
+func (r *PoetryRepository) GetAnthologies(ctx context.Context) ([]models.Anthology, error) {
+	db := r.db.WithContext(ctx)
+	var anthologies []models.Anthology
+	err := db.Find(&anthologies).Error
+	return anthologies, err
+}
+
+// GetDynasties 获取朝代列表（按时间先后），附带作者和作品数量
+func (r *PoetryRepository) GetDynasties(ctx context.Context) ([]models.DynastyStats, error) {
+	db := r.db.WithContext(ctx)
+	var dynasties []models.DynastyStats
+	err := db.Model(&models.Dynasty{}).
+		Select(`dynasties.*,
+			(SELECT COUNT(*) FROM authors WHERE authors.dynasty_id = dynasties.id) AS author_count,
+			(SELECT COUNT(*) FROM works JOIN authors ON authors.id = works.author_id WHERE authors.dynasty_id = dynasties.id) AS work_count`).
+		Order("dynasties.sort_order asc, dynasties.id asc").
+		Scan(&dynasties).Error
+	return dynasties, err
+}
+
+// DataVersion 获取数据版本
+func (r *PoetryRepository) DataVersion(ctx context.Context, name string) (*models.DataVersion, error) {
+	var version models.DataVersion
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// LatestImportRun 获取最近一次 ETL 导入记录，尚未导入过时返回 nil
+func (r *PoetryRepository) LatestImportRun(ctx context.Context) (*models.ImportRun, error) {
+	db := r.db.WithContext(ctx)
+	if !db.Migrator().HasTable(&models.ImportRun{}) {
+		return nil, nil
+	}
+	var run models.ImportRun
+	err := db.Order("finished_at desc").Limit(1).Find(&run).Error
+	if err != nil || run.ID == 0 {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// SearchEngine 搜索实现（like、trigram 或 fulltext），首次调用时检查索引并缓存，
+// 运行 ETL 重建索引后需重启服务
+func (r *PoetryRepository) SearchEngine(ctx context.Context) string {
+	r.searchOnce.Do(func() {
+		r.searchEngine = SearchEngine(ctx, r.db)
+		if r.searchEngine == SearchLike && r.db.Dialector.Name() != DriverSQLite {
+			slog.WarnContext(ctx, "full-text search index missing, search falls back to LIKE scans; re-run `manage etl` to create it",
+				"driver", r.db.Dialector.Name())
+		}
+	})
+	return r.searchEngine
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"poem/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RevokedTokenRepository 已吊销access token数据访问接口，实现 auth.DenylistStore
+type RevokedTokenRepository interface {
+	// Add 记录被吊销的 jti，重复记录会被忽略
+	Add(ctx context.Context, jti string, userID uint, reason string, expiresAt time.Time) error
+	// IsRevoked 查询 jti 是否已被吊销
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	// DeleteExpired 删除 before 之前已过期的记录
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type revokedTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRevokedTokenRepository 创建吊销记录Repository
+func NewRevokedTokenRepository(db *gorm.DB) (RevokedTokenRepository, error) {
+	return &revokedTokenRepository{db: db}, nil
+}
+
+func (r *revokedTokenRepository) Add(ctx context.Context, jti string, userID uint, reason string, expiresAt time.Time) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		Reason:    reason,
+		ExpiresAt: expiresAt,
+	}).Error
+}
+
+func (r *revokedTokenRepository) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *revokedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at <= ?", before).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"poem/backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository 登录会话（刷新令牌）数据访问接口
+type SessionRepository interface {
+	// Create 保存新签发的刷新令牌
+	Create(ctx context.Context, session *models.UserSession) error
+	// GetByTokenHash 根据刷新令牌哈希获取记录
+	GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error)
+	// MarkRotated 标记令牌已被轮换，令牌已被轮换过时返回 false
+	MarkRotated(ctx context.Context, id uint) (bool, error)
+	// RevokeFamily 作废整个会话
+	RevokeFamily(ctx context.Context, familyID string) error
+	// RevokeUserSession 作废用户的某个会话，会话不存在时返回 false
+	RevokeUserSession(ctx context.Context, userID uint, familyID string) (bool, error)
+	// RevokeAllForUser 作废用户除 exceptFamilyID 外的所有会话
+	RevokeAllForUser(ctx context.Context, userID uint, exceptFamilyID string) error
+	// ListActive 列出用户当前有效的会话
+	ListActive(ctx context.Context, userID uint) ([]models.UserSession, error)
+	// ListUnexpiredAccess 列出用户所有access token尚未过期的令牌记录（含已轮换、已作废的）
+	ListUnexpiredAccess(ctx context.Context, userID uint) ([]models.UserSession, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository 创建会话Repository
+func NewSessionRepository(db *gorm.DB) (SessionRepository, error) {
+	return &sessionRepository{db: db}, nil
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.UserSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*models.UserSession, error) {
+	var session models.UserSession
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) MarkRotated(ctx context.Context, id uint) (bool, error) {
+	// 条件更新保证并发刷新时只有一个请求能成功轮换
+	result := r.db.WithContext(ctx).Model(&models.UserSession{}).
+		Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+		Update("rotated_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *sessionRepository) RevokeFamily(ctx context.Context, familyID string) error {
+	return r.db.WithContext(ctx).Model(&models.UserSession{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *sessionRepository) RevokeUserSession(ctx context.Context, userID uint, familyID string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.UserSession{}).
+		Where("user_id = ? AND family_id = ? AND revoked_at IS NULL", userID, familyID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uint, exceptFamilyID string) error {
+	query := r.db.WithContext(ctx).Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptFamilyID != "" {
+		query = query.Where("family_id <> ?", exceptFamilyID)
+	}
+	return query.Update("revoked_at", time.Now()).Error
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("created_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) ListUnexpiredAccess(ctx context.Context, userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND access_jti <> '' AND access_exp > ?", userID, time.Now()).
+		Find(&sessions).Error
+	return sessions, err
+}
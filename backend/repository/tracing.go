@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"poem/backend/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var tracer = otel.Tracer("poem/backend/repository")
+
+// tracingPlugin 通过 GORM 回调为每条语句创建 span，父 span 取自 WithContext 传入的 ctx
+type tracingPlugin struct{}
+
+const tracingSpanKey = "tracing:span"
+
+// InstrumentTracing 为数据库连接注册链路追踪，span 中的 SQL 保留 ? 占位符，不含参数值
+func InstrumentTracing(db *gorm.DB) error {
+	return db.Use(tracingPlugin{})
+}
+
+func (tracingPlugin) Name() string { return "tracing" }
+
+func (p tracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		{"query", cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		{"update", cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		{"delete", cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		{"row", cb.Row().Before("*").Register, cb.Row().After("*").Register},
+		{"raw", cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+	for _, proc := range processors {
+		if err := proc.before("tracing:before_"+proc.operation, p.before(proc.operation)); err != nil {
+			return err
+		}
+		if err := proc.after("tracing:after_"+proc.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tracingPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx := db.Statement.Context
+		// 没有父 span 的语句（启动时的迁移等）不单独成链
+		if !trace.SpanContextFromContext(ctx).IsValid() {
+			return
+		}
+		_, span := tracer.Start(ctx, "db."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system.name", db.Dialector.Name()),
+				attribute.String("db.operation.name", operation),
+			),
+		)
+		db.InstanceSet(tracingSpanKey, span)
+	}
+}
+
+func (tracingPlugin) after(db *gorm.DB) {
+	v, ok := db.InstanceGet(tracingSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := v.(trace.Span)
+	if !ok {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		attribute.String("db.query.text", db.Statement.SQL.String()),
+		attribute.Int64("db.response.returned_rows", db.Statement.RowsAffected),
+	}
+	if db.Statement.Table != "" {
+		attrs = append(attrs, attribute.String("db.collection.name", db.Statement.Table))
+	}
+	span.SetAttributes(attrs...)
+	tracing.End(span, db.Error)
+}
@@ -15,6 +15,8 @@ type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
 	// GetByID 根据ID获取用户
 	GetByID(ctx context.Context, id uint) (*models.User, error)
+	// GetByIDAnyStatus 根据ID获取用户，包括已禁用的用户
+	GetByIDAnyStatus(ctx context.Context, id uint) (*models.User, error)
 	// GetByUsername 根据用户名获取用户
 	GetByUsername(ctx context.Context, username string) (*models.User, error)
 	// GetByEmail 根据邮箱获取用户
@@ -25,6 +27,8 @@ type UserRepository interface {
 	Update(ctx context.Context, user *models.User) error
 	// UpdateLastLogin 更新最后登录时间
 	UpdateLastLogin(ctx context.Context, userID uint) error
+	// AddExperience 增加经验值
+	AddExperience(ctx context.Context, userID uint, amount int) error
 	// AddFavorite 添加收藏
 	AddFavorite(ctx context.Context, userID, targetID uint, targetType string) error
 	// RemoveFavorite 取消收藏
@@ -43,10 +47,6 @@ type userRepository struct {
 
 // NewUserRepository 创建用户Repository
 func NewUserRepository(db *gorm.DB) (UserRepository, error) {
-	// 自动迁移表结构
-	if err := db.AutoMigrate(&models.User{}, &models.UserFavorite{}, &models.UserHistory{}); err != nil {
-		return nil, err
-	}
 	return &userRepository{db: db}, nil
 }
 
@@ -63,6 +63,15 @@ func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, er
 	return &user, nil
 }
 
+func (r *userRepository) GetByIDAnyStatus(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
 	err := r.db.WithContext(ctx).Where("username = ?", username).First(&user).Error
@@ -101,6 +110,12 @@ func (r *userRepository) UpdateLastLogin(ctx context.Context, userID uint) error
 		Update("last_login_at", now).Error
 }
 
+func (r *userRepository) AddExperience(ctx context.Context, userID uint, amount int) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		Update("experience", gorm.Expr("experience + ?", amount)).Error
+}
+
 func (r *userRepository) AddFavorite(ctx context.Context, userID, targetID uint, targetType string) error {
 	favorite := &models.UserFavorite{
 		UserID:    userID,
@@ -0,0 +1,15 @@
+// Package cache 服务层的查询结果缓存
+package cache
+
+// Cache 键值缓存。实现需并发安全，过期和淘汰策略由实现决定；
+// 缓存的值会被多个请求共享，调用方取出后不能修改
+type Cache interface {
+	// Get 获取未过期的值
+	Get(key string) (interface{}, bool)
+	// Set 写入值，超出容量时淘汰旧条目
+	Set(key string, value interface{})
+	// Purge 清空全部条目，数据变更后调用
+	Purge()
+	// Stats 累计命中和未命中次数
+	Stats() (hits, misses uint64)
+}
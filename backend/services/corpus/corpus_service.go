@@ -0,0 +1,555 @@
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"poem/backend/models"
+	"poem/backend/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWorkNotFound     = errors.New("作品不存在")
+	ErrAuthorNotFound   = errors.New("作者不存在")
+	ErrCommentNotFound  = errors.New("注释不存在")
+	ErrCategoryNotFound = errors.New("分类不存在")
+	ErrDynastyNotFound  = errors.New("朝代不存在")
+	ErrAuthorHasWorks   = errors.New("作者名下还有作品，请先转移或删除")
+	ErrIncompleteWork   = errors.New("新建作品需要标题、正文、作者和分类")
+	ErrIncompleteAuthor = errors.New("新建作者需要姓名和朝代")
+)
+
+// CorpusService 语料编辑服务。所有修改同时记录为 CorpusOverride 和 WorkRevision，
+// 前者在重新运行 ETL 后会再次应用，后者用于查看修订历史和回滚
+type CorpusService struct {
+	repo repository.CorpusRepository
+}
+
+// NewCorpusService 创建语料编辑服务
+func NewCorpusService(repo repository.CorpusRepository) *CorpusService {
+	return &CorpusService{repo: repo}
+}
+
+// Edit 一次修改的操作人和原因，写入修订历史
+type Edit struct {
+	EditorID   uint
+	Reason     string
+	rollbackOf uint // 由回滚产生的修改，指向回滚到的修订
+}
+
+// WorkRequest 新建或修改作品的请求，修改时只处理非空字段
+type WorkRequest struct {
+	Title    *string   `json:"title" binding:"omitempty,max=255"`
+	Rhythmic *string   `json:"rhythmic" binding:"omitempty,max=255"`
+	Volume   *string   `json:"volume" binding:"omitempty,max=100"`
+	Section  *string   `json:"section" binding:"omitempty,max=100"`
+	Prologue *string   `json:"prologue"`
+	Content  *[]string `json:"content" binding:"omitempty,min=1"`
+	AuthorID *uint     `json:"author_id"`
+	Category *string   `json:"category"` // 分类名，如 quantangshi
+	Reason   string    `json:"reason" binding:"max=255"`
+}
+
+// AuthorRequest 新建或修改作者的请求，修改时只处理非空字段
+type AuthorRequest struct {
+	Name      *string `json:"name" binding:"omitempty,min=1,max=255"`
+	Dynasty   *string `json:"dynasty" binding:"omitempty,max=50"`
+	Biography *string `json:"biography"`
+	Reason    string  `json:"reason" binding:"max=255"`
+}
+
+// CommentRequest 新建或修改注释的请求
+type CommentRequest struct {
+	WorkID         uint   `json:"work_id"` // 仅新建时使用
+	Content        string `json:"content" binding:"required"`
+	Type           string `json:"type" binding:"omitempty,oneof=note comment translation"`
+	Commenter      string `json:"commenter" binding:"max=100"`
+	ParagraphIndex int    `json:"paragraph_index"`
+	Reason         string `json:"reason" binding:"max=255"`
+}
+
+// WorkDetail 作品及其修改记录
+type WorkDetail struct {
+	Work     *models.Work           `json:"work"`
+	Override *models.CorpusOverride `json:"override,omitempty"`
+}
+
+// GetWork 获取作品及其修改记录
+func (s *CorpusService) GetWork(ctx context.Context, id uint) (*WorkDetail, error) {
+	work, err := s.repo.GetWork(ctx, id)
+	if err != nil {
+		return nil, ErrWorkNotFound
+	}
+	detail := &WorkDetail{Work: work}
+	if override, err := s.repo.GetOverride(ctx, models.OverrideEntityWork, workKey(work)); err == nil {
+		detail.Override = override
+	}
+	return detail, nil
+}
+
+// CreateWork 新建作品
+func (s *CorpusService) CreateWork(ctx context.Context, edit Edit, req *WorkRequest) (*models.Work, error) {
+	if req.Title == nil || req.Content == nil || req.AuthorID == nil || req.Category == nil {
+		return nil, ErrIncompleteWork
+	}
+
+	work := &models.Work{SourceKey: "admin:" + uuid.New().String()}
+	patch, err := s.applyWorkRequest(ctx, work, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新建的作品在 ETL 中需要完整重建，因此记录所有字段
+	patch.Rhythmic = orEmpty(patch.Rhythmic)
+	patch.Volume = orEmpty(patch.Volume)
+	patch.Section = orEmpty(patch.Section)
+	patch.Prologue = orEmpty(patch.Prologue)
+
+	override, err := s.workOverride(ctx, work, edit.EditorID, models.OverrideActionCreate, patch)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := newRevision(models.OverrideEntityWork, workKey(work), models.OverrideActionCreate, edit, nil, models.NewWorkSnapshot(work))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveWork(ctx, work, override, revision); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWork(ctx, work.ID)
+}
+
+// UpdateWork 修改作品
+func (s *CorpusService) UpdateWork(ctx context.Context, edit Edit, id uint, req *WorkRequest) (*models.Work, error) {
+	work, err := s.repo.GetWork(ctx, id)
+	if err != nil {
+		return nil, ErrWorkNotFound
+	}
+	// 先确定 SourceKey，修改标题或正文不应改变它
+	workKey(work)
+	before := models.NewWorkSnapshot(work)
+
+	patch, err := s.applyWorkRequest(ctx, work, req)
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := s.workOverride(ctx, work, edit.EditorID, models.OverrideActionUpdate, patch)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := newRevision(models.OverrideEntityWork, workKey(work), models.OverrideActionUpdate, edit, before, models.NewWorkSnapshot(work))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveWork(ctx, work, override, revision); err != nil {
+		return nil, err
+	}
+	return s.repo.GetWork(ctx, work.ID)
+}
+
+// DeleteWork 删除作品，注释随作品一起删除，修订中只保存作品本身的快照
+func (s *CorpusService) DeleteWork(ctx context.Context, edit Edit, id uint) error {
+	work, err := s.repo.GetWork(ctx, id)
+	if err != nil {
+		return ErrWorkNotFound
+	}
+
+	override, err := s.workOverride(ctx, work, edit.EditorID, models.OverrideActionDelete, models.WorkPatch{})
+	if err != nil {
+		return err
+	}
+	revision, err := newRevision(models.OverrideEntityWork, workKey(work), models.OverrideActionDelete, edit, models.NewWorkSnapshot(work), nil)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteWork(ctx, work, override, revision)
+}
+
+// applyWorkRequest 把请求写入作品，返回对应的修改
+func (s *CorpusService) applyWorkRequest(ctx context.Context, work *models.Work, req *WorkRequest) (models.WorkPatch, error) {
+	patch := models.WorkPatch{
+		Title:    req.Title,
+		Rhythmic: req.Rhythmic,
+		Volume:   req.Volume,
+		Section:  req.Section,
+		Prologue: req.Prologue,
+		Content:  req.Content,
+	}
+
+	if req.AuthorID != nil {
+		author, err := s.repo.GetAuthor(ctx, *req.AuthorID)
+		if err != nil {
+			return patch, ErrAuthorNotFound
+		}
+		work.AuthorID = author.ID
+		work.Author = *author
+		key := authorKey(author)
+		patch.AuthorKey = &key
+	}
+
+	if req.Category != nil {
+		category, err := s.repo.GetCategoryByName(ctx, *req.Category)
+		if err != nil {
+			return patch, ErrCategoryNotFound
+		}
+		work.CategoryID = category.ID
+		work.Category = *category
+		patch.Category = &category.Name
+	}
+
+	patch.ApplyTo(work)
+	return patch, nil
+}
+
+// GetAuthor 获取作者
+func (s *CorpusService) GetAuthor(ctx context.Context, id uint) (*models.Author, error) {
+	author, err := s.repo.GetAuthor(ctx, id)
+	if err != nil {
+		return nil, ErrAuthorNotFound
+	}
+	return author, nil
+}
+
+// CreateAuthor 新建作者
+func (s *CorpusService) CreateAuthor(ctx context.Context, edit Edit, req *AuthorRequest) (*models.Author, error) {
+	if req.Name == nil || *req.Name == "" || req.Dynasty == nil {
+		return nil, ErrIncompleteAuthor
+	}
+
+	author := &models.Author{SourceKey: "admin:" + uuid.New().String()}
+	patch, err := s.applyAuthorRequest(ctx, author, req)
+	if err != nil {
+		return nil, err
+	}
+	patch.Biography = orEmpty(patch.Biography)
+
+	override, err := s.authorOverride(ctx, author, edit.EditorID, models.OverrideActionCreate, patch)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := newRevision(models.OverrideEntityAuthor, authorKey(author), models.OverrideActionCreate, edit, nil, models.NewAuthorSnapshot(author))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveAuthor(ctx, author, override, revision); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// UpdateAuthor 修改作者
+func (s *CorpusService) UpdateAuthor(ctx context.Context, edit Edit, id uint, req *AuthorRequest) (*models.Author, error) {
+	author, err := s.repo.GetAuthor(ctx, id)
+	if err != nil {
+		return nil, ErrAuthorNotFound
+	}
+	// 先确定 SourceKey，改名不应改变它
+	authorKey(author)
+	before := models.NewAuthorSnapshot(author)
+
+	patch, err := s.applyAuthorRequest(ctx, author, req)
+	if err != nil {
+		return nil, err
+	}
+
+	override, err := s.authorOverride(ctx, author, edit.EditorID, models.OverrideActionUpdate, patch)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := newRevision(models.OverrideEntityAuthor, authorKey(author), models.OverrideActionUpdate, edit, before, models.NewAuthorSnapshot(author))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveAuthor(ctx, author, override, revision); err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// DeleteAuthor 删除没有作品的作者
+func (s *CorpusService) DeleteAuthor(ctx context.Context, edit Edit, id uint) error {
+	author, err := s.repo.GetAuthor(ctx, id)
+	if err != nil {
+		return ErrAuthorNotFound
+	}
+
+	count, err := s.repo.CountWorksByAuthor(ctx, author.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrAuthorHasWorks
+	}
+
+	override, err := s.authorOverride(ctx, author, edit.EditorID, models.OverrideActionDelete, models.AuthorPatch{})
+	if err != nil {
+		return err
+	}
+	revision, err := newRevision(models.OverrideEntityAuthor, authorKey(author), models.OverrideActionDelete, edit, models.NewAuthorSnapshot(author), nil)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteAuthor(ctx, author, override, revision)
+}
+
+// applyAuthorRequest 把请求写入作者，返回对应的修改
+func (s *CorpusService) applyAuthorRequest(ctx context.Context, author *models.Author, req *AuthorRequest) (models.AuthorPatch, error) {
+	patch := models.AuthorPatch{
+		Name:      req.Name,
+		Biography: req.Biography,
+	}
+
+	if req.Dynasty != nil {
+		dynasty, err := s.repo.GetDynastyByName(ctx, models.NormalizeDynasty(*req.Dynasty))
+		if err != nil {
+			return patch, ErrDynastyNotFound
+		}
+		author.DynastyID = dynasty.ID
+		patch.Dynasty = &dynasty.Name
+	}
+
+	patch.ApplyTo(author)
+	return patch, nil
+}
+
+// ListComments 列出注释，可按作品和类型过滤
+func (s *CorpusService) ListComments(ctx context.Context, filter models.CommentFilter, pq models.PageQuery) (models.CommentCollection, error) {
+	return s.repo.ListComments(ctx, filter, pq.Normalize())
+}
+
+// GetComment 获取注释
+func (s *CorpusService) GetComment(ctx context.Context, id uint) (*models.Comment, error) {
+	comment, err := s.repo.GetComment(ctx, id)
+	if err != nil {
+		return nil, ErrCommentNotFound
+	}
+	return comment, nil
+}
+
+// CreateComment 为作品新增注释
+func (s *CorpusService) CreateComment(ctx context.Context, edit Edit, req *CommentRequest) (*models.Comment, error) {
+	work, err := s.repo.GetWork(ctx, req.WorkID)
+	if err != nil {
+		return nil, ErrWorkNotFound
+	}
+
+	comment := &models.Comment{WorkID: work.ID}
+	applyCommentRequest(comment, req)
+
+	override, err := s.commentsOverride(ctx, work, edit.EditorID, append(work.Comments, *comment))
+	if err != nil {
+		return nil, err
+	}
+	revision, err := newRevision(models.RevisionEntityComment, workKey(work), models.OverrideActionCreate, edit, nil, models.NewCommentSnapshot(comment, workKey(work)))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveComment(ctx, comment, override, revision); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// UpdateComment 修改注释
+func (s *CorpusService) UpdateComment(ctx context.Context, edit Edit, id uint, req *CommentRequest) (*models.Comment, error) {
+	comment, work, err := s.loadComment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := models.NewCommentSnapshot(comment, workKey(work))
+	applyCommentRequest(comment, req)
+
+	comments := make([]models.Comment, len(work.Comments))
+	for i, c := range work.Comments {
+		if c.ID == comment.ID {
+			c = *comment
+		}
+		comments[i] = c
+	}
+
+	override, err := s.commentsOverride(ctx, work, edit.EditorID, comments)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := newRevision(models.RevisionEntityComment, workKey(work), models.OverrideActionUpdate, edit, before, models.NewCommentSnapshot(comment, workKey(work)))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveComment(ctx, comment, override, revision); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// DeleteComment 删除注释
+func (s *CorpusService) DeleteComment(ctx context.Context, edit Edit, id uint) error {
+	comment, work, err := s.loadComment(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	comments := make([]models.Comment, 0, len(work.Comments))
+	for _, c := range work.Comments {
+		if c.ID != comment.ID {
+			comments = append(comments, c)
+		}
+	}
+
+	override, err := s.commentsOverride(ctx, work, edit.EditorID, comments)
+	if err != nil {
+		return err
+	}
+	revision, err := newRevision(models.RevisionEntityComment, workKey(work), models.OverrideActionDelete, edit, models.NewCommentSnapshot(comment, workKey(work)), nil)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteComment(ctx, comment, override, revision)
+}
+
+// ListOverrides 列出修改记录
+func (s *CorpusService) ListOverrides(ctx context.Context, entityType string) ([]models.CorpusOverride, error) {
+	return s.repo.ListOverrides(ctx, entityType)
+}
+
+func (s *CorpusService) loadComment(ctx context.Context, id uint) (*models.Comment, *models.Work, error) {
+	comment, err := s.repo.GetComment(ctx, id)
+	if err != nil {
+		return nil, nil, ErrCommentNotFound
+	}
+	work, err := s.repo.GetWork(ctx, comment.WorkID)
+	if err != nil {
+		return nil, nil, ErrWorkNotFound
+	}
+	return comment, work, nil
+}
+
+func applyCommentRequest(comment *models.Comment, req *CommentRequest) {
+	comment.Content = req.Content
+	comment.Type = req.Type
+	if comment.Type == "" {
+		comment.Type = "note"
+	}
+	comment.Commenter = req.Commenter
+	comment.ParagraphIndex = req.ParagraphIndex
+}
+
+// commentsOverride 注释没有稳定标识，修改任意一条时记录作品的完整注释列表
+func (s *CorpusService) commentsOverride(ctx context.Context, work *models.Work, editorID uint, comments []models.Comment) (*models.CorpusOverride, error) {
+	data := make([]models.CommentData, len(comments))
+	for i, c := range comments {
+		data[i] = models.CommentData{
+			Content:        c.Content,
+			Type:           c.Type,
+			Commenter:      c.Commenter,
+			ParagraphIndex: c.ParagraphIndex,
+		}
+	}
+	return s.workOverride(ctx, work, editorID, models.OverrideActionUpdate, models.WorkPatch{Comments: &data})
+}
+
+// workOverride 把本次修改合并进作品已有的修改记录
+func (s *CorpusService) workOverride(ctx context.Context, work *models.Work, editorID uint, action string, patch models.WorkPatch) (*models.CorpusOverride, error) {
+	key := workKey(work)
+	if existing, err := s.repo.GetOverride(ctx, models.OverrideEntityWork, key); err == nil {
+		var previous models.WorkPatch
+		if existing.Data != "" {
+			if err := json.Unmarshal([]byte(existing.Data), &previous); err != nil {
+				return nil, err
+			}
+		}
+		patch = previous.Merge(patch)
+		// 后台新建的作品再修改时仍需在 ETL 中整体重建
+		if existing.Action == models.OverrideActionCreate && action == models.OverrideActionUpdate {
+			action = models.OverrideActionCreate
+		}
+	}
+	return newOverride(models.OverrideEntityWork, key, action, editorID, patch)
+}
+
+// authorOverride 把本次修改合并进作者已有的修改记录
+func (s *CorpusService) authorOverride(ctx context.Context, author *models.Author, editorID uint, action string, patch models.AuthorPatch) (*models.CorpusOverride, error) {
+	key := authorKey(author)
+	if existing, err := s.repo.GetOverride(ctx, models.OverrideEntityAuthor, key); err == nil {
+		var previous models.AuthorPatch
+		if existing.Data != "" {
+			if err := json.Unmarshal([]byte(existing.Data), &previous); err != nil {
+				return nil, err
+			}
+		}
+		patch = previous.Merge(patch)
+		if existing.Action == models.OverrideActionCreate && action == models.OverrideActionUpdate {
+			action = models.OverrideActionCreate
+		}
+	}
+	return newOverride(models.OverrideEntityAuthor, key, action, editorID, patch)
+}
+
+func newOverride(entityType, key, action string, editorID uint, patch interface{}) (*models.CorpusOverride, error) {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CorpusOverride{
+		EntityType: entityType,
+		SourceKey:  key,
+		Action:     action,
+		Data:       string(data),
+		UpdatedBy:  editorID,
+	}, nil
+}
+
+// workKey 返回作品的 SourceKey；旧数据库中尚未填充时按 ETL 的规则补算
+func workKey(work *models.Work) string {
+	if work.SourceKey == "" {
+		work.SourceKey = models.WorkSourceKey(work.Category.Name, work.OriginalID, authorKey(&work.Author), work.Title, work.Content)
+	}
+	return work.SourceKey
+}
+
+// findWork 按 SourceKey 查找作品，找不到时退回到ID，但要求该ID对应作品的 SourceKey 一致，
+// 兼容尚未写入 source_key 的旧库，又不会在 ETL 重建后改到别的作品上；key 为空时直接按ID查找
+func (s *CorpusService) findWork(ctx context.Context, key string, id uint) (*models.Work, error) {
+	if key != "" {
+		if work, err := s.repo.GetWorkByKey(ctx, key); err == nil {
+			id = work.ID
+		}
+	}
+	work, err := s.repo.GetWork(ctx, id)
+	if err != nil || (key != "" && workKey(work) != key) {
+		return nil, ErrWorkNotFound
+	}
+	return work, nil
+}
+
+// findAuthor 按 SourceKey 查找作者，规则同 findWork
+func (s *CorpusService) findAuthor(ctx context.Context, key string, id uint) (*models.Author, error) {
+	if key != "" {
+		if author, err := s.repo.GetAuthorByKey(ctx, key); err == nil {
+			return author, nil
+		}
+	}
+	author, err := s.repo.GetAuthor(ctx, id)
+	if err != nil || (key != "" && authorKey(author) != key) {
+		return nil, ErrAuthorNotFound
+	}
+	return author, nil
+}
+
+// authorKey 返回作者的 SourceKey；旧数据库中尚未填充时按 ETL 的规则补算
+func authorKey(author *models.Author) string {
+	if author.SourceKey == "" {
+		author.SourceKey = models.AuthorSourceKey(author.Name, author.Dynasty)
+	}
+	return author.SourceKey
+}
+
+func orEmpty(s *string) *string {
+	if s == nil {
+		empty := ""
+		return &empty
+	}
+	return s
+}
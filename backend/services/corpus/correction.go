@@ -0,0 +1,236 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"poem/backend/models"
+	"poem/backend/pkg/textdiff"
+	"poem/backend/repository"
+)
+
+// AcceptedCorrectionExperience 纠错被采纳时提交者获得的经验值
+const AcceptedCorrectionExperience = 20
+
+// maxPendingCorrections 每个用户同时待审核的纠错上限
+const maxPendingCorrections = 20
+
+var (
+	ErrCorrectionNotFound = errors.New("纠错不存在")
+	ErrCorrectionReviewed = errors.New("纠错已审核")
+	ErrEmptyCorrection    = errors.New("纠错内容与当前版本相同")
+	ErrTooManyCorrections = errors.New("待审核的纠错过多，请等待审核后再提交")
+	ErrRejectReason       = errors.New("驳回时请填写原因")
+)
+
+// CorrectionService 读者纠错服务：提交、审核队列，采纳时通过 CorpusService 修改作品并记录修订
+type CorrectionService struct {
+	repo     repository.CorrectionRepository
+	corpus   *CorpusService
+	userRepo repository.UserRepository
+}
+
+// NewCorrectionService 创建纠错服务
+func NewCorrectionService(repo repository.CorrectionRepository, corpus *CorpusService, userRepo repository.UserRepository) *CorrectionService {
+	return &CorrectionService{
+		repo:     repo,
+		corpus:   corpus,
+		userRepo: userRepo,
+	}
+}
+
+// CorrectionRequest 提交纠错的请求，未填写的项表示不修改
+type CorrectionRequest struct {
+	Title    string   `json:"title" binding:"max=255"`
+	Content  []string `json:"content" binding:"max=500,dive,max=500"` // 任何登录用户都能提交，限制行数和每行长度
+	AuthorID uint     `json:"author_id"`
+	Note     string   `json:"note" binding:"max=2000"`
+}
+
+// ReviewRequest 审核纠错的请求，驳回时 Note 为原因
+type ReviewRequest struct {
+	Note string `json:"note" binding:"max=500"`
+}
+
+// CorrectionDetail 纠错及作品当前版本，Lines 为当前正文与建议正文的逐行差异
+type CorrectionDetail struct {
+	Correction *models.Correction `json:"correction"`
+	Work       *models.Work       `json:"work,omitempty"`
+	Lines      []textdiff.Line    `json:"lines,omitempty"`
+}
+
+// Submit 提交纠错，与当前版本相同的项会被忽略
+func (s *CorrectionService) Submit(ctx context.Context, userID, workID uint, req *CorrectionRequest) (*models.Correction, error) {
+	work, err := s.corpus.repo.GetWork(ctx, workID)
+	if err != nil {
+		return nil, ErrWorkNotFound
+	}
+
+	correction := &models.Correction{
+		WorkID:  work.ID,
+		WorkKey: workKey(work),
+		UserID:  userID,
+		Note:    req.Note,
+		Status:  models.CorrectionPending,
+	}
+	if req.Title != "" && req.Title != work.Title {
+		correction.Title = req.Title
+	}
+	if len(req.Content) > 0 && !sameLines(req.Content, work.Content) {
+		correction.Content = models.JSONArr(req.Content)
+	}
+	if req.AuthorID != 0 && req.AuthorID != work.AuthorID {
+		author, err := s.corpus.repo.GetAuthor(ctx, req.AuthorID)
+		if err != nil {
+			return nil, ErrAuthorNotFound
+		}
+		correction.AuthorID = author.ID
+		correction.AuthorKey = authorKey(author)
+	}
+	if correction.Title == "" && correction.Content == nil && correction.AuthorID == 0 {
+		return nil, ErrEmptyCorrection
+	}
+
+	pending, err := s.repo.CountPending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if pending >= maxPendingCorrections {
+		return nil, ErrTooManyCorrections
+	}
+
+	if err := s.repo.Create(ctx, correction); err != nil {
+		return nil, err
+	}
+	return correction, nil
+}
+
+// ListByUser 列出用户提交的纠错
+func (s *CorrectionService) ListByUser(ctx context.Context, userID uint, pq models.PageQuery) (models.CorrectionCollection, error) {
+	return s.repo.List(ctx, models.CorrectionFilter{UserID: userID}, pq.Normalize())
+}
+
+// List 审核队列，status 为空时列出全部
+func (s *CorrectionService) List(ctx context.Context, filter models.CorrectionFilter, pq models.PageQuery) (models.CorrectionCollection, error) {
+	return s.repo.List(ctx, filter, pq.Normalize())
+}
+
+// Get 获取纠错及作品当前版本
+func (s *CorrectionService) Get(ctx context.Context, id uint) (*CorrectionDetail, error) {
+	correction, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrCorrectionNotFound
+	}
+
+	detail := &CorrectionDetail{Correction: correction}
+	if work, err := s.correctionWork(ctx, correction); err == nil {
+		detail.Work = work
+		if correction.Content != nil {
+			detail.Lines = textdiff.Lines(work.Content, correction.Content)
+		}
+	}
+	return detail, nil
+}
+
+// Approve 采纳纠错：修改作品（同时记录修订和 ETL 修改记录），并给提交者增加经验值。
+// 先把纠错标记为已采纳，避免两位编辑同时采纳时重复修改；修改失败时退回待审核
+func (s *CorrectionService) Approve(ctx context.Context, reviewerID, id uint, note string) (*models.Correction, error) {
+	correction, err := s.claim(ctx, id, models.CorrectionApproved, reviewerID, note)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &WorkRequest{}
+	if correction.Title != "" {
+		req.Title = &correction.Title
+	}
+	if correction.Content != nil {
+		content := []string(correction.Content)
+		req.Content = &content
+	}
+
+	edit := Edit{EditorID: reviewerID, Reason: fmt.Sprintf("采纳纠错 #%d", correction.ID)}
+	if err := s.apply(ctx, edit, correction, req); err != nil {
+		if reopenErr := s.repo.Reopen(ctx, correction.ID); reopenErr != nil {
+			slog.ErrorContext(ctx, "reopen correction failed", "correction_id", correction.ID, "error", reopenErr)
+		}
+		return nil, err
+	}
+
+	// 编辑采纳自己提交的纠错不加经验
+	if correction.UserID != reviewerID {
+		if err := s.userRepo.AddExperience(ctx, correction.UserID, AcceptedCorrectionExperience); err != nil {
+			slog.ErrorContext(ctx, "reward correction failed", "correction_id", correction.ID, "error", err)
+		}
+	}
+
+	return s.repo.GetByID(ctx, correction.ID)
+}
+
+// apply 把纠错写入作品。作品和作者按提交时记录的 SourceKey 找回，
+// 避免 manage etl 重建语料后按旧ID改到别的作品上
+func (s *CorrectionService) apply(ctx context.Context, edit Edit, correction *models.Correction, req *WorkRequest) error {
+	work, err := s.correctionWork(ctx, correction)
+	if err != nil {
+		return err
+	}
+	if correction.AuthorID != 0 {
+		author, err := s.corpus.findAuthor(ctx, correction.AuthorKey, correction.AuthorID)
+		if err != nil {
+			return err
+		}
+		req.AuthorID = &author.ID
+	}
+	_, err = s.corpus.UpdateWork(ctx, edit, work.ID, req)
+	return err
+}
+
+// correctionWork 获取纠错对应的作品，早于记录 WorkKey 的纠错按ID查找
+func (s *CorrectionService) correctionWork(ctx context.Context, correction *models.Correction) (*models.Work, error) {
+	return s.corpus.findWork(ctx, correction.WorkKey, correction.WorkID)
+}
+
+// Reject 驳回纠错，reason 会展示给提交者
+func (s *CorrectionService) Reject(ctx context.Context, reviewerID, id uint, reason string) (*models.Correction, error) {
+	if reason == "" {
+		return nil, ErrRejectReason
+	}
+	correction, err := s.claim(ctx, id, models.CorrectionRejected, reviewerID, reason)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, correction.ID)
+}
+
+// claim 把待审核的纠错标记为审核结果，已被其他人审核时返回 ErrCorrectionReviewed
+func (s *CorrectionService) claim(ctx context.Context, id uint, status string, reviewerID uint, note string) (*models.Correction, error) {
+	correction, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrCorrectionNotFound
+	}
+	if correction.Status != models.CorrectionPending {
+		return nil, ErrCorrectionReviewed
+	}
+
+	ok, err := s.repo.Review(ctx, id, status, reviewerID, note)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrCorrectionReviewed
+	}
+	return correction, nil
+}
+
+func sameLines(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
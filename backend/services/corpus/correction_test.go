@@ -0,0 +1,198 @@
+package corpus
+
+import (
+	"context"
+	"errors"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"gorm.io/gorm"
+)
+
+// newTestCorrections 创建纠错服务，并注册编辑（ID 1）和读者（ID 2）
+func newTestCorrections(t *testing.T) (*CorrectionService, *gorm.DB, *models.Work) {
+	t.Helper()
+	corpus, db, work := newTestCorpus(t)
+	for _, name := range []string{"editor", "reader"} {
+		if err := db.Create(&models.User{Username: name, PasswordHash: "x", Status: 1}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo, _ := repository.NewCorrectionRepository(db)
+	userRepo, _ := repository.NewUserRepository(db)
+	return NewCorrectionService(repo, corpus, userRepo), db, work
+}
+
+const (
+	testEditorID = 1
+	testReaderID = 2
+)
+
+func experience(t *testing.T, db *gorm.DB, userID uint) int {
+	t.Helper()
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.Experience
+}
+
+func countRevisions(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.WorkRevision{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestApproveCorrection(t *testing.T) {
+	s, db, work := newTestCorrections(t)
+	ctx := context.Background()
+
+	content := []string{"床前看月光，疑是地上霜。", "举头望山月，低头思故乡。"}
+	correction, err := s.Submit(ctx, testReaderID, work.ID, &CorrectionRequest{Content: content, Note: "据宋本"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 两位编辑同时采纳，只有一次生效
+	const reviewers = 4
+	errs := make(chan error, reviewers)
+	var wg sync.WaitGroup
+	for i := 0; i < reviewers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Approve(ctx, testEditorID, correction.ID, "")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	approved := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			approved++
+		case !errors.Is(err, ErrCorrectionReviewed):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if approved != 1 {
+		t.Fatalf("%d approvals succeeded, want exactly 1", approved)
+	}
+
+	updated, err := s.corpus.repo.GetWork(ctx, work.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(updated.Content, "") != strings.Join(content, "") || updated.Title != work.Title {
+		t.Fatalf("work after approval = %q %q", updated.Title, updated.Content)
+	}
+	if got := experience(t, db, testReaderID); got != AcceptedCorrectionExperience {
+		t.Fatalf("reader experience = %d, want %d", got, AcceptedCorrectionExperience)
+	}
+	if got := countRevisions(t, db); got != 1 {
+		t.Fatalf("%d revisions, want 1", got)
+	}
+	revision := latestRevision(t, db)
+	if revision.EditorID != testEditorID || !strings.Contains(revision.Reason, "采纳纠错") {
+		t.Fatalf("revision = %+v", revision)
+	}
+
+	result, err := s.repo.GetByID(ctx, correction.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status != models.CorrectionApproved || result.ReviewerID != testEditorID || result.ReviewedAt == nil {
+		t.Fatalf("correction after approval = %+v", result)
+	}
+}
+
+func TestApproveOwnCorrectionNoExperience(t *testing.T) {
+	s, db, work := newTestCorrections(t)
+	ctx := context.Background()
+
+	title := "夜思"
+	correction, err := s.Submit(ctx, testEditorID, work.ID, &CorrectionRequest{Title: title})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Approve(ctx, testEditorID, correction.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := experience(t, db, testEditorID); got != 0 {
+		t.Fatalf("editor experience = %d, want 0", got)
+	}
+}
+
+func TestRejectCorrection(t *testing.T) {
+	s, db, work := newTestCorrections(t)
+	ctx := context.Background()
+
+	correction, err := s.Submit(ctx, testReaderID, work.ID, &CorrectionRequest{Title: "夜思"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Reject(ctx, testEditorID, correction.ID, ""); !errors.Is(err, ErrRejectReason) {
+		t.Fatalf("reject without reason error = %v, want ErrRejectReason", err)
+	}
+
+	rejected, err := s.Reject(ctx, testEditorID, correction.ID, "版本无据")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.Status != models.CorrectionRejected || rejected.ReviewNote != "版本无据" {
+		t.Fatalf("correction after rejection = %+v", rejected)
+	}
+	if _, err := s.Approve(ctx, testEditorID, correction.ID, ""); !errors.Is(err, ErrCorrectionReviewed) {
+		t.Fatalf("approve after rejection error = %v, want ErrCorrectionReviewed", err)
+	}
+
+	unchanged, err := s.corpus.repo.GetWork(ctx, work.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unchanged.Title != work.Title {
+		t.Fatalf("rejected correction changed the title to %q", unchanged.Title)
+	}
+	if got := experience(t, db, testReaderID); got != 0 {
+		t.Fatalf("reader experience = %d, want 0", got)
+	}
+	if got := countRevisions(t, db); got != 0 {
+		t.Fatalf("%d revisions after rejection, want 0", got)
+	}
+}
+
+func TestCorrectionRequestLimits(t *testing.T) {
+	lines := func(n, length int) []string {
+		out := make([]string, n)
+		for i := range out {
+			out[i] = strings.Repeat("月", length)
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		content []string
+		ok      bool
+	}{
+		{"empty", nil, true},
+		{"at limits", lines(500, 500), true},
+		{"too many lines", lines(501, 1), false},
+		{"line too long", lines(1, 501), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(&CorrectionRequest{Content: tt.content})
+			if (err == nil) != tt.ok {
+				t.Fatalf("validate error = %v, want ok=%v", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"poem/backend/models"
+	"poem/backend/pkg/textdiff"
+	"poem/backend/repository"
+	"strings"
+)
+
+var (
+	ErrRevisionNotFound  = errors.New("修订不存在")
+	ErrNothingToRollback = errors.New("记录已删除，无需回滚")
+	ErrRevisionStale     = errors.New("该注释修订早于最近一次导入完成，注释已重建，无法回滚")
+
+	// ErrInvalidCursor 分页游标无效
+	ErrInvalidCursor = repository.ErrInvalidCursor
+)
+
+// RevisionDiff 修订前后的逐行差异：作品比较正文，注释比较内容，作者比较简介
+type RevisionDiff struct {
+	Revision *models.WorkRevision `json:"revision"`
+	Lines    []textdiff.Line      `json:"lines"`
+}
+
+// GetRevision 获取修订
+func (s *CorpusService) GetRevision(ctx context.Context, id uint) (*models.WorkRevision, error) {
+	revision, err := s.repo.GetRevision(ctx, id)
+	if err != nil {
+		return nil, ErrRevisionNotFound
+	}
+	return revision, nil
+}
+
+// ListRevisions 按时间倒序列出修订
+func (s *CorpusService) ListRevisions(ctx context.Context, filter models.RevisionFilter, pq models.PageQuery) (models.RevisionCollection, error) {
+	return s.repo.ListRevisions(ctx, filter, pq.Normalize())
+}
+
+// DiffRevision 计算修订前后的逐行差异
+func (s *CorpusService) DiffRevision(ctx context.Context, id uint) (*RevisionDiff, error) {
+	revision, err := s.GetRevision(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := revisionLines(revision.EntityType, revision.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := revisionLines(revision.EntityType, revision.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevisionDiff{Revision: revision, Lines: textdiff.Lines(before, after)}, nil
+}
+
+// revisionLines 取出快照中参与比较的文本行，空快照返回 nil
+func revisionLines(entityType, data string) ([]string, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	switch entityType {
+	case models.OverrideEntityWork:
+		var snapshot models.WorkSnapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			return nil, err
+		}
+		return snapshot.Content, nil
+	case models.OverrideEntityAuthor:
+		var snapshot models.AuthorSnapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			return nil, err
+		}
+		return strings.Split(snapshot.Biography, "\n"), nil
+	default:
+		var snapshot models.CommentSnapshot
+		if err := json.Unmarshal([]byte(data), &snapshot); err != nil {
+			return nil, err
+		}
+		return strings.Split(snapshot.Content, "\n"), nil
+	}
+}
+
+// Rollback 把实体恢复到修订 id 完成后的状态，并作为一次新的修改记录。
+// 该修订是删除时再次删除实体；实体已被删除时重新创建，此时ID会变化，作品的注释不会随之恢复。
+// 快照中引用的作者、作品按 SourceKey 找回；注释没有稳定的键，ETL 重建后其ID全部变化，
+// 因此最近一次导入完成之前的注释修订不能回滚（包括导入进行中做出的修订，其ID已被重建覆盖）
+func (s *CorpusService) Rollback(ctx context.Context, edit Edit, id uint) (interface{}, error) {
+	revision, err := s.GetRevision(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	edit.rollbackOf = revision.ID
+
+	switch revision.EntityType {
+	case models.OverrideEntityWork:
+		return s.rollbackWork(ctx, edit, revision)
+	case models.OverrideEntityAuthor:
+		return s.rollbackAuthor(ctx, edit, revision)
+	default:
+		return s.rollbackComment(ctx, edit, revision)
+	}
+}
+
+func (s *CorpusService) rollbackWork(ctx context.Context, edit Edit, revision *models.WorkRevision) (interface{}, error) {
+	_, err := s.repo.GetWork(ctx, revision.EntityID)
+	exists := err == nil
+
+	if revision.After == "" {
+		if !exists {
+			return nil, ErrNothingToRollback
+		}
+		return nil, s.DeleteWork(ctx, edit, revision.EntityID)
+	}
+
+	var snapshot models.WorkSnapshot
+	if err := json.Unmarshal([]byte(revision.After), &snapshot); err != nil {
+		return nil, err
+	}
+	author, err := s.findAuthor(ctx, snapshot.AuthorKey, snapshot.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	req := &WorkRequest{
+		Title:    &snapshot.Title,
+		Rhythmic: &snapshot.Rhythmic,
+		Volume:   &snapshot.Volume,
+		Section:  &snapshot.Section,
+		Prologue: &snapshot.Prologue,
+		Content:  &snapshot.Content,
+		AuthorID: &author.ID,
+		Category: &snapshot.Category,
+	}
+	if exists {
+		return s.UpdateWork(ctx, edit, revision.EntityID, req)
+	}
+	return s.CreateWork(ctx, edit, req)
+}
+
+func (s *CorpusService) rollbackAuthor(ctx context.Context, edit Edit, revision *models.WorkRevision) (interface{}, error) {
+	_, err := s.repo.GetAuthor(ctx, revision.EntityID)
+	exists := err == nil
+
+	if revision.After == "" {
+		if !exists {
+			return nil, ErrNothingToRollback
+		}
+		return nil, s.DeleteAuthor(ctx, edit, revision.EntityID)
+	}
+
+	var snapshot models.AuthorSnapshot
+	if err := json.Unmarshal([]byte(revision.After), &snapshot); err != nil {
+		return nil, err
+	}
+	req := &AuthorRequest{
+		Name:      &snapshot.Name,
+		Dynasty:   &snapshot.Dynasty,
+		Biography: &snapshot.Biography,
+	}
+	if exists {
+		return s.UpdateAuthor(ctx, edit, revision.EntityID, req)
+	}
+	return s.CreateAuthor(ctx, edit, req)
+}
+
+func (s *CorpusService) rollbackComment(ctx context.Context, edit Edit, revision *models.WorkRevision) (interface{}, error) {
+	run, err := s.repo.LatestImportRun(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if run != nil && !revision.CreatedAt.After(run.FinishedAt) {
+		return nil, ErrRevisionStale
+	}
+
+	_, err = s.repo.GetComment(ctx, revision.EntityID)
+	exists := err == nil
+
+	if revision.After == "" {
+		if !exists {
+			return nil, ErrNothingToRollback
+		}
+		return nil, s.DeleteComment(ctx, edit, revision.EntityID)
+	}
+
+	var snapshot models.CommentSnapshot
+	if err := json.Unmarshal([]byte(revision.After), &snapshot); err != nil {
+		return nil, err
+	}
+	// 旧快照没有 WorkKey，注释修订的 SourceKey 同样是所属作品的
+	workKey := snapshot.WorkKey
+	if workKey == "" {
+		workKey = revision.SourceKey
+	}
+	work, err := s.findWork(ctx, workKey, snapshot.WorkID)
+	if err != nil {
+		return nil, err
+	}
+	req := &CommentRequest{
+		WorkID:         work.ID,
+		Content:        snapshot.Content,
+		Type:           snapshot.Type,
+		Commenter:      snapshot.Commenter,
+		ParagraphIndex: snapshot.ParagraphIndex,
+	}
+	if exists {
+		return s.UpdateComment(ctx, edit, revision.EntityID, req)
+	}
+	return s.CreateComment(ctx, edit, req)
+}
+
+// newRevision 生成修订记录，before/after 为 nil 时对应字段留空；
+// key 为作品或作者的 SourceKey，注释使用所属作品的
+func newRevision(entityType, key, action string, edit Edit, before, after interface{}) (*models.WorkRevision, error) {
+	revision := &models.WorkRevision{
+		EntityType: entityType,
+		SourceKey:  key,
+		Action:     action,
+		EditorID:   edit.EditorID,
+		Reason:     edit.Reason,
+		RollbackOf: edit.rollbackOf,
+	}
+	if before != nil {
+		data, err := json.Marshal(before)
+		if err != nil {
+			return nil, err
+		}
+		revision.Before = string(data)
+	}
+	if after != nil {
+		data, err := json.Marshal(after)
+		if err != nil {
+			return nil, err
+		}
+		revision.After = string(data)
+	}
+	return revision, nil
+}
@@ -0,0 +1,195 @@
+package corpus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newTestCorpus 创建语料编辑服务，并写入一个分类、一个作者和一首作品
+func newTestCorpus(t *testing.T) (*CorpusService, *gorm.DB, *models.Work) {
+	t.Helper()
+	db := testdb.New(t)
+	category := models.Category{Name: "tangshi", DisplayName: "唐诗"}
+	dynasty := models.Dynasty{Code: "tang", Name: "唐"}
+	for _, v := range []interface{}{&category, &dynasty} {
+		if err := db.Create(v).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	author := models.Author{Name: "李白", DynastyID: dynasty.ID, Dynasty: "唐", SourceKey: models.AuthorSourceKey("李白", "唐")}
+	if err := db.Create(&author).Error; err != nil {
+		t.Fatal(err)
+	}
+	work := models.Work{
+		CategoryID: category.ID,
+		AuthorID:   author.ID,
+		Title:      "静夜思",
+		Content:    models.JSONArr{"床前明月光，疑是地上霜。", "举头望明月，低头思故乡。"},
+		OriginalID: "1",
+		SourceKey:  "tangshi|id:1",
+	}
+	if err := db.Omit("Author", "Category").Create(&work).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	repo, _ := repository.NewCorpusRepository(db)
+	return NewCorpusService(repo), db, &work
+}
+
+// latestRevision 最近一条修订
+func latestRevision(t *testing.T, db *gorm.DB) *models.WorkRevision {
+	t.Helper()
+	var revision models.WorkRevision
+	if err := db.Order("id desc").First(&revision).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &revision
+}
+
+func TestRollbackWorkInPlace(t *testing.T) {
+	s, db, work := newTestCorpus(t)
+	ctx := context.Background()
+	edit := Edit{EditorID: 1}
+
+	first, second := "静夜思·其一", "静夜思·其二"
+	if _, err := s.UpdateWork(ctx, edit, work.ID, &WorkRequest{Title: &first}); err != nil {
+		t.Fatal(err)
+	}
+	target := latestRevision(t, db)
+	if _, err := s.UpdateWork(ctx, edit, work.ID, &WorkRequest{Title: &second}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Rollback(ctx, edit, target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := result.(*models.Work)
+	if restored.ID != work.ID || restored.Title != first {
+		t.Fatalf("rolled back to id %d title %q, want id %d title %q", restored.ID, restored.Title, work.ID, first)
+	}
+	if rev := latestRevision(t, db); rev.RollbackOf != target.ID || rev.Action != models.OverrideActionUpdate {
+		t.Fatalf("rollback revision = %+v, want an update with rollback_of %d", rev, target.ID)
+	}
+}
+
+func TestRollbackWorkRecreatesDeleted(t *testing.T) {
+	s, db, work := newTestCorpus(t)
+	ctx := context.Background()
+	edit := Edit{EditorID: 1}
+
+	title := "夜思"
+	if _, err := s.UpdateWork(ctx, edit, work.ID, &WorkRequest{Title: &title}); err != nil {
+		t.Fatal(err)
+	}
+	target := latestRevision(t, db)
+	if err := s.DeleteWork(ctx, edit, work.ID); err != nil {
+		t.Fatal(err)
+	}
+	deletion := latestRevision(t, db)
+
+	// 回滚到删除修订时作品已不存在
+	if _, err := s.Rollback(ctx, edit, deletion.ID); !errors.Is(err, ErrNothingToRollback) {
+		t.Fatalf("rollback of deletion error = %v, want ErrNothingToRollback", err)
+	}
+
+	result, err := s.Rollback(ctx, edit, target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recreated := result.(*models.Work)
+	if recreated.ID == work.ID {
+		t.Fatal("recreated work reused the deleted id")
+	}
+	if recreated.Title != title || recreated.AuthorID != work.AuthorID || len(recreated.Content) != len(work.Content) {
+		t.Fatalf("recreated work = %+v", recreated)
+	}
+	if rev := latestRevision(t, db); rev.RollbackOf != target.ID || rev.Action != models.OverrideActionCreate {
+		t.Fatalf("rollback revision = %+v, want a create with rollback_of %d", rev, target.ID)
+	}
+}
+
+func TestRollbackCommentStaleAfterImport(t *testing.T) {
+	s, db, work := newTestCorpus(t)
+	ctx := context.Background()
+	edit := Edit{EditorID: 1}
+
+	comment, err := s.CreateComment(ctx, edit, &CommentRequest{WorkID: work.ID, Content: "明月：一作“山月”。"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := latestRevision(t, db)
+	if _, err := s.UpdateComment(ctx, edit, comment.ID, &CommentRequest{Content: "改过的注释"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修订之前完成的导入不影响回滚
+	earlier := models.ImportRun{StartedAt: target.CreatedAt.Add(-2 * time.Hour), FinishedAt: target.CreatedAt.Add(-time.Hour)}
+	if err := db.Create(&earlier).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rollback(ctx, edit, target.ID); err != nil {
+		t.Fatalf("rollback after an earlier import: %v", err)
+	}
+
+	// 导入进行中做出的修订：开始于修订之前、完成于修订之后
+	during := models.ImportRun{StartedAt: target.CreatedAt.Add(-time.Minute), FinishedAt: target.CreatedAt.Add(time.Minute)}
+	if err := db.Create(&during).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rollback(ctx, edit, target.ID); !errors.Is(err, ErrRevisionStale) {
+		t.Fatalf("rollback of a revision made during an import error = %v, want ErrRevisionStale", err)
+	}
+}
+
+func TestRollbackCommentWorkKeyFallback(t *testing.T) {
+	s, db, work := newTestCorpus(t)
+	ctx := context.Background()
+	edit := Edit{EditorID: 1}
+
+	comment, err := s.CreateComment(ctx, edit, &CommentRequest{WorkID: work.ID, Content: "疑：好像。"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := latestRevision(t, db)
+	if err := s.DeleteComment(ctx, edit, comment.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟引入 WorkKey 之前的旧快照，且快照中的作品ID已被重建改变
+	var snapshot models.CommentSnapshot
+	if err := json.Unmarshal([]byte(target.After), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	snapshot.WorkKey = ""
+	snapshot.WorkID = work.ID + 100
+	data, _ := json.Marshal(snapshot)
+	if err := db.Model(target).Update("after", string(data)).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Rollback(ctx, edit, target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recreated := result.(*models.Comment)
+	if recreated.WorkID != work.ID || recreated.Content != snapshot.Content {
+		t.Fatalf("recreated comment = %+v, want it under work %d", recreated, work.ID)
+	}
+
+	// 修订的 SourceKey 也对不上时找不到作品
+	if err := db.Model(target).Update("source_key", "tangshi|id:missing").Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Rollback(ctx, edit, target.ID); !errors.Is(err, ErrWorkNotFound) {
+		t.Fatalf("rollback with unknown work key error = %v, want ErrWorkNotFound", err)
+	}
+}
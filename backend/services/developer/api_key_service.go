@@ -0,0 +1,207 @@
+package developer
+
+import (
+	"context"
+	"errors"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/repository"
+	"time"
+)
+
+const (
+	// DefaultDailyQuota 未指定时每个密钥的每日调用上限
+	DefaultDailyQuota = 1000
+	// MaxDailyQuota 用户可为密钥设置的每日调用上限
+	MaxDailyQuota = 10000
+	// maxActiveKeys 每个用户同时有效的密钥数上限
+	maxActiveKeys = 10
+	// maxUsageDays 用量统计最多查询的天数
+	maxUsageDays = 90
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API密钥不存在")
+	ErrTooManyAPIKeys = errors.New("有效的API密钥过多，请先吊销不再使用的密钥")
+	ErrInvalidScope   = errors.New("无效的权限范围")
+)
+
+// APIKeyService 第三方开发者API密钥服务
+type APIKeyService struct {
+	repo repository.APIKeyRepository
+	now  func() time.Time
+}
+
+// NewAPIKeyService 创建API密钥服务
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{repo: repo, now: time.Now}
+}
+
+// CreateAPIKeyRequest 创建密钥请求，Scopes 为空时只授予 poetry:read
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes"`
+	DailyQuota    int      `json:"daily_quota" binding:"min=0,max=10000"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=365"` // 0 表示永不过期
+}
+
+// CreatedAPIKey 新建的密钥，明文只在创建时返回一次
+type CreatedAPIKey struct {
+	Key    string         `json:"key"`
+	APIKey *models.APIKey `json:"api_key"`
+}
+
+// UsageReport 密钥近期每天的调用次数，没有调用的日期计为 0
+type UsageReport struct {
+	APIKey *models.APIKey       `json:"api_key"`
+	Days   []models.APIKeyUsage `json:"days"`
+	Total  int64                `json:"total"`
+}
+
+// Create 为用户创建密钥
+func (s *APIKeyService) Create(ctx context.Context, userID uint, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	scopes := models.JSONArr{}
+	for _, scope := range req.Scopes {
+		if !auth.ValidScope(scope) {
+			return nil, ErrInvalidScope
+		}
+		if !contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		scopes = models.JSONArr{auth.ScopePoetryRead}
+	}
+
+	active, err := s.repo.CountActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active >= maxActiveKeys {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	record := &models.APIKey{
+		UserID:     userID,
+		Name:       req.Name,
+		Prefix:     prefix,
+		KeyHash:    hash,
+		Scopes:     scopes,
+		DailyQuota: req.DailyQuota,
+	}
+	if record.DailyQuota == 0 {
+		record.DailyQuota = DefaultDailyQuota
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := s.now().AddDate(0, 0, req.ExpiresInDays)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{Key: key, APIKey: record}, nil
+}
+
+// List 列出用户的密钥及当日调用次数
+func (s *APIKeyService) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	keys, err := s.repo.ListByUser(ctx, userID)
+	if err != nil || len(keys) == 0 {
+		return keys, err
+	}
+
+	ids := make([]uint, len(keys))
+	for i := range keys {
+		ids[i] = keys[i].ID
+	}
+	usage, err := s.repo.GetUsageOn(ctx, ids, s.today())
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		keys[i].UsageToday = usage[keys[i].ID]
+	}
+	return keys, nil
+}
+
+// Revoke 吊销用户自己的密钥
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id uint) error {
+	if _, err := s.owned(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.repo.Revoke(ctx, id)
+}
+
+// Usage 获取用户自己的密钥最近 days 天的调用次数
+func (s *APIKeyService) Usage(ctx context.Context, userID, id uint, days int) (*UsageReport, error) {
+	key, err := s.owned(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if days < 1 || days > maxUsageDays {
+		days = 30
+	}
+
+	start := s.now().UTC().AddDate(0, 0, 1-days)
+	rows, err := s.repo.GetUsage(ctx, id, start.Format(time.DateOnly))
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Day] = row.Count
+	}
+
+	report := &UsageReport{APIKey: key, Days: make([]models.APIKeyUsage, 0, days)}
+	for i := 0; i < days; i++ {
+		day := start.AddDate(0, 0, i).Format(time.DateOnly)
+		report.Days = append(report.Days, models.APIKeyUsage{Day: day, Count: counts[day]})
+		report.Total += counts[day]
+	}
+	key.UsageToday = counts[s.today()]
+	return report, nil
+}
+
+// Authenticate 校验密钥明文并计入当日用量，返回密钥和当日累计调用次数。
+// 当日额度已用完时返回 auth.ErrAPIKeyQuotaExceeded，被拒绝的请求不计入用量
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, int64, error) {
+	key, err := s.repo.GetActiveByHash(ctx, auth.HashToken(rawKey))
+	if err != nil {
+		return nil, 0, auth.ErrInvalidAPIKey
+	}
+
+	used, allowed, err := s.repo.IncrementUsage(ctx, key.ID, s.today(), key.DailyQuota)
+	if err != nil {
+		return nil, 0, err
+	}
+	key.UsageToday = used
+	if !allowed {
+		return key, used, auth.ErrAPIKeyQuotaExceeded
+	}
+	return key, used, nil
+}
+
+func (s *APIKeyService) owned(ctx context.Context, userID, id uint) (*models.APIKey, error) {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil || key.UserID != userID {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// today 用量按 UTC 日期统计
+func (s *APIKeyService) today() string {
+	return s.now().UTC().Format(time.DateOnly)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
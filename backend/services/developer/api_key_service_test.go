@@ -0,0 +1,126 @@
+package developer
+
+import (
+	"context"
+	"errors"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/repository"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestAPIKey 创建每日额度为 quota 的密钥，服务的时钟由返回的指针控制
+func newTestAPIKey(t *testing.T, quota int) (*APIKeyService, string, *time.Time) {
+	t.Helper()
+	db := testdb.New(t)
+	if err := db.Create(&models.User{Username: "dev", PasswordHash: "x", Status: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo, _ := repository.NewAPIKeyRepository(db)
+	s := NewAPIKeyService(repo)
+	now := time.Date(2026, 3, 1, 23, 59, 58, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	created, err := s.Create(context.Background(), 1, &CreateAPIKeyRequest{Name: "test", DailyQuota: quota})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, created.Key, &now
+}
+
+func TestDailyQuotaBoundary(t *testing.T) {
+	const quota = 3
+	s, key, _ := newTestAPIKey(t, quota)
+	ctx := context.Background()
+
+	for i := 1; i <= quota; i++ {
+		_, used, err := s.Authenticate(ctx, key)
+		if err != nil {
+			t.Fatalf("request %d of %d: %v", i, quota, err)
+		}
+		if used != int64(i) {
+			t.Fatalf("request %d usage = %d", i, used)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		_, used, err := s.Authenticate(ctx, key)
+		if !errors.Is(err, auth.ErrAPIKeyQuotaExceeded) {
+			t.Fatalf("request over quota error = %v, want ErrAPIKeyQuotaExceeded", err)
+		}
+		// 被拒绝的请求不计入用量
+		if used != quota {
+			t.Fatalf("usage after rejected request = %d, want %d", used, quota)
+		}
+	}
+}
+
+func TestDailyQuotaConcurrent(t *testing.T) {
+	const quota = 5
+	s, key, _ := newTestAPIKey(t, quota)
+	ctx := context.Background()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+	)
+	for i := 0; i < quota*3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := s.Authenticate(ctx, key)
+			if err != nil && !errors.Is(err, auth.ErrAPIKeyQuotaExceeded) {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				allowed++
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != quota {
+		t.Fatalf("%d concurrent requests allowed, want %d", allowed, quota)
+	}
+}
+
+func TestDailyQuotaRollover(t *testing.T) {
+	s, key, now := newTestAPIKey(t, 1)
+	ctx := context.Background()
+
+	if _, _, err := s.Authenticate(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	// 同一 UTC 日的最后一秒仍然超额
+	*now = now.Add(time.Second)
+	if _, _, err := s.Authenticate(ctx, key); !errors.Is(err, auth.ErrAPIKeyQuotaExceeded) {
+		t.Fatalf("error before midnight = %v, want ErrAPIKeyQuotaExceeded", err)
+	}
+
+	// 过了 UTC 零点重新计数
+	*now = now.Add(time.Second)
+	_, used, err := s.Authenticate(ctx, key)
+	if err != nil {
+		t.Fatalf("first request of the next day: %v", err)
+	}
+	if used != 1 {
+		t.Fatalf("usage on the next day = %d, want 1", used)
+	}
+
+	report, err := s.Usage(ctx, 1, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Days) != 2 || report.Days[0].Day != "2026-03-01" || report.Days[0].Count != 1 ||
+		report.Days[1].Day != "2026-03-02" || report.Days[1].Count != 1 {
+		t.Fatalf("usage report = %+v", report.Days)
+	}
+	if report.APIKey.UsageToday != 1 || report.Total != 2 {
+		t.Fatalf("usage today = %d, total = %d", report.APIKey.UsageToday, report.Total)
+	}
+}
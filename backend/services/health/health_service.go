@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"poem/backend/models"
+	"poem/backend/pkg/buildinfo"
+	"poem/backend/repository"
+	"time"
+)
+
+// 检查结果状态。degraded 只提示，不影响就绪
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// checkTimeout 单次就绪检查的总超时，应小于编排系统探针的超时
+const checkTimeout = 3 * time.Second
+
+// requiredModels 服务运行所需的表：ETL 导入的语料表和用户表
+var requiredModels = []interface{}{
+	&models.Dynasty{}, &models.Category{}, &models.Author{}, &models.AuthorAlias{},
+	&models.Work{}, &models.Comment{}, &models.Anthology{}, &models.AnthologyWork{},
+	&models.User{}, &models.UserFavorite{}, &models.UserHistory{},
+}
+
+// Check 单项检查结果
+type Check struct {
+	Status     string   `json:"status"`
+	Message    string   `json:"message,omitempty"`
+	DurationMS float64  `json:"duration_ms"`
+	Missing    []string `json:"missing,omitempty"`
+}
+
+// Report 就绪检查报告
+type Report struct {
+	Status         string           `json:"status"`
+	Checks         map[string]Check `json:"checks"`
+	Build          buildinfo.Info   `json:"build"`
+	DataImportedAt *time.Time       `json:"data_imported_at"`
+}
+
+// Ready 所有检查都没有失败
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// HealthService 存活和就绪检查
+type HealthService struct {
+	repo repository.HealthRepository
+}
+
+// NewHealthService 创建健康检查服务
+func NewHealthService(repo repository.HealthRepository) *HealthService {
+	return &HealthService{repo: repo}
+}
+
+// Readiness 依次检查数据库连接、迁移版本、表结构、作品数据和搜索索引。连接失败时跳过其余检查
+func (s *HealthService) Readiness(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Check), Build: buildinfo.Get()}
+	run := func(name string, fn func() Check) {
+		start := time.Now()
+		c := fn()
+		c.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+		report.Checks[name] = c
+		switch {
+		case c.Status == StatusFail:
+			report.Status = StatusFail
+		case c.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	run("database", func() Check {
+		if err := s.repo.Ping(ctx); err != nil {
+			return Check{Status: StatusFail, Message: err.Error()}
+		}
+		return Check{Status: StatusOK}
+	})
+	if !report.Ready() {
+		return report
+	}
+
+	run("migrations", func() Check {
+		if err := s.repo.CheckMigrations(ctx); err != nil {
+			return Check{Status: StatusFail, Message: err.Error()}
+		}
+		return Check{Status: StatusOK}
+	})
+
+	run("schema", func() Check {
+		missing, err := s.repo.MissingSchema(ctx, requiredModels...)
+		if err != nil {
+			return Check{Status: StatusFail, Message: err.Error()}
+		}
+		if len(missing) > 0 {
+			return Check{Status: StatusFail, Message: "schema is out of date, run `manage migrate up` and `manage etl`", Missing: missing}
+		}
+		return Check{Status: StatusOK}
+	})
+
+	run("data", func() Check {
+		ok, err := s.repo.HasWorks(ctx)
+		if err != nil {
+			return Check{Status: StatusFail, Message: err.Error()}
+		}
+		if !ok {
+			return Check{Status: StatusFail, Message: "works table is empty, run `manage etl`"}
+		}
+		return Check{Status: StatusOK}
+	})
+
+	// SQLite 搜索走 LIKE 查询，PostgreSQL/MySQL 另有全文索引；缺少索引时仍可用，只是变慢
+	run("search", func() Check {
+		engine := "engine=" + s.repo.SearchEngine(ctx)
+		missing := s.repo.MissingIndexes(ctx)
+		if len(missing) > 0 {
+			return Check{Status: StatusDegraded, Message: engine + ", search falls back to full table scans", Missing: missing}
+		}
+		return Check{Status: StatusOK, Message: engine}
+	})
+
+	run("import", func() Check {
+		importRun, err := s.repo.LatestImportRun(ctx)
+		if err != nil {
+			return Check{Status: StatusDegraded, Message: err.Error()}
+		}
+		if importRun == nil {
+			return Check{Status: StatusDegraded, Message: "no import run recorded"}
+		}
+		report.DataImportedAt = &importRun.FinishedAt
+		return Check{Status: StatusOK, Message: fmt.Sprintf("%d works imported", importRun.Works)}
+	})
+
+	return report
+}
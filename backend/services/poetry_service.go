@@ -1,14 +1,41 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
 	"poem/backend/models"
+	"poem/backend/pkg/metrics"
+	"poem/backend/pkg/tracing"
 	"poem/backend/repository"
-	"strings"
+	"poem/backend/services/cache"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrInvalidCursor 分页游标无效
+var ErrInvalidCursor = repository.ErrInvalidCursor
+
+var tracer = otel.Tracer("poem/backend/services")
+
 // PoetryService 诗词服务
 type PoetryService struct {
 	repo *repository.PoetryRepository
+
+	searchDuration *metrics.HistogramVec
+
+	// 查询结果缓存，键带数据版本，语料变化后旧条目不再命中
+	cache         cache.Cache
+	checkInterval time.Duration
+
+	versionMu sync.Mutex
+	version   *models.DataVersion // 最近一次读到的数据版本
+	checkedAt time.Time
 }
 
 // NewPoetryService 创建诗词服务
@@ -16,25 +43,93 @@ func NewPoetryService(repo *repository.PoetryRepository) *PoetryService {
 	return &PoetryService{repo: repo}
 }
 
-// GetPoems 获取诗词列表
-func (s *PoetryService) GetPoems(page, pageSize int, categoryName string) (models.PoemCollection, error) {
-	if page < 1 {
-		page = 1
+// EnableMetrics 记录搜索耗时，按是否出错（result 为 ok/error）区分
+func (s *PoetryService) EnableMetrics(registry *metrics.Registry) {
+	s.searchDuration = registry.NewHistogram("poetry_search_duration_seconds",
+		"Full-text search latency in seconds.", nil, "result")
+}
+
+// EnableCache 缓存分类、作者列表和诗词详情的查询结果。数据版本每隔 checkInterval 从数据库读取一次，
+// 版本变化时清空缓存，因此 ETL 或管理后台修改语料后最多延迟 checkInterval 生效。
+// 开启后 GetPoemByID、GetAuthors、GetCategories 返回的值与缓存及其他请求共享，调用方只能读取，不能修改
+func (s *PoetryService) EnableCache(c cache.Cache, checkInterval time.Duration) {
+	s.cache = c
+	s.checkInterval = checkInterval
+}
+
+// DataVersion 当前的语料数据版本，用于缓存失效和 HTTP 的 ETag/Last-Modified
+func (s *PoetryService) DataVersion(ctx context.Context) (*models.DataVersion, error) {
+	s.versionMu.Lock()
+	version, checkedAt := s.version, s.checkedAt
+	s.versionMu.Unlock()
+	if version != nil && time.Since(checkedAt) < s.checkInterval {
+		return version, nil
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+
+	latest, err := s.repo.DataVersion(ctx, models.DataVersionCorpus)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.repo.GetPoems(page, pageSize, categoryName)
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	if s.version != nil && s.version.Version != latest.Version && s.cache != nil {
+		s.cache.Purge()
+		slog.InfoContext(ctx, "data version changed, cache purged", "from", s.version.Version, "to", latest.Version)
+	}
+	s.version, s.checkedAt = latest, time.Now()
+	return latest, nil
+}
+
+// cached 按数据版本缓存 load 的结果，出错的结果不缓存；读取数据版本失败时不使用缓存。
+// 返回的值不复制，缓存命中时各请求拿到的是同一份数据
+func (s *PoetryService) cached(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	if s.cache == nil {
+		return load()
+	}
+	version, err := s.DataVersion(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "read data version failed, cache bypassed", "error", err)
+		return load()
+	}
+	key = strconv.FormatInt(version.Version, 10) + ":" + key
+	if value, ok := s.cache.Get(key); ok {
+		return value, nil
+	}
+	value, err := load()
+	if err == nil {
+		s.cache.Set(key, value)
+	}
+	return value, err
+}
+
+// GetPoems 获取诗词列表
+func (s *PoetryService) GetPoems(ctx context.Context, pq models.PageQuery, categoryName, anthologyName string) (result models.PoemCollection, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetPoems", trace.WithAttributes(
+		attribute.String("poetry.category", categoryName),
+		attribute.String("poetry.anthology", anthologyName),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.GetPoems(ctx, pq.Normalize(), categoryName, anthologyName)
 }
 
 // GetPoemByID 获取单首诗词
-func (s *PoetryService) GetPoemByID(id string) (*models.Work, error) {
-	return s.repo.GetPoemByID(id)
+func (s *PoetryService) GetPoemByID(ctx context.Context, id string) (work *models.Work, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetPoemByID", trace.WithAttributes(attribute.String("poetry.id", id)))
+	defer func() { tracing.End(span, err) }()
+
+	value, err := s.cached(ctx, "poem:"+id, func() (interface{}, error) {
+		return s.repo.GetPoemByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*models.Work), nil
 }
 
 // GetRandomPoems 获取随机诗词
-func (s *PoetryService) GetRandomPoems(count int, categoryName string) ([]models.Work, error) {
+func (s *PoetryService) GetRandomPoems(ctx context.Context, count int, categoryName string) (works []models.Work, err error) {
 	if count < 1 {
 		count = 1
 	}
@@ -42,79 +137,121 @@ func (s *PoetryService) GetRandomPoems(count int, categoryName string) ([]models
 		count = 10
 	}
 
-	return s.repo.GetRandomPoems(count, categoryName)
+	ctx, span := tracer.Start(ctx, "PoetryService.GetRandomPoems", trace.WithAttributes(
+		attribute.Int("poetry.count", count),
+		attribute.String("poetry.category", categoryName),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.GetRandomPoems(ctx, count, categoryName)
 }
 
 // GetPoemsByAuthor 获取作者的诗词
-func (s *PoetryService) GetPoemsByAuthor(authorName string, page, pageSize int) (models.PoemCollection, error) {
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
+func (s *PoetryService) GetPoemsByAuthor(ctx context.Context, authorName, dynasty string, pq models.PageQuery) (result models.PoemCollection, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetPoemsByAuthor", trace.WithAttributes(
+		attribute.String("poetry.author", authorName),
+		attribute.String("poetry.dynasty", dynasty),
+	))
+	defer func() { tracing.End(span, err) }()
 
-	return s.repo.GetPoemsByAuthor(authorName, page, pageSize)
+	return s.repo.GetPoemsByAuthor(ctx, authorName, models.NormalizeDynasty(dynasty), pq.Normalize())
 }
 
 // GetAuthors 获取作者列表
-func (s *PoetryService) GetAuthors(page, pageSize int, dynasty string) (models.AuthorCollection, error) {
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
+func (s *PoetryService) GetAuthors(ctx context.Context, pq models.PageQuery, filter models.AuthorFilter) (result models.AuthorCollection, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetAuthors", trace.WithAttributes(
+		attribute.String("poetry.dynasty", filter.Dynasty),
+		attribute.String("poetry.sort", filter.SortBy),
+	))
+	defer func() { tracing.End(span, err) }()
 
 	// Map API dynasty code to DB Chinese value
-	dbDynasty := mapDynasty(dynasty)
+	filter.Dynasty = models.NormalizeDynasty(filter.Dynasty)
 
-	return s.repo.GetAuthors(page, pageSize, dbDynasty)
+	pq = pq.Normalize()
+	value, err := s.cached(ctx, authorsKey(pq, filter), func() (interface{}, error) {
+		return s.repo.GetAuthors(ctx, pq, filter)
+	})
+	if err != nil {
+		return models.AuthorCollection{}, err
+	}
+	return value.(models.AuthorCollection), nil
 }
 
-func mapDynasty(dynasty string) string {
-	// Normalize to lower case
-	key := strings.ToLower(dynasty)
-
-	mapping := map[string]string{
-		"tang":    "唐",
-		"song":    "宋",
-		"yuan":    "元",
-		"ming":    "明",
-		"qing":    "清",
-		"han":     "汉/魏", // Mapping han to 汉/魏 for Cao Cao etc.
-		"wei-jin": "魏晋",
-		"pre-qin": "先秦",
-		"wudai":   "五代",
-	}
-	if val, ok := mapping[key]; ok {
-		return val
+// authorsKey 作者列表的缓存键
+func authorsKey(pq models.PageQuery, filter models.AuthorFilter) string {
+	year := func(y *int) string {
+		if y == nil {
+			return ""
+		}
+		return strconv.Itoa(*y)
 	}
-	return dynasty
+	return fmt.Sprintf("authors:%d:%d:%q:%t:%q:%s:%s:%s:%q:%t", pq.Page, pq.PageSize, pq.Cursor, pq.WithTotal,
+		filter.Dynasty, year(filter.AliveIn), year(filter.BornAfter), year(filter.BornBefore), filter.SortBy, filter.Desc)
 }
 
 // GetAuthorByName 获取作者详情
-func (s *PoetryService) GetAuthorByName(name string) (*models.Author, error) {
-	return s.repo.GetAuthorByName(name)
+func (s *PoetryService) GetAuthorByName(ctx context.Context, name, dynasty string) (author *models.Author, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetAuthorByName", trace.WithAttributes(
+		attribute.String("poetry.author", name),
+		attribute.String("poetry.dynasty", dynasty),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.GetAuthorByName(ctx, name, models.NormalizeDynasty(dynasty))
 }
 
 // Search 搜索
-func (s *PoetryService) Search(query string, page, pageSize int) (models.SearchResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
-	}
+func (s *PoetryService) Search(ctx context.Context, query string, pq models.PageQuery) (result models.SearchResponse, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.Search", trace.WithAttributes(attribute.String("poetry.query", query)))
+	defer func() { tracing.End(span, err) }()
 
-	return s.repo.Search(query, page, pageSize)
+	start := time.Now()
+	result, err = s.repo.Search(ctx, query, pq.Normalize())
+	if s.searchDuration != nil {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+		s.searchDuration.With(outcome).Observe(time.Since(start).Seconds())
+	}
+	span.SetAttributes(attribute.Int("poetry.total", result.Total))
+	return result, err
 }
 
 // GetCategories 获取分类列表
-func (s *PoetryService) GetCategories() []models.Category {
-	categories, err := s.repo.GetCategories()
+func (s *PoetryService) GetCategories(ctx context.Context) []models.Category {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetCategories")
+	value, err := s.cached(ctx, "categories", func() (interface{}, error) {
+		return s.repo.GetCategories(ctx)
+	})
+	tracing.End(span, err)
 	if err != nil {
 		return []models.Category{}
 	}
-	return categories
+	return value.([]models.Category)
+}
+
+// GetAnthologies 获取选集列表
+func (s *PoetryService) GetAnthologies(ctx context.Context) []models.Anthology {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetAnthologies")
+	anthologies, err := s.repo.GetAnthologies(ctx)
+	tracing.End(span, err)
+	if err != nil {
+		return []models.Anthology{}
+	}
+	return anthologies
+}
+
+// GetDynasties 获取朝代列表（按时间先后），附带作者和作品数量
+func (s *PoetryService) GetDynasties(ctx context.Context) (dynasties []models.DynastyStats, err error) {
+	ctx, span := tracer.Start(ctx, "PoetryService.GetDynasties")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.GetDynasties(ctx)
+}
+
+// LatestImportRun 获取最近一次 ETL 导入记录
+func (s *PoetryService) LatestImportRun(ctx context.Context) (*models.ImportRun, error) {
+	return s.repo.LatestImportRun(ctx)
 }
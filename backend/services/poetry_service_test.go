@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"poem/backend/services/cache"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCachePurgedAfterDataVersionBump(t *testing.T) {
+	repo, db := testdb.Poetry(t)
+	category := models.Category{Name: "tangshi", DisplayName: "唐诗"}
+	if err := db.Create(&category).Error; err != nil {
+		t.Fatal(err)
+	}
+	work := models.Work{CategoryID: category.ID, Title: "静夜思", Content: models.JSONArr{"床前明月光，疑是地上霜。"}}
+	if err := db.Omit("Author", "Category").Create(&work).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	lru := cache.NewLRU(100, time.Hour)
+	s := NewPoetryService(repo)
+	// 每次都重新读取数据版本
+	s.EnableCache(lru, 0)
+	ctx := context.Background()
+	id := strconv.FormatUint(uint64(work.ID), 10)
+
+	title := func() string {
+		t.Helper()
+		poem, err := s.GetPoemByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return poem.Title
+	}
+	if got := title(); got != "静夜思" {
+		t.Fatalf("title = %q", got)
+	}
+
+	// 绕过数据版本直接改库，缓存仍返回旧值
+	if err := db.Model(&work).Update("title", "夜思").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := title(); got != "静夜思" {
+		t.Fatalf("title before version bump = %q, want the cached value", got)
+	}
+	if hits, _ := lru.Stats(); hits != 1 {
+		t.Fatalf("cache hits = %d, want 1", hits)
+	}
+
+	if err := repository.BumpDataVersion(db, models.DataVersionCorpus); err != nil {
+		t.Fatal(err)
+	}
+	if got := title(); got != "夜思" {
+		t.Fatalf("title after version bump = %q, want 夜思", got)
+	}
+	// 旧版本的条目已被清除，而不只是换了键
+	if _, ok := lru.Get("1:poem:" + id); ok {
+		t.Fatal("entry of the previous data version survived the purge")
+	}
+	// 清空后再次读取命中新版本的条目
+	if got := title(); got != "夜思" {
+		t.Fatalf("title = %q", got)
+	}
+	if hits, misses := lru.Stats(); hits != 2 || misses != 3 {
+		t.Fatalf("cache hits/misses = %d/%d, want 2/3", hits, misses)
+	}
+}
@@ -0,0 +1,163 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/pkg/mail"
+	"poem/backend/repository"
+	"strings"
+	"time"
+)
+
+// passwordResetTTL 找回密码链接的有效期
+const passwordResetTTL = 30 * time.Minute
+
+// passwordResetLimit 每个账号每小时最多发送的找回密码邮件数，超出后静默忽略
+const passwordResetLimit = 3
+
+var (
+	ErrWrongPassword         = errors.New("原密码错误")
+	ErrInvalidResetToken     = errors.New("重置链接无效或已过期")
+	ErrPasswordResetDisabled = errors.New("未启用找回密码")
+)
+
+// ChangePasswordRequest 修改密码请求
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=50"`
+}
+
+// ForgotPasswordRequest 申请找回密码请求
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// ResetPasswordRequest 通过邮件中的令牌重置密码
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=50"`
+}
+
+// EnablePasswordReset 启用找回密码。resetURL 为前端重置页面地址，令牌以 token 查询参数附加在后面
+func (s *UserService) EnablePasswordReset(repo repository.PasswordResetRepository, mailer mail.Mailer, resetURL string) {
+	s.resetRepo = repo
+	s.mailer = mailer
+	s.resetURL = resetURL
+}
+
+// ChangePassword 校验原密码后修改密码，并注销当前会话以外的所有会话
+func (s *UserService) ChangePassword(ctx context.Context, userID uint, sessionID string, req *ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if !auth.VerifyPassword(user.PasswordHash, req.OldPassword) {
+		return ErrWrongPassword
+	}
+
+	if err := s.setPassword(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+	return s.RevokeAllTokens(ctx, userID, sessionID, "password_changed")
+}
+
+// RequestPasswordReset 为邮箱对应的账号发送找回密码邮件。
+// 邮箱不存在、账号已禁用或申请过于频繁时同样返回成功，避免泄露账号是否存在
+func (s *UserService) RequestPasswordReset(ctx context.Context, email, ip string) error {
+	if s.resetRepo == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil || user.Status != 1 {
+		return nil
+	}
+
+	recent, err := s.resetRepo.CountSince(ctx, user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if recent >= passwordResetLimit {
+		slog.WarnContext(ctx, "password reset throttled", "user_id", user.ID)
+		return nil
+	}
+
+	// 新链接发出后旧链接随即失效
+	if err := s.resetRepo.InvalidateForUser(ctx, user.ID); err != nil {
+		return err
+	}
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	record := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		IP:        ip,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := s.resetRepo.Create(ctx, record); err != nil {
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "重置密码",
+		Body: fmt.Sprintf("%s，你好：\n\n请在 %d 分钟内打开以下链接重置密码：\n\n%s\n\n如果这不是你本人的操作，请忽略这封邮件。\n",
+			user.Nickname, int(passwordResetTTL.Minutes()), s.resetLink(token)),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		slog.ErrorContext(ctx, "send password reset mail failed", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// ResetPassword 用找回密码令牌设置新密码，令牌只能使用一次；成功后注销所有会话
+func (s *UserService) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	if s.resetRepo == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	record, err := s.resetRepo.GetByTokenHash(ctx, auth.HashToken(req.Token))
+	if err != nil || record.UsedAt != nil || time.Now().After(record.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+	ok, err := s.resetRepo.MarkUsed(ctx, record.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if err := s.setPassword(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+	return s.RevokeAllTokens(ctx, user.ID, "", "password_reset")
+}
+
+func (s *UserService) setPassword(ctx context.Context, user *models.User, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = hash
+	return s.userRepo.Update(ctx, user)
+}
+
+func (s *UserService) resetLink(token string) string {
+	sep := "?"
+	if strings.Contains(s.resetURL, "?") {
+		sep = "&"
+	}
+	return s.resetURL + sep + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,209 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/pkg/mail"
+	"poem/backend/repository"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMailer 记录发出的邮件
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []mail.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var resetLinkPattern = regexp.MustCompile(`https://example\.com/reset\?token=(\S+)`)
+
+// lastToken 取出最近一封邮件中重置链接的令牌
+func (m *recordingMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+	match := resetLinkPattern.FindStringSubmatch(m.sent[len(m.sent)-1].Body)
+	if match == nil {
+		t.Fatalf("no reset link in %q", m.sent[len(m.sent)-1].Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// newTestService 创建启用找回密码的用户服务，并建好一个有邮箱的用户
+func newTestService(t *testing.T) (*UserService, *recordingMailer, repository.PasswordResetRepository, *models.User) {
+	t.Helper()
+	db := testdb.New(t)
+	userRepo, _ := repository.NewUserRepository(db)
+	sessionRepo, _ := repository.NewSessionRepository(db)
+	revokedRepo, _ := repository.NewRevokedTokenRepository(db)
+	resetRepo, _ := repository.NewPasswordResetRepository(db)
+	jwtManager, err := auth.NewJWTManager(auth.Config{Secret: "test-secret-at-least-32-characters", TokenDuration: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewUserService(userRepo, sessionRepo, jwtManager, auth.NewDenylist(revokedRepo, 100))
+	mailer := &recordingMailer{}
+	s.EnablePasswordReset(resetRepo, mailer, "https://example.com/reset")
+
+	hash, err := auth.HashPassword("old-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: "alice", Nickname: "Alice", Email: "alice@example.com", PasswordHash: hash, Status: 1}
+	if err := userRepo.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return s, mailer, resetRepo, user
+}
+
+func TestResetPasswordSingleUse(t *testing.T) {
+	s, mailer, _, user := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.RequestPasswordReset(ctx, user.Email, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	token := mailer.lastToken(t)
+
+	if err := s.ResetPassword(ctx, &ResetPasswordRequest{Token: token, NewPassword: "new-password"}); err != nil {
+		t.Fatalf("first reset: %v", err)
+	}
+	err := s.ResetPassword(ctx, &ResetPasswordRequest{Token: token, NewPassword: "another-password"})
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("second reset error = %v, want ErrInvalidResetToken", err)
+	}
+
+	updated, err := s.userRepo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.VerifyPassword(updated.PasswordHash, "new-password") {
+		t.Fatal("password was not changed by the first reset")
+	}
+}
+
+func TestResetPasswordConcurrentUse(t *testing.T) {
+	s, mailer, _, user := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.RequestPasswordReset(ctx, user.Email, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	token := mailer.lastToken(t)
+
+	// 同一令牌同时提交多次，MarkUsed 的条件更新保证只有一次成功
+	const attempts = 8
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- s.ResetPassword(ctx, &ResetPasswordRequest{Token: token, NewPassword: "new-password"})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrInvalidResetToken):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d resets succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestMarkUsedOnce(t *testing.T) {
+	_, _, resetRepo, user := newTestService(t)
+	ctx := context.Background()
+
+	_, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &models.PasswordResetToken{UserID: user.ID, TokenHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := resetRepo.Create(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []bool{true, false} {
+		ok, err := resetRepo.MarkUsed(ctx, record.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != want {
+			t.Fatalf("MarkUsed call %d = %v, want %v", i+1, ok, want)
+		}
+	}
+}
+
+func TestResetPasswordExpired(t *testing.T) {
+	s, _, resetRepo, user := newTestService(t)
+	ctx := context.Background()
+
+	token, hash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &models.PasswordResetToken{UserID: user.ID, TokenHash: hash, ExpiresAt: time.Now().Add(-time.Second)}
+	if err := resetRepo.Create(ctx, record); err != nil {
+		t.Fatal(err)
+	}
+
+	err = s.ResetPassword(ctx, &ResetPasswordRequest{Token: token, NewPassword: "new-password"})
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("reset with expired token error = %v, want ErrInvalidResetToken", err)
+	}
+}
+
+func TestRequestPasswordResetInvalidatesPrevious(t *testing.T) {
+	s, mailer, _, user := newTestService(t)
+	ctx := context.Background()
+
+	if err := s.RequestPasswordReset(ctx, user.Email, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	first := mailer.lastToken(t)
+	if err := s.RequestPasswordReset(ctx, user.Email, "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	second := mailer.lastToken(t)
+	if first == second {
+		t.Fatal("second request reused the first token")
+	}
+
+	err := s.ResetPassword(ctx, &ResetPasswordRequest{Token: first, NewPassword: "new-password"})
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Fatalf("reset with superseded token error = %v, want ErrInvalidResetToken", err)
+	}
+	if err := s.ResetPassword(ctx, &ResetPasswordRequest{Token: second, NewPassword: "new-password"}); err != nil {
+		t.Fatalf("reset with latest token: %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// refreshTokenDuration 刷新令牌有效期，每次轮换重新计算
+const refreshTokenDuration = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("刷新令牌无效或已过期")
+	ErrRefreshTokenReused  = errors.New("刷新令牌已被使用，会话已注销")
+	ErrSessionNotFound     = errors.New("会话不存在")
+)
+
+// ClientInfo 发起登录或刷新的客户端信息，记录在会话上
+type ClientInfo struct {
+	Device    string
+	IP        string
+	UserAgent string
+}
+
+// startSession 为新登录创建会话并签发access token和刷新令牌
+func (s *UserService) startSession(ctx context.Context, user *models.User, client ClientInfo) (*LoginResponse, error) {
+	return s.issueTokens(ctx, user, uuid.New().String(), client)
+}
+
+// issueTokens 在会话 familyID 下签发一对新令牌
+func (s *UserService) issueTokens(ctx context.Context, user *models.User, familyID string, client ClientInfo) (*LoginResponse, error) {
+	refreshToken, refreshHash, err := auth.GenerateOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token, claims, err := s.jwtManager.GenerateToken(user.ID, user.Username, user.Role, familyID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 记录access token的 jti，会话作废时据此吊销尚未过期的access token
+	session := &models.UserSession{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshHash,
+		AccessJTI: claims.ID,
+		AccessExp: claims.ExpiresAt.Time,
+		Device:    client.Device,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Now().Add(refreshTokenDuration),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &LoginResponse{
+		Token:            token,
+		ExpiresAt:        claims.ExpiresAt.Unix(),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt.Unix(),
+		SessionID:        familyID,
+		User:             toUserInfo(user),
+	}, nil
+}
+
+// RefreshSession 用刷新令牌换取新令牌。旧令牌随即失效；已轮换的令牌再次使用时整个会话作废
+func (s *UserService) RefreshSession(ctx context.Context, refreshToken string, client ClientInfo) (*LoginResponse, error) {
+	session, err := s.sessionRepo.GetByTokenHash(ctx, auth.HashToken(refreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.RotatedAt != nil {
+		s.revokeFamily(ctx, session.UserID, session.FamilyID, "refresh_reused")
+		return nil, ErrRefreshTokenReused
+	}
+
+	rotated, err := s.sessionRepo.MarkRotated(ctx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// 并发请求抢先完成了轮换，按重用处理
+		s.revokeFamily(ctx, session.UserID, session.FamilyID, "refresh_reused")
+		return nil, ErrRefreshTokenReused
+	}
+
+	user, err := s.userRepo.GetByIDAnyStatus(ctx, session.UserID)
+	if err != nil {
+		s.revokeFamily(ctx, session.UserID, session.FamilyID, "user_not_found")
+		return nil, ErrInvalidRefreshToken
+	}
+	// DisableUser 先改状态再作废会话，作废失败或直接改库禁用时仍需在这里拦住
+	if user.Status != 1 {
+		s.revokeFamily(ctx, session.UserID, session.FamilyID, "disabled")
+		return nil, ErrUserDisabled
+	}
+
+	if client.Device == "" {
+		client.Device = session.Device
+	}
+	return s.issueTokens(ctx, user, session.FamilyID, client)
+}
+
+// Logout 注销当前会话，并吊销发起请求的access token及该会话签发过的其他access token
+func (s *UserService) Logout(ctx context.Context, claims *auth.Claims) error {
+	if err := s.denylist.Revoke(ctx, claims.ID, claims.UserID, "logout", claims.ExpiresAt.Time); err != nil {
+		return err
+	}
+	if claims.SessionID == "" {
+		return nil
+	}
+	if _, err := s.sessionRepo.RevokeUserSession(ctx, claims.UserID, claims.SessionID); err != nil {
+		return err
+	}
+	return s.revokeAccessTokens(ctx, claims.UserID, "logout", func(familyID string) bool {
+		return familyID == claims.SessionID
+	})
+}
+
+// ListSessions 列出用户当前有效的会话，currentSessionID 对应的会话标记为 current
+func (s *UserService) ListSessions(ctx context.Context, userID uint, currentSessionID string) ([]models.UserSession, error) {
+	sessions, err := s.sessionRepo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].FamilyID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession 注销用户的指定会话
+func (s *UserService) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	revoked, err := s.sessionRepo.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return s.revokeAccessTokens(ctx, userID, "session_revoked", func(familyID string) bool {
+		return familyID == sessionID
+	})
+}
+
+// RevokeAllTokens 作废用户除 exceptSessionID 外的所有会话并吊销对应的access token，
+// 用于修改密码、禁用账号等场景；exceptSessionID 为空时全部作废
+func (s *UserService) RevokeAllTokens(ctx context.Context, userID uint, exceptSessionID, reason string) error {
+	if err := s.sessionRepo.RevokeAllForUser(ctx, userID, exceptSessionID); err != nil {
+		return err
+	}
+	return s.revokeAccessTokens(ctx, userID, reason, func(familyID string) bool {
+		return exceptSessionID == "" || familyID != exceptSessionID
+	})
+}
+
+// DisableUser 禁用账号并立即吊销其所有令牌
+func (s *UserService) DisableUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	user.Status = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.RevokeAllTokens(ctx, userID, "", "disabled")
+}
+
+// revokeFamily 作废整个会话及其access token，用于刷新令牌被重用等无法返回错误的场景
+func (s *UserService) revokeFamily(ctx context.Context, userID uint, familyID, reason string) {
+	if err := s.sessionRepo.RevokeFamily(ctx, familyID); err != nil {
+		slog.ErrorContext(ctx, "revoke session failed", "family_id", familyID, "error", err)
+	}
+	err := s.revokeAccessTokens(ctx, userID, reason, func(id string) bool {
+		return id == familyID
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "revoke session access tokens failed", "family_id", familyID, "error", err)
+	}
+}
+
+// revokeAccessTokens 把用户尚未过期、且所属会话满足 match 的access token加入吊销名单
+func (s *UserService) revokeAccessTokens(ctx context.Context, userID uint, reason string, match func(familyID string) bool) error {
+	sessions, err := s.sessionRepo.ListUnexpiredAccess(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if !match(session.FamilyID) {
+			continue
+		}
+		if err := s.denylist.Revoke(ctx, session.AccessJTI, userID, reason, session.AccessExp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"poem/backend/internal/testdb"
+	"poem/backend/models"
+	"poem/backend/pkg/auth"
+	"poem/backend/repository"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// newSessionService 创建用户服务并注册 alice，返回服务和数据库
+func newSessionService(t *testing.T) (*UserService, *gorm.DB) {
+	t.Helper()
+	db := testdb.New(t)
+	userRepo, _ := repository.NewUserRepository(db)
+	sessionRepo, _ := repository.NewSessionRepository(db)
+	revokedRepo, _ := repository.NewRevokedTokenRepository(db)
+	jwtManager, err := auth.NewJWTManager(auth.Config{Secret: "test-secret-at-least-32-characters", TokenDuration: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewUserService(userRepo, sessionRepo, jwtManager, auth.NewDenylist(revokedRepo, 100))
+	if _, err := s.CreateUser(context.Background(), &RegisterRequest{Username: "alice", Password: "alice-password"}, auth.RoleUser); err != nil {
+		t.Fatal(err)
+	}
+	return s, db
+}
+
+func login(t *testing.T, s *UserService) *LoginResponse {
+	t.Helper()
+	resp, err := s.Login(context.Background(), &LoginRequest{Username: "alice", Password: "alice-password"}, ClientInfo{IP: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+// accessRevoked access token 是否已被吊销
+func accessRevoked(t *testing.T, s *UserService, token string) bool {
+	t.Helper()
+	claims, err := s.jwtManager.ValidateToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.denylist.IsRevoked(context.Background(), claims.ID, claims.ExpiresAt.Time)
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	s, _ := newSessionService(t)
+	ctx := context.Background()
+	first := login(t, s)
+
+	second, err := s.RefreshSession(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken || second.Token == first.Token {
+		t.Fatal("refresh did not issue new tokens")
+	}
+	if second.SessionID != first.SessionID {
+		t.Fatalf("session id changed from %s to %s", first.SessionID, second.SessionID)
+	}
+
+	third, err := s.RefreshSession(ctx, second.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatalf("refresh rotated token: %v", err)
+	}
+	if accessRevoked(t, s, third.Token) {
+		t.Fatal("fresh access token is revoked")
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	s, _ := newSessionService(t)
+	ctx := context.Background()
+	first := login(t, s)
+
+	second, err := s.RefreshSession(ctx, first.RefreshToken, ClientInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 已轮换的令牌再次使用，整个会话作废
+	if _, err := s.RefreshSession(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse error = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := s.RefreshSession(ctx, second.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after reuse error = %v, want ErrInvalidRefreshToken", err)
+	}
+	if !accessRevoked(t, s, second.Token) {
+		t.Fatal("access token of the reused session is not revoked")
+	}
+}
+
+func TestRefreshConcurrentUse(t *testing.T) {
+	s, _ := newSessionService(t)
+	ctx := context.Background()
+	first := login(t, s)
+
+	const attempts = 8
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.RefreshSession(ctx, first.RefreshToken, ClientInfo{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrRefreshTokenReused) && !errors.Is(err, ErrInvalidRefreshToken):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d refreshes succeeded, want exactly 1", succeeded)
+	}
+}
+
+func TestMarkRotatedOnce(t *testing.T) {
+	s, _ := newSessionService(t)
+	ctx := context.Background()
+	first := login(t, s)
+	session, err := s.sessionRepo.GetByTokenHash(ctx, auth.HashToken(first.RefreshToken))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 8
+	results := make(chan bool, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := s.sessionRepo.MarkRotated(ctx, session.ID)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- ok
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	rotated := 0
+	for ok := range results {
+		if ok {
+			rotated++
+		}
+	}
+	if rotated != 1 {
+		t.Fatalf("MarkRotated succeeded %d times, want exactly 1", rotated)
+	}
+}
+
+func TestRefreshDisabledUser(t *testing.T) {
+	s, db := newSessionService(t)
+	ctx := context.Background()
+	first := login(t, s)
+
+	// 直接改库禁用，不经过 DisableUser 作废会话
+	if err := db.Model(&models.User{}).Where("username = ?", "alice").Update("status", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshSession(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrUserDisabled) {
+		t.Fatalf("refresh error = %v, want ErrUserDisabled", err)
+	}
+	if !accessRevoked(t, s, first.Token) {
+		t.Fatal("access token of the disabled user is not revoked")
+	}
+	sessions, err := s.ListSessions(ctx, first.User.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 0 {
+		t.Fatalf("%d sessions still active, want 0", len(sessions))
+	}
+}
+
+func TestRefreshDeletedUser(t *testing.T) {
+	s, db := newSessionService(t)
+	first := login(t, s)
+
+	if err := db.Unscoped().Where("username = ?", "alice").Delete(&models.User{}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RefreshSession(context.Background(), first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh error = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	s, _ := newSessionService(t)
+	ctx := context.Background()
+	first := login(t, s)
+	other := login(t, s)
+
+	claims, err := s.jwtManager.ValidateToken(first.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Logout(ctx, claims); err != nil {
+		t.Fatal(err)
+	}
+	if !accessRevoked(t, s, first.Token) {
+		t.Fatal("access token is not revoked after logout")
+	}
+	if _, err := s.RefreshSession(ctx, first.RefreshToken, ClientInfo{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("refresh after logout error = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	// 其他会话不受影响
+	if accessRevoked(t, s, other.Token) {
+		t.Fatal("logout revoked another session's access token")
+	}
+	if _, err := s.RefreshSession(ctx, other.RefreshToken, ClientInfo{}); err != nil {
+		t.Fatalf("refresh other session: %v", err)
+	}
+}
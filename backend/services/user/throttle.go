@@ -0,0 +1,173 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"poem/backend/models"
+	"poem/backend/repository"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LoginThrottleConfig 登录防暴力破解配置。
+// 同一用户名或同一IP连续失败达到阈值后锁定，之后每多失败一次锁定时长翻倍，直到 MaxLockout
+type LoginThrottleConfig struct {
+	MaxUserFailures int           // 同一用户名允许的连续失败次数
+	MaxIPFailures   int           // 同一IP允许的连续失败次数
+	BaseLockout     time.Duration // 首次锁定时长
+	MaxLockout      time.Duration // 锁定时长上限
+	FailureWindow   time.Duration // 距上次失败（或锁定结束）超过该时长后重新计数
+}
+
+// LoginLockedError 登录被临时锁定
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return fmt.Sprintf("登录失败次数过多，请 %d 秒后再试", retrySeconds(e.RetryAfter))
+}
+
+// RetryAfterSeconds 向上取整的重试等待秒数，用于 Retry-After 响应头
+func (e *LoginLockedError) RetryAfterSeconds() int {
+	return retrySeconds(e.RetryAfter)
+}
+
+func retrySeconds(d time.Duration) int {
+	secs := int((d + time.Second - 1) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// loginThrottle 按用户名和IP统计登录失败
+type loginThrottle struct {
+	repo repository.LoginAttemptRepository
+	cfg  LoginThrottleConfig
+}
+
+// EnableLoginThrottle 启用登录失败计数、锁定和审计日志
+func (s *UserService) EnableLoginThrottle(repo repository.LoginAttemptRepository, cfg LoginThrottleConfig) {
+	s.throttle = &loginThrottle{repo: repo, cfg: cfg}
+}
+
+func userSubject(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
+
+// check 用户名或IP处于锁定期时返回 LoginLockedError
+func (t *loginThrottle) check(ctx context.Context, username string, client ClientInfo) error {
+	throttles, err := t.repo.GetThrottles(ctx, []string{userSubject(username), ipSubject(client.IP)})
+	if err != nil {
+		return err
+	}
+
+	var wait time.Duration
+	now := time.Now()
+	for _, th := range throttles {
+		if th.LockedUntil != nil && th.LockedUntil.After(now) {
+			if d := th.LockedUntil.Sub(now); d > wait {
+				wait = d
+			}
+		}
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	t.audit(ctx, &models.AuthAuditLog{
+		Event:     models.AuditLoginBlocked,
+		Username:  username,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+	})
+	return &LoginLockedError{RetryAfter: wait}
+}
+
+// fail 记录一次失败，达到阈值时锁定用户名或IP
+func (t *loginThrottle) fail(ctx context.Context, username string, userID uint, detail string, client ClientInfo) {
+	t.audit(ctx, &models.AuthAuditLog{
+		Event:     models.AuditLoginFailed,
+		UserID:    userID,
+		Username:  username,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Detail:    detail,
+	})
+
+	since := time.Now().Add(-t.cfg.FailureWindow)
+	limits := []struct {
+		subject string
+		max     int
+	}{
+		{userSubject(username), t.cfg.MaxUserFailures},
+		{ipSubject(client.IP), t.cfg.MaxIPFailures},
+	}
+	for _, l := range limits {
+		failures, err := t.repo.RecordFailure(ctx, l.subject, since)
+		if err != nil {
+			slog.ErrorContext(ctx, "record login failure failed", "subject", l.subject, "error", err)
+			continue
+		}
+		if l.max <= 0 || failures < l.max {
+			continue
+		}
+
+		lockout := t.lockout(failures - l.max)
+		if err := t.repo.Lock(ctx, l.subject, time.Now().Add(lockout)); err != nil {
+			slog.ErrorContext(ctx, "lock login subject failed", "subject", l.subject, "error", err)
+			continue
+		}
+		t.audit(ctx, &models.AuthAuditLog{
+			Event:     models.AuditLockout,
+			UserID:    userID,
+			Username:  username,
+			IP:        client.IP,
+			UserAgent: client.UserAgent,
+			Detail:    fmt.Sprintf("%s failures=%d lockout=%s", l.subject, failures, lockout),
+		})
+	}
+}
+
+// lockout 第 n 次超出阈值（从 0 开始）的锁定时长
+func (t *loginThrottle) lockout(n int) time.Duration {
+	d := t.cfg.BaseLockout
+	for i := 0; i < n && d < t.cfg.MaxLockout; i++ {
+		d *= 2
+	}
+	if t.cfg.MaxLockout > 0 && d > t.cfg.MaxLockout {
+		d = t.cfg.MaxLockout
+	}
+	return d
+}
+
+// succeed 登录成功后清除该用户名的失败计数。IP 的计数不清除，只随 FailureWindow 过期，
+// 否则攻击者每猜几次密码就用自己的账号登录一次，即可绕过按IP的锁定
+func (t *loginThrottle) succeed(ctx context.Context, username string) {
+	if err := t.repo.Reset(ctx, []string{userSubject(username)}); err != nil {
+		slog.ErrorContext(ctx, "reset login failures failed", "username", username, "error", err)
+	}
+}
+
+func (t *loginThrottle) audit(ctx context.Context, entry *models.AuthAuditLog) {
+	entry.Username = truncate(entry.Username, 50)
+	entry.UserAgent = truncate(entry.UserAgent, 255)
+	if err := t.repo.AddAudit(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "write auth audit log failed", "event", entry.Event, "error", err)
+	}
+}
+
+// truncate 按字符截断，避免超出列宽
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
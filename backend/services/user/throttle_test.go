@@ -0,0 +1,98 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"poem/backend/internal/testdb"
+	"poem/backend/pkg/auth"
+	"poem/backend/repository"
+	"testing"
+	"time"
+)
+
+// newThrottledService 创建启用登录防护的用户服务，并注册 alice 和 mallory 两个账号
+func newThrottledService(t *testing.T, cfg LoginThrottleConfig) *UserService {
+	t.Helper()
+	db := testdb.New(t)
+	userRepo, _ := repository.NewUserRepository(db)
+	sessionRepo, _ := repository.NewSessionRepository(db)
+	revokedRepo, _ := repository.NewRevokedTokenRepository(db)
+	attemptRepo, _ := repository.NewLoginAttemptRepository(db)
+	jwtManager, err := auth.NewJWTManager(auth.Config{Secret: "test-secret-at-least-32-characters", TokenDuration: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewUserService(userRepo, sessionRepo, jwtManager, auth.NewDenylist(revokedRepo, 100))
+	for _, name := range []string{"alice", "mallory"} {
+		if _, err := s.CreateUser(context.Background(), &RegisterRequest{Username: name, Password: name + "-password"}, auth.RoleUser); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.EnableLoginThrottle(attemptRepo, cfg)
+	return s
+}
+
+func TestSuccessfulLoginKeepsIPFailures(t *testing.T) {
+	s := newThrottledService(t, LoginThrottleConfig{
+		MaxUserFailures: 100,
+		MaxIPFailures:   3,
+		BaseLockout:     time.Minute,
+		MaxLockout:      time.Hour,
+		FailureWindow:   time.Hour,
+	})
+	ctx := context.Background()
+	attacker := ClientInfo{IP: "203.0.113.7"}
+
+	// 猜两次 alice 的密码后用自己的账号登录一次，IP 的失败计数不应被清零
+	for i := 0; i < 2; i++ {
+		if _, err := s.Login(ctx, &LoginRequest{Username: "alice", Password: "guess"}, attacker); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("guess %d: err = %v, want ErrInvalidCredentials", i+1, err)
+		}
+	}
+	if _, err := s.Login(ctx, &LoginRequest{Username: "mallory", Password: "mallory-password"}, attacker); err != nil {
+		t.Fatalf("own login: %v", err)
+	}
+	if _, err := s.Login(ctx, &LoginRequest{Username: "alice", Password: "guess"}, attacker); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("third guess: err = %v, want ErrInvalidCredentials", err)
+	}
+
+	var locked *LoginLockedError
+	if _, err := s.Login(ctx, &LoginRequest{Username: "alice", Password: "alice-password"}, attacker); !errors.As(err, &locked) {
+		t.Fatalf("login from locked IP: err = %v, want LoginLockedError", err)
+	}
+	// 其他IP不受影响
+	if _, err := s.Login(ctx, &LoginRequest{Username: "alice", Password: "alice-password"}, ClientInfo{IP: "198.51.100.1"}); err != nil {
+		t.Fatalf("login from another IP: %v", err)
+	}
+}
+
+func TestSuccessfulLoginResetsUserFailures(t *testing.T) {
+	s := newThrottledService(t, LoginThrottleConfig{
+		MaxUserFailures: 3,
+		MaxIPFailures:   100,
+		BaseLockout:     time.Minute,
+		MaxLockout:      time.Hour,
+		FailureWindow:   time.Hour,
+	})
+	ctx := context.Background()
+	client := ClientInfo{IP: "198.51.100.1"}
+
+	login := func(password string) error {
+		_, err := s.Login(ctx, &LoginRequest{Username: "alice", Password: password}, client)
+		return err
+	}
+	for i := 0; i < 2; i++ {
+		login("wrong")
+	}
+	if err := login("alice-password"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	// 计数已清零，再失败两次仍未达到阈值
+	for i := 0; i < 2; i++ {
+		login("wrong")
+	}
+	if err := login("alice-password"); err != nil {
+		t.Fatalf("login after reset: %v", err)
+	}
+}
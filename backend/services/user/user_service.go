@@ -3,30 +3,43 @@ package user
 import (
 	"context"
 	"errors"
+	"poem/backend/models"
 	"poem/backend/pkg/auth"
+	"poem/backend/pkg/mail"
 	"poem/backend/repository"
-	"poem/backend/models"
-	"time"
 )
 
 var (
-	ErrUserAlreadyExists = errors.New("用户已存在")
+	ErrUserAlreadyExists  = errors.New("用户已存在")
 	ErrInvalidCredentials = errors.New("用户名或密码错误")
-	ErrUserNotFound      = errors.New("用户不存在")
-	ErrUserDisabled      = errors.New("用户已被禁用")
+	ErrUserNotFound       = errors.New("用户不存在")
+	ErrUserDisabled       = errors.New("用户已被禁用")
+	ErrInvalidRole        = errors.New("无效的角色")
 )
 
 // UserService 用户服务
 type UserService struct {
-	userRepo   repository.UserRepository
-	jwtManager *auth.JWTManager
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	jwtManager  *auth.JWTManager
+	denylist    *auth.Denylist
+
+	// 找回密码，调用 EnablePasswordReset 后可用
+	resetRepo repository.PasswordResetRepository
+	mailer    mail.Mailer
+	resetURL  string
+
+	// 登录防暴力破解，调用 EnableLoginThrottle 后启用
+	throttle *loginThrottle
 }
 
 // NewUserService 创建用户服务
-func NewUserService(userRepo repository.UserRepository, jwtManager *auth.JWTManager) *UserService {
+func NewUserService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository, jwtManager *auth.JWTManager, denylist *auth.Denylist) *UserService {
 	return &UserService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		jwtManager:  jwtManager,
+		denylist:    denylist,
 	}
 }
 
@@ -37,19 +50,24 @@ type RegisterRequest struct {
 	Nickname string `json:"nickname" binding:"max=100"`
 	Email    string `json:"email" binding:"omitempty,email"`
 	Phone    string `json:"phone" binding:"omitempty,len=11"`
+	Device   string `json:"device" binding:"max=100"`
 }
 
 // LoginRequest 登录请求
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"max=100"` // 设备名称，显示在会话列表中
 }
 
 // LoginResponse 登录响应
 type LoginResponse struct {
-	Token     string     `json:"token"`
-	ExpiresAt int64      `json:"expires_at"`
-	User      *UserInfo  `json:"user"`
+	Token            string    `json:"token"`
+	ExpiresAt        int64     `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt int64     `json:"refresh_expires_at"`
+	SessionID        string    `json:"session_id"`
+	User             *UserInfo `json:"user"`
 }
 
 // UserInfo 用户信息
@@ -66,6 +84,7 @@ type UserInfo struct {
 	Coins      int    `json:"coins"`
 	VIPLevel   int    `json:"vip_level"`
 	Status     int    `json:"status"`
+	Role       string `json:"role"`
 	CreatedAt  string `json:"created_at"`
 }
 
@@ -84,12 +103,39 @@ func toUserInfo(user *models.User) *UserInfo {
 		Coins:      user.Coins,
 		VIPLevel:   user.VIPLevel,
 		Status:     user.Status,
+		Role:       user.Role,
 		CreatedAt:  user.CreatedAt.Format("2006-01-02 15:04:05"),
 	}
 }
 
 // Register 用户注册
-func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*LoginResponse, error) {
+func (s *UserService) Register(ctx context.Context, req *RegisterRequest, client ClientInfo) (*LoginResponse, error) {
+	user, err := s.CreateUser(ctx, req, auth.RoleUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Device == "" {
+		client.Device = req.Device
+	}
+
+	// 注册前针对该用户名的失败不应锁住新账号；同一IP的计数保持不变
+	if s.throttle != nil {
+		s.throttle.succeed(ctx, req.Username)
+	}
+
+	// 更新最后登录时间
+	s.userRepo.UpdateLastLogin(ctx, user.ID)
+
+	return s.startSession(ctx, user, client)
+}
+
+// CreateUser 创建指定角色的用户，不签发token（用于注册和 manage user create-admin）
+func (s *UserService) CreateUser(ctx context.Context, req *RegisterRequest, role string) (*models.User, error) {
+	if !auth.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
 	// 检查用户名是否已存在
 	_, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err == nil {
@@ -118,6 +164,7 @@ func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*Logi
 		Email:        req.Email,
 		Phone:        req.Phone,
 		Status:       1, // 默认正常
+		Role:         role,
 	}
 
 	if req.Nickname == "" {
@@ -128,32 +175,57 @@ func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*Logi
 		return nil, err
 	}
 
-	// 生成token
-	token, err := s.jwtManager.GenerateToken(user.ID, user.Username)
+	return user, nil
+}
+
+// SetRole 修改用户角色。已签发的token中带有旧角色，因此同时作废该用户的所有会话
+func (s *UserService) SetRole(ctx context.Context, userID uint, role string) (*UserInfo, error) {
+	if !auth.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
-		return nil, err
+		return nil, ErrUserNotFound
+	}
+	if user.Role == role {
+		return toUserInfo(user), nil
 	}
 
-	// 更新最后登录时间
-	s.userRepo.UpdateLastLogin(ctx, user.ID)
+	user.Role = role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if err := s.RevokeAllTokens(ctx, userID, "", "role_changed"); err != nil {
+		return nil, err
+	}
 
-	return &LoginResponse{
-		Token:     token,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour).Unix(),
-		User:      toUserInfo(user),
-	}, nil
+	return toUserInfo(user), nil
 }
 
 // Login 用户登录
-func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error) {
+func (s *UserService) Login(ctx context.Context, req *LoginRequest, client ClientInfo) (*LoginResponse, error) {
+	// 锁定期间不再校验密码
+	if s.throttle != nil {
+		if err := s.throttle.check(ctx, req.Username, client); err != nil {
+			return nil, err
+		}
+	}
+
 	// 查找用户
 	user, err := s.userRepo.GetByUsername(ctx, req.Username)
 	if err != nil {
+		if s.throttle != nil {
+			s.throttle.fail(ctx, req.Username, 0, "unknown_user", client)
+		}
 		return nil, ErrInvalidCredentials
 	}
 
 	// 验证密码
 	if !auth.VerifyPassword(user.PasswordHash, req.Password) {
+		if s.throttle != nil {
+			s.throttle.fail(ctx, req.Username, user.ID, "wrong_password", client)
+		}
 		return nil, ErrInvalidCredentials
 	}
 
@@ -162,20 +234,18 @@ func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		return nil, ErrUserDisabled
 	}
 
-	// 生成token
-	token, err := s.jwtManager.GenerateToken(user.ID, user.Username)
-	if err != nil {
-		return nil, err
+	if client.Device == "" {
+		client.Device = req.Device
+	}
+
+	if s.throttle != nil {
+		s.throttle.succeed(ctx, req.Username)
 	}
 
 	// 更新最后登录时间
 	s.userRepo.UpdateLastLogin(ctx, user.ID)
 
-	return &LoginResponse{
-		Token:     token,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour).Unix(),
-		User:      toUserInfo(user),
-	}, nil
+	return s.startSession(ctx, user, client)
 }
 
 // GetProfile 获取用户资料
@@ -187,11 +257,6 @@ func (s *UserService) GetProfile(ctx context.Context, userID uint) (*UserInfo, e
 	return toUserInfo(user), nil
 }
 
-// RefreshToken 刷新token
-func (s *UserService) RefreshToken(tokenString string) (string, error) {
-	return s.jwtManager.RefreshToken(tokenString)
-}
-
 // UpdateProfileRequest 更新资料请求
 type UpdateProfileRequest struct {
 	Nickname  string `json:"nickname" binding:"omitempty,max=100"`